@@ -33,6 +33,28 @@ func GetConfigPath(filename string) string {
 	return tmpPath
 }
 
+// GetCachePath returns the path to a file in the user's cache directory.
+// It checks $XDG_CACHE_HOME first, then falls back to ~/.cache, and finally to a temp directory.
+func GetCachePath(filename string) string {
+	if xdgCache, ok := os.LookupEnv("XDG_CACHE_HOME"); ok && xdgCache != "" {
+		return filepath.Join(xdgCache, "kat", filename)
+	}
+
+	usrHome, err := os.UserHomeDir()
+	if err == nil && usrHome != "" {
+		return filepath.Join(usrHome, ".cache", "kat", filename)
+	}
+
+	tmpPath := filepath.Join(os.TempDir(), "kat", "cache", filename)
+
+	slog.Warn("could not determine user cache directory, using temp path",
+		slog.String("path", tmpPath),
+		slog.Any("error", fmt.Errorf("$XDG_CACHE_HOME is unset, fall back to home directory: %w", err)),
+	)
+
+	return tmpPath
+}
+
 // ReadFile reads a file from disk with proper error handling.
 func ReadFile(path string) ([]byte, error) {
 	pathInfo, err := os.Stat(path)
@@ -76,6 +76,50 @@ func TestGetConfigPath(t *testing.T) {
 	}
 }
 
+//nolint:paralleltest // We need to set environment variables, so run tests sequentially.
+func TestGetCachePath(t *testing.T) {
+	tcs := map[string]struct {
+		setupEnv func(t *testing.T)
+		want     string
+	}{
+		"XDG_CACHE_HOME is set and not empty": {
+			setupEnv: func(t *testing.T) {
+				t.Helper()
+				t.Setenv("XDG_CACHE_HOME", "/custom/cache")
+			},
+			want: "/custom/cache/kat/renders",
+		},
+		"XDG_CACHE_HOME is empty and HOME is set": {
+			setupEnv: func(t *testing.T) {
+				t.Helper()
+				t.Setenv("XDG_CACHE_HOME", "")
+				t.Setenv("HOME", "/test/home")
+			},
+			want: "/test/home/.cache/kat/renders",
+		},
+		"XDG_CACHE_HOME is empty and HOME is empty": {
+			setupEnv: func(t *testing.T) {
+				t.Helper()
+				t.Setenv("XDG_CACHE_HOME", "")
+				t.Setenv("HOME", "")
+			},
+			want: filepath.Join(os.TempDir(), "kat", "cache", "renders"), //nolint:usetesting // Needs to equal host.
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			if tc.setupEnv != nil {
+				tc.setupEnv(t)
+			}
+
+			got := api.GetCachePath("renders")
+
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
 func TestReadFile(t *testing.T) {
 	t.Parallel()
 
@@ -389,9 +389,9 @@ func TestUnmarshalAndValidateDefaultConfig(t *testing.T) {
 	cmdErr := cfg.Command.Validate()
 	require.NoError(t, cmdErr, "embedded default config Command section should pass validation")
 
-	// Validate the UI configuration key binds.
-	err = cfg.UI.KeyBinds.Validate()
-	require.NoError(t, err, "embedded default config UI key binds should pass validation")
+	// Validate the UI configuration, including key binds.
+	err = cfg.UI.Validate()
+	require.NoError(t, err, "embedded default config UI section should pass validation")
 
 	// Verify essential config properties.
 	assert.Equal(t, "kat.jacobcolvin.com/v1beta1", cfg.GetAPIVersion())
@@ -443,8 +443,8 @@ func TestDefaultConfigFullPipeline(t *testing.T) {
 	cfg, err := cl.Load()
 	require.NoError(t, err)
 
-	// Validate UI KeyBinds (simulating the validation in main.go).
-	err = cfg.UI.KeyBinds.Validate()
+	// Validate the UI config (simulating the validation in main.go).
+	err = cfg.UI.Validate()
 	require.NoError(t, err)
 
 	// Test that the config can be marshaled back to YAML.
@@ -461,3 +461,60 @@ func TestDefaultConfigFullPipeline(t *testing.T) {
 	assert.Len(t, cfg2.Command.Profiles, len(cfg.Command.Profiles))
 	assert.Len(t, cfg2.Command.Rules, len(cfg.Command.Rules))
 }
+
+func TestConfig_SavedQueries(t *testing.T) {
+	t.Parallel()
+
+	tcs := map[string]struct {
+		queries string
+		wantErr string
+	}{
+		"valid queries": {
+			queries: `
+queries:
+  - query: kind=Deployment ns=prod
+    description: prod deployments
+    keys: [{code: "D"}]
+  - query: object.kind == "Secret"
+    keys: [{code: "S"}]
+`,
+		},
+		"invalid query": {
+			queries: `
+queries:
+  - query: object.kind ==
+    keys: [{code: "D"}]
+`,
+			wantErr: "invalid query",
+		},
+		"conflicting key": {
+			queries: `
+queries:
+  - query: kind=Deployment
+    keys: [{code: "/"}]
+`,
+			wantErr: "duplicate key binding found: /",
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			data := "apiVersion: kat.jacobcolvin.com/v1beta1\nkind: Configuration\n" + tc.queries
+
+			cfg, err := config.NewLoaderFromBytes([]byte(data), configs.New).Load()
+			require.NoError(t, err)
+
+			err = cfg.UI.Validate()
+			if tc.wantErr != "" {
+				require.ErrorContains(t, err, tc.wantErr)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Len(t, cfg.UI.Queries, 2)
+		})
+	}
+}
@@ -0,0 +1,487 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/aymanbagabas/go-udiff"
+	"github.com/spf13/cobra"
+
+	"github.com/macropower/kat/api/v1beta1/configs"
+	"github.com/macropower/kat/api/v1beta1/policies"
+	"github.com/macropower/kat/api/v1beta1/runtimeconfigs"
+	"github.com/macropower/kat/pkg/command"
+	"github.com/macropower/kat/pkg/kube"
+	"github.com/macropower/kat/pkg/policy"
+)
+
+const (
+	diffCmdExamples = `  # Compare the rendered output of two directories:
+  kat diff ./envs/staging ./envs/prod
+
+  # Compare the working tree against a git revision:
+  kat diff ./example/helm --ref main
+
+  # Compare two profiles for the same path:
+  kat diff ./example/helm --from-profile helm --to-profile task
+
+  # Set the extra arguments for both renders:
+  kat diff ./example/helm --ref HEAD~1 -- -f prod-values.yaml
+
+  # Only list which resources changed:
+  kat diff ./old ./new --summary`
+)
+
+// ErrResourcesDiffer is returned by the diff command when --exit-code is set
+// and the two renders are not identical.
+var ErrResourcesDiffer = errors.New("rendered resources differ")
+
+type DiffArgs struct {
+	*RootArgs
+
+	FromPath    string
+	ToPath      string
+	ConfigPath  string
+	Ref         string
+	Profile     string
+	FromProfile string
+	ToProfile   string
+	Args        []string
+	Summary     bool
+	ExitCode    bool
+	Trust       bool
+	NoTrust     bool
+}
+
+func NewDiffArgs(rootArgs *RootArgs) *DiffArgs {
+	return &DiffArgs{
+		RootArgs: rootArgs,
+	}
+}
+
+func (da *DiffArgs) AddFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&da.ConfigPath, "config", "", "Path to the kat configuration file")
+	cmd.Flags().StringVar(&da.Ref, "ref", "", "Compare the path against this git revision")
+	cmd.Flags().StringVar(&da.Profile, "profile", "", "Profile to use for both renders")
+	cmd.Flags().StringVar(&da.FromProfile, "from-profile", "", "Profile to use for the old render")
+	cmd.Flags().StringVar(&da.ToProfile, "to-profile", "", "Profile to use for the new render")
+	cmd.Flags().BoolVar(&da.Summary, "summary", false, "Only list added, removed and modified resources")
+	cmd.Flags().BoolVar(&da.ExitCode, "exit-code", false, "Exit with a non-zero status if there are differences")
+	cmd.Flags().BoolVar(&da.Trust, "trust", false, "Trust project configurations without prompting")
+	cmd.Flags().BoolVar(&da.NoTrust, "no-trust", false, "Skip project configurations without prompting")
+
+	cmd.MarkFlagsMutuallyExclusive("trust", "no-trust")
+	cmd.MarkFlagsMutuallyExclusive("profile", "from-profile")
+	cmd.MarkFlagsMutuallyExclusive("profile", "to-profile")
+
+	err := cmd.MarkFlagFilename("config", "yaml", "yml")
+	if err != nil {
+		panic(fmt.Errorf("mark config flag: %w", err))
+	}
+
+	err = cmd.RegisterFlagCompletionFunc("profile", da.profileCompletion)
+	if err != nil {
+		panic(fmt.Errorf("register profile completion: %w", err))
+	}
+
+	err = cmd.RegisterFlagCompletionFunc("from-profile", da.profileCompletion)
+	if err != nil {
+		panic(fmt.Errorf("register from-profile completion: %w", err))
+	}
+
+	err = cmd.RegisterFlagCompletionFunc("to-profile", da.profileCompletion)
+	if err != nil {
+		panic(fmt.Errorf("register to-profile completion: %w", err))
+	}
+}
+
+func (da *DiffArgs) profileCompletion(*cobra.Command, []string, string) ([]cobra.Completion, cobra.ShellCompDirective) {
+	return tryGetProfileNames(da.ConfigPath), cobra.ShellCompDirectiveNoFileComp
+}
+
+func NewDiffCmd(da *DiffArgs) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "diff <path> [path]",
+		Short: "Compare the rendered resources of two paths, profiles or git revisions",
+		Long: `Render twice and compare the resulting resources.
+
+Resources are matched by apiVersion, kind, namespace and name, and are
+reported as added, removed or modified.
+
+With two paths, the first path is the old render and the second path is the
+new render. With --ref, the path is rendered from the given git revision and
+compared against the working tree. With a single path and no --ref, the path
+is compared against itself, which is useful together with --from-profile and
+--to-profile.
+
+Each side is rendered with its own project configuration. With --ref, the
+configuration at the git revision is used if the project is trusted in the
+working tree, or if --trust is set.`,
+		Example: diffCmdExamples,
+		Args: func(cmd *cobra.Command, args []string) error {
+			dashPos := cmd.ArgsLenAtDash()
+
+			argsBeforeDash := args
+			if dashPos != -1 {
+				argsBeforeDash = args[:dashPos]
+			}
+
+			switch {
+			case len(argsBeforeDash) == 0:
+				return errors.New("requires at least 1 path")
+			case len(argsBeforeDash) > 2:
+				return fmt.Errorf("accepts at most 2 paths, received %d", len(argsBeforeDash))
+			case len(argsBeforeDash) == 2 && da.Ref != "":
+				return errors.New("--ref accepts only 1 path")
+			}
+
+			return nil
+		},
+		ValidArgsFunction: func(_ *cobra.Command, args []string, _ string) ([]cobra.Completion, cobra.ShellCompDirective) {
+			if len(args) < 2 {
+				return nil, cobra.ShellCompDirectiveFilterDirs
+			}
+
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			dashPos := cmd.ArgsLenAtDash()
+
+			argsBeforeDash := args
+			if dashPos != -1 {
+				argsBeforeDash = args[:dashPos]
+				da.Args = args[dashPos:]
+			}
+
+			da.FromPath = argsBeforeDash[0]
+			da.ToPath = argsBeforeDash[0]
+
+			if len(argsBeforeDash) > 1 {
+				da.ToPath = argsBeforeDash[1]
+			}
+
+			if da.Profile != "" {
+				da.FromProfile = da.Profile
+				da.ToProfile = da.Profile
+			}
+
+			return runDiff(cmd, da)
+		},
+	}
+	da.AddFlags(cmd)
+
+	bindEnvVars(cmd)
+
+	return cmd
+}
+
+func runDiff(cmd *cobra.Command, da *DiffArgs) error {
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	configPath := da.ConfigPath
+	if configPath == "" {
+		configPath = configs.GetPath()
+	}
+
+	trustMode := policy.TrustModePrompt
+	if da.Trust {
+		trustMode = policy.TrustModeAllow
+	}
+
+	if da.NoTrust {
+		trustMode = policy.TrustModeSkip
+	}
+
+	// Each side is rendered with its own project configuration. The new side
+	// is loaded first, so that a trust decision made for it also applies to
+	// the configuration at the git revision.
+	toCfg, err := loadDiffConfig(configPath, da.ToPath, trustMode)
+	if err != nil {
+		return err
+	}
+
+	var from command.Output
+
+	if da.Ref != "" {
+		worktree, path, cleanup, err := checkoutGitRef(ctx, da.FromPath, da.Ref)
+		if err != nil {
+			return err
+		}
+		defer cleanup()
+
+		fromTrustMode := refTrustMode(da.FromPath, trustMode)
+
+		fromCfg, err := loadDiffConfig(configPath, filepath.Join(worktree, path), fromTrustMode)
+		if err != nil {
+			return fmt.Errorf("%w at %q", err, da.Ref)
+		}
+
+		// Render within the worktree, rather than the working directory.
+		root, err := os.OpenRoot(worktree)
+		if err != nil {
+			return fmt.Errorf("open worktree %q: %w", worktree, err)
+		}
+		defer root.Close() //nolint:errcheck // Ignore errors.
+
+		from, err = renderForDiff(ctx, root, path, da.FromProfile, fromCfg, da.Args)
+		if err != nil {
+			return fmt.Errorf("render %s at %q: %w", da.FromPath, da.Ref, err)
+		}
+	} else {
+		fromCfg, err := loadDiffConfig(configPath, da.FromPath, trustMode)
+		if err != nil {
+			return err
+		}
+
+		from, err = renderForDiff(ctx, nil, da.FromPath, da.FromProfile, fromCfg, da.Args)
+		if err != nil {
+			return fmt.Errorf("render %s: %w", da.FromPath, err)
+		}
+	}
+
+	to, err := renderForDiff(ctx, nil, da.ToPath, da.ToProfile, toCfg, da.Args)
+	if err != nil {
+		return fmt.Errorf("render %s: %w", da.ToPath, err)
+	}
+
+	diffs := kube.DiffResources(from.Resources, to.Resources)
+
+	changed, err := writeResourceDiffs(cmd.OutOrStdout(), diffs, da.Summary)
+	if err != nil {
+		return err
+	}
+
+	if da.ExitCode && changed {
+		return ErrResourcesDiffer
+	}
+
+	return nil
+}
+
+// loadDiffConfig loads and validates the configuration for one side of the
+// diff, merging the project configuration found for path, if it is trusted.
+func loadDiffConfig(configPath, path string, tm policy.TrustMode) (*configs.Config, error) {
+	cfg, _, err := loadAnyRuntimeConfigs(configPath, path, tm)
+	if err != nil {
+		return nil, err
+	}
+
+	err = cfg.Validate()
+	if err != nil {
+		return nil, fmt.Errorf("invalid config: %w", err)
+	}
+
+	return cfg, nil
+}
+
+// refTrustMode returns the trust mode for the project configuration of path
+// at a git revision. The temporary worktree cannot be added to the trust list,
+// so its configuration is trusted only if the configuration of path in the
+// working tree is trusted, or if --trust was given.
+func refTrustMode(path string, tm policy.TrustMode) policy.TrustMode {
+	switch tm {
+	case policy.TrustModeAllow, policy.TrustModeAllowOnce:
+		return policy.TrustModeAllowOnce
+	case policy.TrustModeSkip:
+		return policy.TrustModeSkip
+	case policy.TrustModePrompt:
+	}
+
+	cfgPath, err := runtimeconfigs.Find(path)
+	if err != nil || cfgPath == "" {
+		return policy.TrustModeSkip
+	}
+
+	if loadPolicyOrDefault(policies.GetPath()).IsTrusted(filepath.Dir(cfgPath)) {
+		return policy.TrustModeAllowOnce
+	}
+
+	return policy.TrustModeSkip
+}
+
+// renderForDiff runs the command runner once for the given path and returns
+// the output. If profileName is empty, the profile is selected using rules.
+// The path is relative to root, or to the working directory if root is nil.
+func renderForDiff(
+	ctx context.Context,
+	root *os.Root,
+	path, profileName string,
+	cfg *configs.Config,
+	args []string,
+) (command.Output, error) {
+	cr, err := setupCommandRunner(path, cfg, &RunArgs{
+		CommandOrProfile: profileName,
+		Args:             args,
+		Root:             root,
+	})
+	if err != nil {
+		return command.Output{}, fmt.Errorf("create command runner: %w", err)
+	}
+	defer cr.Close()
+
+	out := cr.RunContext(ctx)
+	if out.Error != nil {
+		if out.Stderr != "" {
+			return out, fmt.Errorf("%w\n%s", out.Error, out.Stderr)
+		}
+
+		return out, out.Error
+	}
+
+	return out, nil
+}
+
+// writeResourceDiffs writes a unified diff for each added, removed or
+// modified resource. If summary is true, only one line per resource is
+// written. It reports whether any resource differed.
+func writeResourceDiffs(w io.Writer, diffs []kube.ResourceDiff, summary bool) (bool, error) {
+	var changed bool
+
+	for _, d := range diffs {
+		if d.Type == kube.DiffUnchanged {
+			continue
+		}
+
+		changed = true
+		id := d.Metadata.String()
+
+		if summary {
+			_, err := fmt.Fprintf(w, "%s %s\n", diffSymbol(d.Type), id)
+			if err != nil {
+				return changed, fmt.Errorf("write summary: %w", err)
+			}
+
+			continue
+		}
+
+		var (
+			oldLabel, newLabel     = "a/" + id, "b/" + id
+			oldContent, newContent string
+		)
+
+		if d.Old != nil {
+			oldContent = d.Old.Content()
+		} else {
+			oldLabel = "/dev/null"
+		}
+
+		if d.New != nil {
+			newContent = d.New.Content()
+		} else {
+			newLabel = "/dev/null"
+		}
+
+		_, err := io.WriteString(w, udiff.Unified(oldLabel, newLabel, oldContent, newContent))
+		if err != nil {
+			return changed, fmt.Errorf("write diff: %w", err)
+		}
+	}
+
+	return changed, nil
+}
+
+func diffSymbol(t kube.DiffType) string {
+	switch t {
+	case kube.DiffAdded:
+		return "+"
+	case kube.DiffRemoved:
+		return "-"
+	case kube.DiffModified:
+		return "~"
+	case kube.DiffUnchanged:
+	}
+
+	return " "
+}
+
+// checkoutGitRef checks out ref into a temporary git worktree. It returns
+// the worktree directory, the path relative to the worktree that corresponds
+// to path, and a function that removes the worktree.
+func checkoutGitRef(ctx context.Context, path, ref string) (string, string, func(), error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return "", "", nil, fmt.Errorf("resolve path %q: %w", path, err)
+	}
+
+	dir := absPath
+
+	fi, err := os.Stat(absPath)
+	if err != nil {
+		return "", "", nil, fmt.Errorf("stat path: %w", err)
+	}
+
+	if !fi.IsDir() {
+		dir = filepath.Dir(absPath)
+	}
+
+	toplevel, err := git(ctx, dir, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return "", "", nil, err
+	}
+
+	// Resolve symlinks so that the relative path is computed consistently
+	// with git's view of the repository.
+	realPath, err := filepath.EvalSymlinks(absPath)
+	if err != nil {
+		return "", "", nil, fmt.Errorf("resolve path %q: %w", path, err)
+	}
+
+	realToplevel, err := filepath.EvalSymlinks(toplevel)
+	if err != nil {
+		return "", "", nil, fmt.Errorf("resolve path %q: %w", toplevel, err)
+	}
+
+	relPath, err := filepath.Rel(realToplevel, realPath)
+	if err != nil {
+		return "", "", nil, fmt.Errorf("get path relative to repository: %w", err)
+	}
+
+	tmpDir, err := os.MkdirTemp("", "kat-diff-")
+	if err != nil {
+		return "", "", nil, fmt.Errorf("create temporary directory: %w", err)
+	}
+
+	worktree := filepath.Join(tmpDir, "worktree")
+
+	_, err = git(ctx, toplevel, "worktree", "add", "--detach", worktree, ref)
+	if err != nil {
+		_ = os.RemoveAll(tmpDir) //nolint:errcheck // Best-effort cleanup.
+
+		return "", "", nil, err
+	}
+
+	cleanup := func() {
+		_, _ = git(context.WithoutCancel(ctx), toplevel, "worktree", "remove", "--force", worktree) //nolint:errcheck // Best-effort cleanup.
+
+		_ = os.RemoveAll(tmpDir) //nolint:errcheck // Best-effort cleanup.
+	}
+
+	return worktree, relPath, cleanup, nil
+}
+
+// git runs a git command in dir and returns its trimmed stdout.
+func git(ctx context.Context, dir string, args ...string) (string, error) {
+	var stdout, stderr bytes.Buffer
+
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	err := cmd.Run()
+	if err != nil {
+		return "", fmt.Errorf("git %s: %w: %s", strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+	}
+
+	return strings.TrimSpace(stdout.String()), nil
+}
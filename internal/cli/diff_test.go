@@ -0,0 +1,163 @@
+package cli_test
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/macropower/kat/api/v1beta1/policies"
+	"github.com/macropower/kat/internal/cli"
+)
+
+//nolint:paralleltest // Changes the working directory and environment.
+func TestDiffCmd(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	const configMap = "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: app\ndata:\n  env: %s\n"
+
+	// The project configuration renders the app with the given env.
+	const runtimeConfig = "apiVersion: kat.jacobcolvin.com/v1beta1\nkind: RuntimeConfig\n" +
+		"profiles:\n  sed:\n    command: sed\n    args: [-e, s/old/%s/, app.yaml]\n"
+
+	// Commit "old" to all apps, then change one of them in the working tree.
+	dir := t.TempDir()
+	for _, app := range []string{"changed", "unchanged", "configured"} {
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, app), 0o755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, app, "app.yaml"),
+			fmt.Appendf(nil, configMap, "old"), 0o644))
+	}
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "configured", ".katrc.yaml"),
+		fmt.Appendf(nil, runtimeConfig, "committed"), 0o644))
+
+	for _, args := range [][]string{
+		{"init", "--quiet"},
+		{"add", "."},
+		{"-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "--quiet", "-m", "init"},
+	} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(out))
+	}
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "changed", "app.yaml"),
+		fmt.Appendf(nil, configMap, "new"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "configured", ".katrc.yaml"),
+		fmt.Appendf(nil, runtimeConfig, "working"), 0o644))
+
+	t.Chdir(dir)
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	// Create an empty policy, so that trusted projects can be saved.
+	pol, err := policies.New().MarshalYAML()
+	require.NoError(t, err)
+	require.NoError(t, os.MkdirAll(filepath.Dir(policies.GetPath()), 0o755))
+	require.NoError(t, os.WriteFile(policies.GetPath(), pol, 0o600))
+
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(configPath,
+		[]byte("apiVersion: kat.jacobcolvin.com/v1beta1\nkind: Configuration\n"), 0o644))
+
+	execute := func(args ...string) (string, error) {
+		var stdout bytes.Buffer
+
+		cmd := cli.NewDiffCmd(cli.NewDiffArgs(cli.NewRootArgs()))
+		cmd.SilenceUsage = true
+		cmd.SilenceErrors = true
+		cmd.SetOut(&stdout)
+		cmd.SetArgs(append([]string{"--config", configPath}, args...))
+
+		err := cmd.Execute()
+
+		return stdout.String(), err
+	}
+
+	// Render each app with cat, without the project configuration.
+	render := []string{"--no-trust", "--profile", "cat", "--", "app.yaml"}
+
+	tcs := map[string]struct {
+		wantErr error
+		want    string
+		args    []string
+	}{
+		"ref": {
+			args: []string{"changed", "--ref", "HEAD"},
+			want: "--- a/v1/ConfigMap/app\n" +
+				"+++ b/v1/ConfigMap/app\n" +
+				"@@ -3,4 +3,4 @@\n" +
+				" metadata:\n" +
+				"   name: app\n" +
+				" data:\n" +
+				"-  env: old\n" +
+				"+  env: new\n",
+		},
+		"ref summary": {
+			args: []string{"changed", "--ref", "HEAD", "--summary"},
+			want: "~ v1/ConfigMap/app\n",
+		},
+		"ref without changes": {
+			args: []string{"unchanged", "--ref", "HEAD"},
+		},
+		"paths summary": {
+			args: []string{"unchanged", "changed", "--summary"},
+			want: "~ v1/ConfigMap/app\n",
+		},
+		"exit code with changes": {
+			args:    []string{"changed", "--ref", "HEAD", "--summary", "--exit-code"},
+			want:    "~ v1/ConfigMap/app\n",
+			wantErr: cli.ErrResourcesDiffer,
+		},
+		"exit code without changes": {
+			args: []string{"unchanged", "--ref", "HEAD", "--exit-code"},
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			got, err := execute(append(tc.args, render...)...)
+			if tc.wantErr != nil {
+				require.ErrorIs(t, err, tc.wantErr)
+			} else {
+				require.NoError(t, err)
+			}
+
+			assert.Equal(t, tc.want, got)
+		})
+	}
+
+	t.Run("unknown ref", func(t *testing.T) {
+		_, err := execute(append([]string{"changed", "--ref", "missing"}, render...)...)
+		require.ErrorContains(t, err, "missing")
+	})
+
+	// Each side uses its own project configuration. The configuration at the
+	// ref is trusted with --trust, and afterwards because the project is now
+	// trusted in the working tree.
+	for _, name := range []string{"ref configuration with trust flag", "ref configuration of trusted project"} {
+		t.Run(name, func(t *testing.T) {
+			args := []string{"configured", "--ref", "HEAD", "--profile", "sed"}
+			if name == "ref configuration with trust flag" {
+				args = append(args, "--trust")
+			}
+
+			got, err := execute(args...)
+			require.NoError(t, err)
+			assert.Contains(t, got, "-  env: committed\n+  env: working\n")
+		})
+	}
+
+	// The working directory is not changed by rendering a ref.
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+	assert.Equal(t, dir, wd)
+}
@@ -0,0 +1,371 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/macropower/kat/api/v1beta1"
+	"github.com/macropower/kat/api/v1beta1/configs"
+	"github.com/macropower/kat/pkg/command"
+	"github.com/macropower/kat/pkg/kube"
+	"github.com/macropower/kat/pkg/policy"
+)
+
+const (
+	matrixCmdExamples = `  # Render every variant of the matched profile and compare them:
+  kat matrix ./example/helm
+
+  # Only compare some variants:
+  kat matrix ./example/helm --variant values-dev.yaml --variant values-prod.yaml
+
+  # Fail if any resource differs between variants:
+  kat matrix ./example/kustomize --exit-code
+
+  # Write the comparison as JSON:
+  kat matrix ./example/helm --output json`
+
+	// matrixResultKind is the kind of the [MatrixResult] object.
+	matrixResultKind = "MatrixResult"
+
+	// matrixAbsent is written in place of values that are absent.
+	matrixAbsent = "-"
+)
+
+// ErrVariantsDiffer is returned by the matrix command when --exit-code is
+// set and the variants did not render identical resources.
+var ErrVariantsDiffer = errors.New("rendered variants differ")
+
+// MatrixOutputFormats contains all valid output formats for the matrix
+// command. The default, "text", writes a presence table followed by the
+// differing fields of each resource.
+var MatrixOutputFormats = []string{"text", "yaml", "json"}
+
+type MatrixArgs struct {
+	*RootArgs
+
+	Path       string
+	ConfigPath string
+	Profile    string
+	Output     string
+	Variants   []string
+	Args       []string
+	ExitCode   bool
+	Trust      bool
+	NoTrust    bool
+}
+
+func NewMatrixArgs(rootArgs *RootArgs) *MatrixArgs {
+	return &MatrixArgs{
+		RootArgs: rootArgs,
+	}
+}
+
+func (ma *MatrixArgs) AddFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&ma.ConfigPath, "config", "", "Path to the kat configuration file")
+	cmd.Flags().StringVar(&ma.Profile, "profile", "", "Profile to render the variants of")
+	cmd.Flags().StringArrayVar(&ma.Variants, "variant", nil, "Variant to render (repeatable, default all)")
+	cmd.Flags().StringVarP(&ma.Output, "output", "o", MatrixOutputFormats[0],
+		"Output format ("+strings.Join(MatrixOutputFormats, ", ")+")")
+	cmd.Flags().BoolVar(&ma.ExitCode, "exit-code", false, "Exit with a non-zero status if the variants differ")
+	cmd.Flags().BoolVar(&ma.Trust, "trust", false, "Trust project configurations without prompting")
+	cmd.Flags().BoolVar(&ma.NoTrust, "no-trust", false, "Skip project configurations without prompting")
+
+	cmd.MarkFlagsMutuallyExclusive("trust", "no-trust")
+
+	err := cmd.MarkFlagFilename("config", "yaml", "yml")
+	if err != nil {
+		panic(fmt.Errorf("mark config flag: %w", err))
+	}
+
+	err = cmd.RegisterFlagCompletionFunc("profile", ma.profileCompletion)
+	if err != nil {
+		panic(fmt.Errorf("register profile completion: %w", err))
+	}
+
+	err = cmd.RegisterFlagCompletionFunc("output", matrixOutputCompletion)
+	if err != nil {
+		panic(fmt.Errorf("register output completion: %w", err))
+	}
+}
+
+func (ma *MatrixArgs) profileCompletion(*cobra.Command, []string, string) ([]cobra.Completion, cobra.ShellCompDirective) {
+	return tryGetProfileNames(ma.ConfigPath), cobra.ShellCompDirectiveNoFileComp
+}
+
+func matrixOutputCompletion(*cobra.Command, []string, string) ([]cobra.Completion, cobra.ShellCompDirective) {
+	return MatrixOutputFormats, cobra.ShellCompDirectiveNoFileComp
+}
+
+func NewMatrixCmd(ma *MatrixArgs) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "matrix <path>",
+		Short: "Render every variant of a profile and compare the results",
+		Long: `Render each variant of the profile concurrently and compare the resulting
+resources.
+
+Resources are matched by apiVersion, kind, namespace and name. The report
+shows which variants render each resource, followed by the value of every
+field that differs between them.
+
+Variants are defined by the profile's variants setting, e.g. one variant per
+Helm values file or Kustomize overlay.`,
+		Example: matrixCmdExamples,
+		Args: func(cmd *cobra.Command, args []string) error {
+			dashPos := cmd.ArgsLenAtDash()
+
+			argsBeforeDash := args
+			if dashPos != -1 {
+				argsBeforeDash = args[:dashPos]
+			}
+
+			if len(argsBeforeDash) != 1 {
+				return fmt.Errorf("accepts 1 path, received %d", len(argsBeforeDash))
+			}
+
+			if !slices.Contains(MatrixOutputFormats, ma.Output) {
+				return fmt.Errorf("%w: %q", ErrInvalidOutputFormat, ma.Output)
+			}
+
+			return nil
+		},
+		ValidArgsFunction: func(_ *cobra.Command, args []string, _ string) ([]cobra.Completion, cobra.ShellCompDirective) {
+			if len(args) == 0 {
+				return nil, cobra.ShellCompDirectiveFilterDirs
+			}
+
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			dashPos := cmd.ArgsLenAtDash()
+			if dashPos != -1 {
+				ma.Args = args[dashPos:]
+			}
+
+			ma.Path = args[0]
+
+			return runMatrix(cmd, ma)
+		},
+	}
+	ma.AddFlags(cmd)
+
+	bindEnvVars(cmd)
+
+	return cmd
+}
+
+// MatrixResult describes a matrix render for structured output formats.
+type MatrixResult struct {
+	v1beta1.TypeMeta `json:",inline"`
+	*kube.Matrix     `json:",inline"`
+
+	// Errors contains the error of each variant that failed to render, keyed
+	// by variant name.
+	Errors  map[string]string `json:"errors,omitempty"`
+	Profile string            `json:"profile,omitempty"`
+	Path    string            `json:"path"`
+}
+
+func runMatrix(cmd *cobra.Command, ma *MatrixArgs) error {
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	configPath := ma.ConfigPath
+	if configPath == "" {
+		configPath = configs.GetPath()
+	}
+
+	trustMode := policy.TrustModePrompt
+	if ma.Trust {
+		trustMode = policy.TrustModeAllow
+	}
+
+	if ma.NoTrust {
+		trustMode = policy.TrustModeSkip
+	}
+
+	cfg, _, err := loadAnyRuntimeConfigs(configPath, ma.Path, trustMode)
+	if err != nil {
+		return err
+	}
+
+	err = cfg.Validate()
+	if err != nil {
+		return fmt.Errorf("invalid config: %w", err)
+	}
+
+	cr, err := setupCommandRunner(ma.Path, cfg, &RunArgs{
+		CommandOrProfile: ma.Profile,
+		Args:             ma.Args,
+	})
+	if err != nil {
+		return fmt.Errorf("create command runner: %w", err)
+	}
+	defer cr.Close()
+
+	mo, err := cr.RunMatrixContext(ctx, ma.Variants...)
+	if err != nil {
+		return fmt.Errorf("render %s: %w", ma.Path, err)
+	}
+
+	profileName, _ := cr.GetCurrentProfile()
+
+	err = writeMatrix(cmd.OutOrStdout(), ma.Output, newMatrixResult(mo, profileName, ma.Path))
+	if err != nil {
+		return err
+	}
+
+	err = mo.Err()
+	if err != nil {
+		return err
+	}
+
+	if ma.ExitCode && !mo.Matrix.Consistent() {
+		return ErrVariantsDiffer
+	}
+
+	return nil
+}
+
+func newMatrixResult(mo command.MatrixOutput, profileName, path string) *MatrixResult {
+	mr := &MatrixResult{
+		TypeMeta: v1beta1.TypeMeta{
+			APIVersion: v1beta1.APIVersion,
+			Kind:       matrixResultKind,
+		},
+		Matrix:  mo.Matrix,
+		Profile: profileName,
+		Path:    path,
+	}
+
+	for i, out := range mo.Outputs {
+		if out.Error == nil {
+			continue
+		}
+
+		if mr.Errors == nil {
+			mr.Errors = map[string]string{}
+		}
+
+		mr.Errors[mo.Matrix.Names[i]] = out.Error.Error()
+	}
+
+	return mr
+}
+
+// writeMatrix writes the matrix result to w using the given format.
+func writeMatrix(w io.Writer, format string, mr *MatrixResult) error {
+	switch format {
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+
+		err := enc.Encode(mr)
+		if err != nil {
+			return fmt.Errorf("encode json: %w", err)
+		}
+
+		return nil
+
+	case "yaml":
+		b, err := mr.YAML()
+		if err != nil {
+			return err //nolint:wrapcheck // Already wrapped.
+		}
+
+		_, err = w.Write(b)
+		if err != nil {
+			return fmt.Errorf("write yaml: %w", err)
+		}
+
+		return nil
+
+	case "text":
+		return writeMatrixText(w, mr.Matrix)
+	}
+
+	return fmt.Errorf("%w: %q", ErrInvalidOutputFormat, format)
+}
+
+// writeMatrixText writes a table with one row per resource and one column
+// per variant. Each cell is "✓" if the variant renders the resource, "~" if
+// the resource's fields differ between variants, or "-" if the variant does
+// not render the resource. The table is followed by the values of each
+// differing field, one line per variant.
+func writeMatrixText(w io.Writer, m *kube.Matrix) error {
+	var b strings.Builder
+
+	b.WriteString("RESOURCE\t" + strings.Join(m.Names, "\t") + "\n")
+
+	for _, r := range m.Resources {
+		b.WriteString(r.Metadata.String())
+
+		for _, present := range r.Present {
+			switch {
+			case !present:
+				b.WriteString("\t" + matrixAbsent)
+			case len(r.Fields) > 0:
+				b.WriteString("\t~")
+			default:
+				b.WriteString("\t✓")
+			}
+		}
+
+		b.WriteString("\n")
+	}
+
+	for _, r := range m.Resources {
+		if len(r.Fields) == 0 {
+			continue
+		}
+
+		fmt.Fprintf(&b, "\n%s\n", r.Metadata)
+
+		for _, f := range r.Fields {
+			fmt.Fprintf(&b, "  %s\n", f.Path)
+
+			for i, name := range m.Names {
+				if r.Present[i] {
+					fmt.Fprintf(&b, "    %s:\t%s\n", name, formatMatrixValue(f.Values[i]))
+				}
+			}
+		}
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	_, err := io.WriteString(tw, b.String())
+	if err != nil {
+		return fmt.Errorf("write matrix: %w", err)
+	}
+
+	err = tw.Flush()
+	if err != nil {
+		return fmt.Errorf("write matrix: %w", err)
+	}
+
+	return nil
+}
+
+// formatMatrixValue formats a field value as compact JSON.
+func formatMatrixValue(v any) string {
+	if v == nil {
+		return matrixAbsent
+	}
+
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+
+	return string(b)
+}
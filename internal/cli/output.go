@@ -0,0 +1,321 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/macropower/kat/api/v1beta1"
+	"github.com/macropower/kat/pkg/command"
+	"github.com/macropower/kat/pkg/kube"
+)
+
+// OutputFormat controls how rendered resources are written when kat is not
+// running interactively.
+type OutputFormat string
+
+const (
+	// OutputFormatRaw writes the command's stdout and stderr unchanged.
+	OutputFormatRaw OutputFormat = "raw"
+	// OutputFormatJSON writes a single [RenderResult] JSON object.
+	OutputFormatJSON OutputFormat = "json"
+	// OutputFormatNDJSON writes one JSON object per resource, followed by a
+	// [RenderResult] without resources.
+	OutputFormatNDJSON OutputFormat = "ndjson"
+	// OutputFormatYAML writes a `v1/List` containing all resources.
+	OutputFormatYAML OutputFormat = "yaml"
+	// OutputFormatTable writes one `kind namespace name` row per resource.
+	OutputFormatTable OutputFormat = "table"
+)
+
+// renderResultKind is the kind of the [RenderResult] object.
+const renderResultKind = "RenderResult"
+
+var (
+	// ErrInvalidOutputFormat is returned for unknown output formats.
+	ErrInvalidOutputFormat = errors.New("invalid output format")
+	// ErrValidationFailed is returned when rendered resources have
+	// error diagnostics.
+	ErrValidationFailed = errors.New("validation failed")
+)
+
+// OutputFormats contains all valid output formats.
+var OutputFormats = []OutputFormat{
+	OutputFormatRaw,
+	OutputFormatJSON,
+	OutputFormatNDJSON,
+	OutputFormatYAML,
+	OutputFormatTable,
+}
+
+// ParseOutputFormat parses s into an [OutputFormat]. An empty string is
+// parsed as [OutputFormatRaw].
+func ParseOutputFormat(s string) (OutputFormat, error) {
+	if s == "" {
+		return OutputFormatRaw, nil
+	}
+
+	for _, f := range OutputFormats {
+		if string(f) == s {
+			return f, nil
+		}
+	}
+
+	return "", fmt.Errorf("%w: %q", ErrInvalidOutputFormat, s)
+}
+
+func outputFormatCompletion(*cobra.Command, []string, string) ([]cobra.Completion, cobra.ShellCompDirective) {
+	completions := make([]cobra.Completion, 0, len(OutputFormats))
+	for _, f := range OutputFormats {
+		completions = append(completions, string(f))
+	}
+
+	return completions, cobra.ShellCompDirectiveNoFileComp
+}
+
+// RenderResult describes a single render for structured output formats.
+type RenderResult struct {
+	v1beta1.TypeMeta `json:",inline"`
+
+	Timestamp   time.Time            `json:"timestamp"`
+	Profile     string               `json:"profile,omitempty"`
+	Path        string               `json:"path"`
+	Duration    string               `json:"duration"`
+	Error       string               `json:"error,omitempty"`
+	Stderr      string               `json:"stderr,omitempty"`
+	Warnings    []command.Warning    `json:"warnings,omitempty"`
+	Resources   []*kube.Object       `json:"resources,omitempty"`
+	Diagnostics []ResourceDiagnostic `json:"diagnostics,omitempty"`
+	Count       int                  `json:"count"`
+}
+
+// ResourceDiagnostic is a [kube.Diagnostic] for a specific resource.
+type ResourceDiagnostic struct {
+	kube.Diagnostic `json:",inline"`
+
+	// Resource identifies the resource, see [kube.ResourceMetadata.String].
+	Resource string `json:"resource"`
+}
+
+// NewRenderResult creates a [RenderResult] from the output of a render.
+func NewRenderResult(run command.Output, profileName, path string, duration time.Duration) *RenderResult {
+	rr := &RenderResult{
+		TypeMeta: v1beta1.TypeMeta{
+			APIVersion: v1beta1.APIVersion,
+			Kind:       renderResultKind,
+		},
+		Timestamp: run.Timestamp,
+		Profile:   profileName,
+		Path:      path,
+		Duration:  duration.Round(time.Millisecond).String(),
+		Stderr:    run.Stderr,
+		Warnings:  run.Warnings,
+		Resources: make([]*kube.Object, 0, len(run.Resources)),
+		Count:     len(run.Resources),
+	}
+
+	if run.Error != nil {
+		rr.Error = run.Error.Error()
+	}
+
+	for _, r := range run.Resources {
+		rr.Resources = append(rr.Resources, r.Object)
+
+		for _, d := range r.Diagnostics {
+			rr.Diagnostics = append(rr.Diagnostics, ResourceDiagnostic{
+				Diagnostic: d,
+				Resource:   r.Object.GetMetadata().String(),
+			})
+		}
+	}
+
+	return rr
+}
+
+// writeResult writes the render result to w using the given format.
+func writeResult(w io.Writer, format OutputFormat, rr *RenderResult, run command.Output) error {
+	switch format {
+	case OutputFormatJSON:
+		return writeJSON(w, rr)
+	case OutputFormatNDJSON:
+		return writeNDJSON(w, rr)
+	case OutputFormatYAML:
+		return writeYAMLList(w, rr, run.Resources)
+	case OutputFormatTable:
+		return writeTable(w, run.Resources)
+	case OutputFormatRaw:
+		_, err := io.WriteString(w, run.Stdout)
+		if err != nil {
+			return fmt.Errorf("write to stdout: %w", err)
+		}
+
+		return nil
+	}
+
+	return fmt.Errorf("%w: %q", ErrInvalidOutputFormat, format)
+}
+
+func writeJSON(w io.Writer, rr *RenderResult) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+
+	err := enc.Encode(rr)
+	if err != nil {
+		return fmt.Errorf("encode json: %w", err)
+	}
+
+	return nil
+}
+
+func writeNDJSON(w io.Writer, rr *RenderResult) error {
+	enc := json.NewEncoder(w)
+
+	for _, obj := range rr.Resources {
+		err := enc.Encode(obj)
+		if err != nil {
+			return fmt.Errorf("encode json: %w", err)
+		}
+	}
+
+	// The final line is the result without resources, so that consumers can
+	// read the profile, timing and error details.
+	summary := *rr
+	summary.Resources = nil
+
+	err := enc.Encode(summary)
+	if err != nil {
+		return fmt.Errorf("encode json: %w", err)
+	}
+
+	return nil
+}
+
+// writeYAMLList writes the resources as a `v1/List`. Each item keeps the
+// original formatting of its document. The render details are written as
+// leading comments, since lists cannot carry annotations.
+func writeYAMLList(w io.Writer, rr *RenderResult, resources []*kube.Resource) error {
+	b := strings.Builder{}
+
+	b.WriteString("# profile: " + rr.Profile + "\n")
+	b.WriteString("# path: " + rr.Path + "\n")
+	b.WriteString("# timestamp: " + rr.Timestamp.Format(time.RFC3339) + "\n")
+	b.WriteString("# duration: " + rr.Duration + "\n")
+
+	if rr.Error != "" {
+		for line := range strings.SplitSeq(strings.TrimSpace(rr.Error), "\n") {
+			b.WriteString("# error: " + line + "\n")
+		}
+	}
+
+	for _, w := range rr.Warnings {
+		b.WriteString("# warning: " + w.String() + "\n")
+	}
+
+	b.WriteString("apiVersion: v1\nkind: List\n")
+
+	if len(resources) == 0 {
+		b.WriteString("items: []\n")
+	} else {
+		b.WriteString("items:\n")
+	}
+
+	for _, r := range resources {
+		content := strings.TrimSuffix(r.Content(), "\n")
+		for i, line := range strings.Split(content, "\n") {
+			switch {
+			case i == 0:
+				b.WriteString("  - " + line)
+			case line == "":
+				// Avoid trailing whitespace in block scalars.
+			default:
+				b.WriteString("    " + line)
+			}
+
+			b.WriteString("\n")
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	if err != nil {
+		return fmt.Errorf("write yaml: %w", err)
+	}
+
+	return nil
+}
+
+// writeDiagnostics writes a report with one `severity: resource: [source]
+// path: message` line per diagnostic, followed by a summary. Nothing is
+// written if there are no diagnostics.
+func writeDiagnostics(w io.Writer, resources []*kube.Resource) error {
+	var (
+		b        strings.Builder
+		affected int
+	)
+
+	for _, r := range resources {
+		if len(r.Diagnostics) > 0 {
+			affected++
+		}
+
+		for _, d := range r.Diagnostics {
+			fmt.Fprintf(&b, "%s: %s: [%s] %s\n", d.Severity, r.Object.GetMetadata(), d.Source, d)
+		}
+	}
+
+	if affected == 0 {
+		return nil
+	}
+
+	fmt.Fprintf(&b, "%d error(s), %d warning(s) in %d resource(s)\n",
+		kube.CountDiagnostics(resources, kube.SeverityError),
+		kube.CountDiagnostics(resources, kube.SeverityWarning),
+		affected)
+
+	_, err := io.WriteString(w, b.String())
+	if err != nil {
+		return fmt.Errorf("write diagnostics: %w", err)
+	}
+
+	return nil
+}
+
+// checkDiagnostics returns an [ErrValidationFailed] error if any resource has
+// error diagnostics.
+func checkDiagnostics(resources []*kube.Resource) error {
+	n := kube.CountDiagnostics(resources, kube.SeverityError)
+	if n > 0 {
+		return fmt.Errorf("%w: %d error(s)", ErrValidationFailed, n)
+	}
+
+	return nil
+}
+
+func writeTable(w io.Writer, resources []*kube.Resource) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	_, err := fmt.Fprintln(tw, "KIND\tNAMESPACE\tNAME")
+	if err != nil {
+		return fmt.Errorf("write table: %w", err)
+	}
+
+	for _, r := range resources {
+		_, err := fmt.Fprintf(tw, "%s\t%s\t%s\n",
+			r.Object.GetGroupKind(), r.Object.GetNamespace(), r.Object.GetName())
+		if err != nil {
+			return fmt.Errorf("write table: %w", err)
+		}
+	}
+
+	err = tw.Flush()
+	if err != nil {
+		return fmt.Errorf("write table: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,264 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/macropower/kat/pkg/kube"
+)
+
+const (
+	// DefaultOutputTemplate is the default path template for --output-dir.
+	DefaultOutputTemplate = "{{namespace}}/{{kind}}-{{name}}.yaml"
+
+	// outputDirManifest is the file in the output directory that records
+	// which files were written, so that stale files can be pruned later.
+	outputDirManifest = ".kat-files"
+)
+
+var (
+	// ErrInvalidOutputTemplate is returned for path templates that cannot
+	// be used.
+	ErrInvalidOutputTemplate = errors.New("invalid output template")
+	// ErrOutputPathCollision is returned when multiple resources would be
+	// written to the same path.
+	ErrOutputPathCollision = errors.New("output path collision")
+
+	placeholderRe = regexp.MustCompile(`\{\{\s*([A-Za-z]+)\s*\}\}`)
+
+	placeholders = map[string]func(o *kube.Object) string{
+		"apiVersion": (*kube.Object).GetAPIVersion,
+		"group":      (*kube.Object).GetGroup,
+		"version":    getVersion,
+		"kind":       (*kube.Object).GetKind,
+		"namespace":  (*kube.Object).GetNamespace,
+		"name":       (*kube.Object).GetName,
+	}
+)
+
+// PathTemplate generates file paths for resources. Placeholders use the
+// `{{field}}` syntax, where field is one of apiVersion, group, version, kind,
+// namespace or name.
+type PathTemplate struct {
+	tmpl string
+}
+
+// NewPathTemplate creates a new [PathTemplate] and validates its
+// placeholders.
+func NewPathTemplate(tmpl string) (*PathTemplate, error) {
+	if strings.TrimSpace(tmpl) == "" {
+		return nil, fmt.Errorf("%w: template is empty", ErrInvalidOutputTemplate)
+	}
+
+	for _, match := range placeholderRe.FindAllStringSubmatch(tmpl, -1) {
+		if _, ok := placeholders[match[1]]; !ok {
+			return nil, fmt.Errorf("%w: unknown placeholder %q", ErrInvalidOutputTemplate, match[0])
+		}
+	}
+
+	return &PathTemplate{tmpl: tmpl}, nil
+}
+
+// Execute returns the path for the given object, relative to the output
+// directory. Placeholder values cannot introduce path separators, and the
+// resulting path cannot escape the output directory. Empty path elements are
+// dropped, so cluster-scoped resources are placed one level higher when the
+// template contains `{{namespace}}/`.
+func (t *PathTemplate) Execute(obj *kube.Object) (string, error) {
+	expanded := placeholderRe.ReplaceAllStringFunc(t.tmpl, func(s string) string {
+		name := placeholderRe.FindStringSubmatch(s)[1]
+
+		return sanitizePathElement(placeholders[name](obj))
+	})
+
+	var elems []string
+
+	for elem := range strings.SplitSeq(expanded, "/") {
+		if elem != "" {
+			elems = append(elems, elem)
+		}
+	}
+
+	path := filepath.Join(elems...)
+	if !filepath.IsLocal(path) {
+		return "", fmt.Errorf("%w: %q is not a local path", ErrInvalidOutputTemplate, path)
+	}
+
+	return path, nil
+}
+
+// OutputDirStats describes the changes made by [WriteOutputDir].
+type OutputDirStats struct {
+	Written int
+	Pruned  int
+}
+
+// WriteOutputDir writes each resource to its own file under dir, using tmpl
+// to generate the file paths. Files that were written by a previous call but
+// are no longer part of the resources are removed.
+//
+// If multiple resources map to the same path, nothing is written and an
+// [ErrOutputPathCollision] error is returned.
+func WriteOutputDir(dir string, tmpl *PathTemplate, resources []*kube.Resource) (OutputDirStats, error) {
+	var stats OutputDirStats
+
+	var (
+		files   = make(map[string]*kube.Resource, len(resources))
+		paths   = make([]string, 0, len(resources))
+		collErr []error
+	)
+
+	for _, r := range resources {
+		path, err := tmpl.Execute(r.Object)
+		if err != nil {
+			return stats, fmt.Errorf("%s: %w", r.Object.GetMetadata(), err)
+		}
+
+		if path == outputDirManifest {
+			return stats, fmt.Errorf("%s: %w: %q is reserved", r.Object.GetMetadata(), ErrInvalidOutputTemplate, path)
+		}
+
+		if prev, ok := files[path]; ok {
+			collErr = append(collErr, fmt.Errorf("%w: %s and %s both map to %q",
+				ErrOutputPathCollision, prev.Object.GetMetadata(), r.Object.GetMetadata(), path))
+
+			continue
+		}
+
+		files[path] = r
+		paths = append(paths, path)
+	}
+
+	if len(collErr) > 0 {
+		return stats, errors.Join(collErr...)
+	}
+
+	err := os.MkdirAll(dir, 0o755)
+	if err != nil {
+		return stats, fmt.Errorf("create output directory: %w", err)
+	}
+
+	root, err := os.OpenRoot(dir)
+	if err != nil {
+		return stats, fmt.Errorf("open output directory: %w", err)
+	}
+	defer root.Close() //nolint:errcheck // Best-effort close.
+
+	previous, err := readOutputDirManifest(root)
+	if err != nil {
+		return stats, err
+	}
+
+	for _, path := range paths {
+		err := root.MkdirAll(filepath.Dir(path), 0o755)
+		if err != nil {
+			return stats, fmt.Errorf("create directory for %q: %w", path, err)
+		}
+
+		err = root.WriteFile(path, []byte(files[path].Content()), 0o644)
+		if err != nil {
+			return stats, fmt.Errorf("write %q: %w", path, err)
+		}
+
+		stats.Written++
+	}
+
+	for _, path := range previous {
+		if _, ok := files[path]; ok {
+			continue
+		}
+
+		pruned, err := pruneOutputFile(root, path)
+		if err != nil {
+			return stats, err
+		}
+
+		if pruned {
+			stats.Pruned++
+		}
+	}
+
+	manifest := make([]string, 0, len(paths))
+	for _, path := range paths {
+		manifest = append(manifest, filepath.ToSlash(path))
+	}
+
+	slices.Sort(manifest)
+
+	err = root.WriteFile(outputDirManifest, []byte(strings.Join(manifest, "\n")+"\n"), 0o644)
+	if err != nil {
+		return stats, fmt.Errorf("write %q: %w", outputDirManifest, err)
+	}
+
+	return stats, nil
+}
+
+// readOutputDirManifest returns the paths recorded by a previous call to
+// [WriteOutputDir]. It returns nil if there is no manifest.
+func readOutputDirManifest(root *os.Root) ([]string, error) {
+	b, err := root.ReadFile(outputDirManifest)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("read %q: %w", outputDirManifest, err)
+	}
+
+	var paths []string
+
+	for line := range strings.SplitSeq(string(b), "\n") {
+		path := filepath.Clean(filepath.FromSlash(strings.TrimSpace(line)))
+		if line == "" || !filepath.IsLocal(path) {
+			continue
+		}
+
+		paths = append(paths, path)
+	}
+
+	return paths, nil
+}
+
+// pruneOutputFile removes a stale file, along with any parent directories
+// that are left empty. It reports whether the file existed.
+func pruneOutputFile(root *os.Root, path string) (bool, error) {
+	err := root.Remove(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	} else if err != nil {
+		return false, fmt.Errorf("prune %q: %w", path, err)
+	}
+
+	for dir := filepath.Dir(path); dir != "."; dir = filepath.Dir(dir) {
+		// Removing a non-empty directory fails, which ends the walk.
+		if root.Remove(dir) != nil {
+			break
+		}
+	}
+
+	return true, nil
+}
+
+// sanitizePathElement makes s safe to use as a single path element.
+func sanitizePathElement(s string) string {
+	s = strings.NewReplacer("/", "_", "\\", "_").Replace(s)
+	if s == "." || s == ".." {
+		return "_"
+	}
+
+	return s
+}
+
+// getVersion returns the version part of the object's apiVersion.
+func getVersion(o *kube.Object) string {
+	apiVersion := o.GetAPIVersion()
+	if i := strings.LastIndex(apiVersion, "/"); i >= 0 {
+		return apiVersion[i+1:]
+	}
+
+	return apiVersion
+}
@@ -0,0 +1,175 @@
+package cli_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/macropower/kat/internal/cli"
+	"github.com/macropower/kat/pkg/kube"
+)
+
+func TestPathTemplate_Execute(t *testing.T) {
+	t.Parallel()
+
+	namespaced := &kube.Object{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"metadata":   map[string]any{"name": "web", "namespace": "prod"},
+	}
+	clusterScoped := &kube.Object{
+		"apiVersion": "v1",
+		"kind":       "Namespace",
+		"metadata":   map[string]any{"name": "prod"},
+	}
+	unsafe := &kube.Object{
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+		"metadata":   map[string]any{"name": "../../etc", "namespace": ".."},
+	}
+
+	tcs := map[string]struct {
+		obj     *kube.Object
+		tmpl    string
+		want    string
+		wantErr bool
+	}{
+		"default template": {
+			obj:  namespaced,
+			tmpl: cli.DefaultOutputTemplate,
+			want: filepath.Join("prod", "Deployment-web.yaml"),
+		},
+		"cluster-scoped resource": {
+			obj:  clusterScoped,
+			tmpl: cli.DefaultOutputTemplate,
+			want: "Namespace-prod.yaml",
+		},
+		"all placeholders": {
+			obj:  namespaced,
+			tmpl: "{{group}}/{{version}}/{{ kind }}/{{namespace}}.{{name}}.yml",
+			want: filepath.Join("apps", "v1", "Deployment", "prod.web.yml"),
+		},
+		"values cannot escape the directory": {
+			obj:  unsafe,
+			tmpl: cli.DefaultOutputTemplate,
+			want: filepath.Join("_", "ConfigMap-.._.._etc.yaml"),
+		},
+		"template cannot escape the directory": {
+			obj:     namespaced,
+			tmpl:    "../{{name}}.yaml",
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			tmpl, err := cli.NewPathTemplate(tc.tmpl)
+			require.NoError(t, err)
+
+			got, err := tmpl.Execute(tc.obj)
+			if tc.wantErr {
+				require.ErrorIs(t, err, cli.ErrInvalidOutputTemplate)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestNewPathTemplate_Invalid(t *testing.T) {
+	t.Parallel()
+
+	_, err := cli.NewPathTemplate("{{labels}}/{{name}}.yaml")
+	require.ErrorIs(t, err, cli.ErrInvalidOutputTemplate)
+
+	_, err = cli.NewPathTemplate(" ")
+	require.ErrorIs(t, err, cli.ErrInvalidOutputTemplate)
+}
+
+func TestWriteOutputDir(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+
+	tmpl, err := cli.NewPathTemplate(cli.DefaultOutputTemplate)
+	require.NoError(t, err)
+
+	first, err := kube.SplitYAML([]byte(`apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: a
+  namespace: one
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: b
+  namespace: two
+`))
+	require.NoError(t, err)
+
+	stats, err := cli.WriteOutputDir(dir, tmpl, first)
+	require.NoError(t, err)
+	assert.Equal(t, cli.OutputDirStats{Written: 2}, stats)
+
+	content, err := os.ReadFile(filepath.Join(dir, "one", "ConfigMap-a.yaml"))
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "name: a")
+	assert.FileExists(t, filepath.Join(dir, "two", "ConfigMap-b.yaml"))
+
+	// Unrelated files are never pruned.
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "README.md"), []byte("keep"), 0o600))
+
+	second, err := kube.SplitYAML([]byte(`apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: a
+  namespace: one
+`))
+	require.NoError(t, err)
+
+	stats, err = cli.WriteOutputDir(dir, tmpl, second)
+	require.NoError(t, err)
+	assert.Equal(t, cli.OutputDirStats{Written: 1, Pruned: 1}, stats)
+
+	assert.FileExists(t, filepath.Join(dir, "one", "ConfigMap-a.yaml"))
+	assert.NoDirExists(t, filepath.Join(dir, "two"))
+	assert.FileExists(t, filepath.Join(dir, "README.md"))
+}
+
+func TestWriteOutputDir_Collision(t *testing.T) {
+	t.Parallel()
+
+	dir := filepath.Join(t.TempDir(), "out")
+
+	tmpl, err := cli.NewPathTemplate("{{kind}}-{{name}}.yaml")
+	require.NoError(t, err)
+
+	resources, err := kube.SplitYAML([]byte(`apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: a
+  namespace: one
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: a
+  namespace: two
+`))
+	require.NoError(t, err)
+
+	_, err = cli.WriteOutputDir(dir, tmpl, resources)
+	require.ErrorIs(t, err, cli.ErrOutputPathCollision)
+
+	// Nothing is written when there are collisions.
+	assert.NoDirExists(t, dir)
+}
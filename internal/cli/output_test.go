@@ -0,0 +1,105 @@
+package cli_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/macropower/kat/internal/cli"
+	"github.com/macropower/kat/pkg/command"
+	"github.com/macropower/kat/pkg/kube"
+)
+
+func TestParseOutputFormat(t *testing.T) {
+	t.Parallel()
+
+	tcs := map[string]struct {
+		input   string
+		want    cli.OutputFormat
+		wantErr bool
+	}{
+		"empty defaults to raw": {
+			input: "",
+			want:  cli.OutputFormatRaw,
+		},
+		"json": {
+			input: "json",
+			want:  cli.OutputFormatJSON,
+		},
+		"ndjson": {
+			input: "ndjson",
+			want:  cli.OutputFormatNDJSON,
+		},
+		"yaml": {
+			input: "yaml",
+			want:  cli.OutputFormatYAML,
+		},
+		"table": {
+			input: "table",
+			want:  cli.OutputFormatTable,
+		},
+		"unknown": {
+			input:   "xml",
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := cli.ParseOutputFormat(tc.input)
+			if tc.wantErr {
+				require.ErrorIs(t, err, cli.ErrInvalidOutputFormat)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestNewRenderResult(t *testing.T) {
+	t.Parallel()
+
+	ts := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	obj := &kube.Object{
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+		"metadata":   map[string]any{"name": "test"},
+	}
+
+	diag := kube.Diagnostic{
+		Severity: kube.SeverityError,
+		Source:   "schema",
+		Path:     "$.data.port",
+		Message:  "got number, want string",
+	}
+
+	run := command.Output{
+		Timestamp: ts,
+		Error:     errors.New("render failed"),
+		Stderr:    "warning",
+		Warnings:  []command.Warning{{Message: "warning"}},
+		Resources: []*kube.Resource{{Object: obj, Diagnostics: []kube.Diagnostic{diag}}},
+	}
+
+	got := cli.NewRenderResult(run, "helm", "./chart", 1234567*time.Microsecond)
+
+	assert.Equal(t, "RenderResult", got.Kind)
+	assert.Equal(t, ts, got.Timestamp)
+	assert.Equal(t, "helm", got.Profile)
+	assert.Equal(t, "./chart", got.Path)
+	assert.Equal(t, "1.235s", got.Duration)
+	assert.Equal(t, "render failed", got.Error)
+	assert.Equal(t, "warning", got.Stderr)
+	assert.Equal(t, []command.Warning{{Message: "warning"}}, got.Warnings)
+	assert.Equal(t, 1, got.Count)
+	assert.Equal(t, []*kube.Object{obj}, got.Resources)
+	assert.Equal(t, []cli.ResourceDiagnostic{{Diagnostic: diag, Resource: "v1/ConfigMap/test"}}, got.Diagnostics)
+}
@@ -36,6 +36,9 @@ func NewRootCmd() *cobra.Command {
 	runArgs := NewRunArgs(args)
 
 	runCmd := NewRunCmd(runArgs)
+	diffCmd := NewDiffCmd(NewDiffArgs(args))
+	matrixCmd := NewMatrixCmd(NewMatrixArgs(args))
+	workspaceCmd := NewWorkspaceCmd(NewWorkspaceArgs(args))
 	cmd := &cobra.Command{
 		Use:               cmdName,
 		Short:             cmdDesc,
@@ -48,7 +51,7 @@ func NewRootCmd() *cobra.Command {
 
 	args.AddFlags(cmd)
 	runArgs.AddFlags(cmd)
-	cmd.AddCommand(runCmd)
+	cmd.AddCommand(runCmd, diffCmd, matrixCmd, workspaceCmd)
 
 	bindEnvVars(cmd)
 
@@ -22,17 +22,22 @@ import (
 	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 	semconv "go.opentelemetry.io/otel/semconv/v1.39.0"
 
+	"github.com/macropower/kat/api"
 	"github.com/macropower/kat/api/v1beta1/configs"
 	"github.com/macropower/kat/api/v1beta1/policies"
+	"github.com/macropower/kat/pkg/check"
 	"github.com/macropower/kat/pkg/command"
 	"github.com/macropower/kat/pkg/config"
+	"github.com/macropower/kat/pkg/kube"
 	"github.com/macropower/kat/pkg/mcp"
 	"github.com/macropower/kat/pkg/policy"
 	"github.com/macropower/kat/pkg/profile"
+	"github.com/macropower/kat/pkg/source"
 	"github.com/macropower/kat/pkg/ui"
 	"github.com/macropower/kat/pkg/ui/common"
 	"github.com/macropower/kat/pkg/ui/setup"
 	"github.com/macropower/kat/pkg/ui/theme"
+	"github.com/macropower/kat/pkg/validate"
 )
 
 const (
@@ -42,12 +47,23 @@ const (
   # kat a file or directory path:
   kat ./example/kustomize
 
+  # kat a remote chart, archive or Git repository:
+  kat oci://ghcr.io/stefanprodan/charts/podinfo:6.9.0
+  kat https://example.com/charts/mychart-1.2.3.tgz
+  kat "git::https://github.com/org/repo//deploy/app?ref=v1"
+
   # Watch for changes and reload:
   kat ./example/helm --watch
 
+  # Watch by polling, e.g. on network filesystems:
+  kat ./example/helm --watch --watch-backend poll
+
   # Force using the "ks" profile (defined in config):
   kat ./example/kustomize ks
 
+  # Render a profile variant (defined in config):
+  kat ./example/helm --variant values-prod.yaml
+
   # Set the extra arguments:
   kat ./example/helm -- -g -f prod-values.yaml
 
@@ -55,7 +71,13 @@ const (
   cat ./example/kustomize/resources.yaml | kat -
 
   # Send output to a file (disables TUI):
-  kat ./example/helm > manifests.yaml`
+  kat ./example/helm > manifests.yaml
+
+  # Write the rendered resources as JSON (disables TUI):
+  kat ./example/helm -o json
+
+  # Write each rendered resource to its own file (disables TUI):
+  kat ./example/helm --output-dir ./rendered`
 )
 
 type RunArgs struct {
@@ -64,15 +86,24 @@ type RunArgs struct {
 	Path             string
 	ConfigPath       string
 	CommandOrProfile string
+	Variant          string
 	ServeMCP         string
 	TracingEndpoint  string
+	WatchBackend     string
+	Output           string
+	OutputDir        string
+	OutputTemplate   string
+	Workspace        string
 	Args             []string
 	StdinData        []byte
+	Source           *source.Source
+	Root             *os.Root
 	Watch            bool
 	WriteConfig      bool
 	ShowConfig       bool
 	Trust            bool
 	NoTrust          bool
+	PlainHTTP        bool
 }
 
 func NewRunArgs(rootArgs *RootArgs) *RunArgs {
@@ -85,18 +116,53 @@ func (ra *RunArgs) AddFlags(cmd *cobra.Command) {
 	cmd.Flags().StringVar(&ra.ConfigPath, "config", "", "Path to the kat configuration file")
 	cmd.Flags().StringVar(&ra.ServeMCP, "serve-mcp", "", "Serve the MCP server at the specified address")
 	cmd.Flags().BoolVarP(&ra.Watch, "watch", "w", false, "Watch for changes and trigger reloading")
+	cmd.Flags().StringVar(&ra.WatchBackend, "watch-backend", "",
+		"Watch backend, one of auto, fsnotify or poll (default from config)")
+	cmd.Flags().StringVar(&ra.Variant, "variant", "", "Name of the profile variant to render")
 	cmd.Flags().BoolVar(&ra.WriteConfig, "write-config", false, "Write the default configuration files and exit")
 	cmd.Flags().BoolVar(&ra.ShowConfig, "show-config", false, "Print the active configuration and exit")
 	cmd.Flags().StringVar(&ra.TracingEndpoint, "tracing-endpoint", "", "OpenTelemetry tracing endpoint")
+	cmd.Flags().StringVarP(&ra.Output, "output", "o", "",
+		"Output format, one of raw, json, ndjson, yaml or table (disables TUI)")
+	cmd.Flags().StringVar(&ra.OutputDir, "output-dir", "",
+		"Write each resource to its own file in this directory (disables TUI)")
+	cmd.Flags().StringVar(&ra.OutputTemplate, "output-template", DefaultOutputTemplate,
+		"Path template for files written to --output-dir")
 	cmd.Flags().BoolVar(&ra.Trust, "trust", false, "Trust project configurations without prompting")
 	cmd.Flags().BoolVar(&ra.NoTrust, "no-trust", false, "Skip project configurations without prompting")
+	cmd.Flags().BoolVar(&ra.PlainHTTP, "plain-http", false, "Use plain HTTP instead of HTTPS for OCI registries")
 
 	cmd.MarkFlagsMutuallyExclusive("trust", "no-trust")
+	cmd.MarkFlagsMutuallyExclusive("output", "output-dir")
 
 	err := cmd.MarkFlagFilename("config", "yaml", "yml")
 	if err != nil {
 		panic(fmt.Errorf("mark config flag: %w", err))
 	}
+
+	err = cmd.MarkFlagDirname("output-dir")
+	if err != nil {
+		panic(fmt.Errorf("mark output-dir flag: %w", err))
+	}
+
+	err = cmd.RegisterFlagCompletionFunc("output", outputFormatCompletion)
+	if err != nil {
+		panic(fmt.Errorf("register output completion: %w", err))
+	}
+
+	err = cmd.RegisterFlagCompletionFunc("watch-backend", watchBackendCompletion)
+	if err != nil {
+		panic(fmt.Errorf("register watch-backend completion: %w", err))
+	}
+}
+
+func watchBackendCompletion(*cobra.Command, []string, string) ([]cobra.Completion, cobra.ShellCompDirective) {
+	completions := make([]cobra.Completion, 0, len(command.WatcherBackends))
+	for _, b := range command.WatcherBackends {
+		completions = append(completions, string(b))
+	}
+
+	return completions, cobra.ShellCompDirectiveNoFileComp
 }
 
 func NewRunCmd(ra *RunArgs) *cobra.Command {
@@ -117,7 +183,7 @@ func NewRunCmd(ra *RunArgs) *cobra.Command {
 				return fmt.Errorf("accepts at most 2 args before --, received %d", dashPos)
 			}
 
-			return nil
+			return command.WatcherBackend(ra.WatchBackend).Validate() //nolint:wrapcheck // Already wrapped.
 		},
 		ValidArgsFunction: runCompletion(ra),
 		RunE: func(cmd *cobra.Command, args []string) error {
@@ -274,6 +340,16 @@ func run(cmd *cobra.Command, rc *RunArgs) error {
 		return err
 	}
 
+	outputFormat, err := ParseOutputFormat(rc.Output)
+	if err != nil {
+		return err
+	}
+
+	outputTemplate, err := NewPathTemplate(rc.OutputTemplate)
+	if err != nil {
+		return err
+	}
+
 	trustMode := policy.TrustModePrompt
 	if rc.Trust {
 		trustMode = policy.TrustModeAllow
@@ -283,7 +359,14 @@ func run(cmd *cobra.Command, rc *RunArgs) error {
 		trustMode = policy.TrustModeSkip
 	}
 
-	cfg, thm, err := loadAnyRuntimeConfigs(configPath, rc.Path, trustMode)
+	// Remote sources are fetched into temporary directories, so project
+	// configuration is only loaded from the current directory.
+	projectPath := rc.Path
+	if source.IsRemote(rc.Path) {
+		projectPath = "."
+	}
+
+	cfg, thm, err := loadAnyRuntimeConfigs(configPath, projectPath, trustMode)
 	if err != nil {
 		return err
 	}
@@ -293,9 +376,9 @@ func run(cmd *cobra.Command, rc *RunArgs) error {
 		return fmt.Errorf("invalid config: %w", err)
 	}
 
-	err = cfg.UI.KeyBinds.Validate()
+	err = cfg.UI.Validate()
 	if err != nil {
-		return fmt.Errorf("validate key binds: %w", err)
+		return fmt.Errorf("validate ui config: %w", err)
 	}
 
 	if rc.ShowConfig {
@@ -327,6 +410,24 @@ func run(cmd *cobra.Command, rc *RunArgs) error {
 		rc.Watch = true
 	}
 
+	if source.IsRemote(rc.Path) {
+		slog.Info("fetching source", slog.String("ref", rc.Path))
+
+		src, err := source.NewFetcher(source.WithPlainHTTP(rc.PlainHTTP)).Fetch(cmd.Context(), rc.Path)
+		if err != nil {
+			return fmt.Errorf("fetch source: %w", err)
+		}
+
+		defer func() {
+			err := src.Close()
+			if err != nil {
+				slog.Error("remove fetched source", slog.String("dir", src.Dir), slog.Any("err", err))
+			}
+		}()
+
+		rc.Source = src
+	}
+
 	tp, err := setupTracerProvider(rc)
 	if err != nil {
 		return fmt.Errorf("setup tracer provider: %w", err)
@@ -337,10 +438,24 @@ func run(cmd *cobra.Command, rc *RunArgs) error {
 	var cr command.Commander
 
 	if len(rc.StdinData) > 0 {
-		cr, err = command.NewStatic(string(rc.StdinData))
+		static, err := command.NewStatic(string(rc.StdinData))
 		if err != nil {
 			return fmt.Errorf("create resource getter: %w", err)
 		}
+
+		v, err := newValidator(cfg)
+		if err != nil {
+			return err
+		}
+
+		if v != nil {
+			v.Validate(static.Resources)
+		}
+
+		check.Run(cfg.Command.Checks, static.Resources)
+		kube.NewGraph(static.Resources).AddDiagnostics()
+
+		cr = static
 	} else {
 		cr, err = setupCommandRunner(rc.Path, cfg, rc)
 		if err != nil {
@@ -348,10 +463,14 @@ func run(cmd *cobra.Command, rc *RunArgs) error {
 		}
 	}
 
-	// If stdout is not a terminal, actually "concatenate".
-	if !term.IsTerminal(int(os.Stdout.Fd())) {
-		run := cr.Run()
-		err := writeToOutput(cmd, run)
+	if rc.OutputDir != "" {
+		return writeToOutputDir(cmd, cr, rc.OutputDir, outputTemplate)
+	}
+
+	// If stdout is not a terminal, or an output format was requested,
+	// actually "concatenate".
+	if rc.Output != "" || !term.IsTerminal(int(os.Stdout.Fd())) {
+		err := writeToOutput(cmd, cr, rc.Path, outputFormat)
 		if err != nil {
 			return err
 		}
@@ -360,6 +479,13 @@ func run(cmd *cobra.Command, rc *RunArgs) error {
 		return nil
 	}
 
+	return runInteractive(cmd, cfg, cr, rc)
+}
+
+// runInteractive runs the UI for the command, and the MCP server if enabled,
+// until the UI exits. Logs are buffered while the UI is running, and written
+// to stderr afterwards.
+func runInteractive(cmd *cobra.Command, cfg *configs.Config, cr command.Commander, rc *RunArgs) error {
 	pub := xlog.NewPublisher(xlog.WithBufferSize(100))
 	sub := pub.Subscribe()
 
@@ -371,7 +497,13 @@ func run(cmd *cobra.Command, rc *RunArgs) error {
 	slog.SetDefault(slog.New(logHandler))
 
 	if rc.ServeMCP != "" {
-		mcpServer, err := mcp.NewServer(rc.ServeMCP, cr, rc.Path)
+		mcpServer, err := mcp.NewServer(rc.ServeMCP, cr, rc.Path,
+			// Use a separate runner for each path, so that tool calls do not
+			// change the path shown in the UI.
+			mcp.WithRunnerFactory(func(path string) (mcp.PooledRunner, error) {
+				return setupCommandRunner(path, cfg, rc)
+			}),
+		)
 		if err != nil {
 			return fmt.Errorf("create MCP server: %w", err)
 		}
@@ -397,14 +529,45 @@ func run(cmd *cobra.Command, rc *RunArgs) error {
 	return nil
 }
 
-func writeToOutput(cmd *cobra.Command, run command.Output) error {
-	if run.Stdout != "" {
-		_, err := fmt.Fprint(cmd.OutOrStdout(), run.Stdout)
+// writeToOutput runs the command once and writes the result in the given
+// format. JSON formats include stderr in the result, other formats write it
+// to stderr.
+func writeToOutput(cmd *cobra.Command, cr command.Commander, path string, format OutputFormat) error {
+	run := cr.Run()
+	duration := time.Since(run.Timestamp)
+	profileName, _ := cr.GetCurrentProfile()
+
+	err := writeResult(cmd.OutOrStdout(), format, NewRenderResult(run, profileName, path, duration), run)
+	if err != nil {
+		return err
+	}
+
+	if format != OutputFormatJSON && format != OutputFormatNDJSON {
+		if run.Stderr != "" {
+			_, err := fmt.Fprint(cmd.ErrOrStderr(), run.Stderr)
+			if err != nil {
+				return fmt.Errorf("write to stderr: %w", err)
+			}
+		}
+
+		err := writeDiagnostics(cmd.ErrOrStderr(), run.Resources)
 		if err != nil {
-			return fmt.Errorf("write to stdout: %w", err)
+			return err
 		}
 	}
 
+	if run.Error != nil {
+		return fmt.Errorf("run error: %w", run.Error)
+	}
+
+	return checkDiagnostics(run.Resources)
+}
+
+// writeToOutputDir runs the command once and writes each resource to its own
+// file in dir. Nothing is written if the command fails.
+func writeToOutputDir(cmd *cobra.Command, cr command.Commander, dir string, tmpl *PathTemplate) error {
+	run := cr.Run()
+
 	if run.Stderr != "" {
 		_, err := fmt.Fprint(cmd.ErrOrStderr(), run.Stderr)
 		if err != nil {
@@ -416,7 +579,23 @@ func writeToOutput(cmd *cobra.Command, run command.Output) error {
 		return fmt.Errorf("run error: %w", run.Error)
 	}
 
-	return nil
+	stats, err := WriteOutputDir(dir, tmpl, run.Resources)
+	if err != nil {
+		return fmt.Errorf("write output directory: %w", err)
+	}
+
+	slog.Info("wrote output directory",
+		slog.String("path", dir),
+		slog.Int("written", stats.Written),
+		slog.Int("pruned", stats.Pruned),
+	)
+
+	err = writeDiagnostics(cmd.ErrOrStderr(), run.Resources)
+	if err != nil {
+		return err
+	}
+
+	return checkDiagnostics(run.Resources)
 }
 
 func flushLogs(w io.Writer, pub *xlog.Publisher, sub *xlog.Subscription) {
@@ -534,22 +713,45 @@ func setupCommandRunner(path string, cfg *configs.Config, rc *RunArgs) (*command
 		err error
 	)
 
+	v, err := newValidator(cfg)
+	if err != nil {
+		return nil, err
+	}
+
 	if rc.CommandOrProfile != "" {
 		p, err := getProfile(cfg, rc.CommandOrProfile, rc.Args)
 		if err != nil {
 			return nil, err
 		}
 
-		cr, err = command.NewRunner(path, command.WithCustomProfile(rc.CommandOrProfile, p))
+		cr, err = newRunner(path, rc,
+			command.WithCustomProfile(rc.CommandOrProfile, p),
+			command.WithVariant(rc.Variant),
+			command.WithValidator(v),
+			command.WithCache(newCache(cfg)),
+			command.WithChecks(cfg.Command.Checks),
+			command.WithWatcherConfig(newWatcherConfig(cfg, rc)),
+			command.WithIgnore(cfg.Command.Ignore...),
+			command.WithIgnoreFiles(cfg.Command.IgnoreFiles...),
+			command.WithWorkspace(rc.Workspace),
+		)
 		if err != nil {
 			return nil, err
 		}
 	} else {
-		cr, err = command.NewRunner(path,
+		cr, err = newRunner(path, rc,
 			command.WithRules(cfg.Command.Rules),
 			command.WithProfiles(cfg.Command.Profiles),
 			command.WithExtraArgs(rc.Args...),
+			command.WithVariant(rc.Variant),
 			command.WithWatch(rc.Watch),
+			command.WithValidator(v),
+			command.WithCache(newCache(cfg)),
+			command.WithChecks(cfg.Command.Checks),
+			command.WithWatcherConfig(newWatcherConfig(cfg, rc)),
+			command.WithIgnore(cfg.Command.Ignore...),
+			command.WithIgnoreFiles(cfg.Command.IgnoreFiles...),
+			command.WithWorkspace(rc.Workspace),
 		)
 		if err != nil {
 			return nil, err
@@ -559,6 +761,80 @@ func setupCommandRunner(path string, cfg *configs.Config, rc *RunArgs) (*command
 	return cr, nil
 }
 
+// newRunner creates a [command.Runner] for the path. If a root is given, the
+// runner is confined to it. Otherwise, if a remote source was fetched, the
+// runner is confined to the source's directory, and the source's ref refers
+// to the project within it. The source's root is closed when the source is
+// closed, i.e. when the command exits.
+func newRunner(path string, rc *RunArgs, opts ...command.RunnerOpt) (*command.Runner, error) {
+	if rc.Root != nil {
+		return command.NewRunnerWithRoot(rc.Root, path, opts...)
+	}
+
+	if rc.Source == nil {
+		return command.NewRunner(path, opts...)
+	}
+
+	root, err := rc.Source.Root()
+	if err != nil {
+		return nil, err
+	}
+
+	if path == rc.Source.Ref {
+		path = rc.Source.Path
+	}
+
+	return command.NewRunnerWithRoot(root, path, opts...)
+}
+
+// newValidator creates a [validate.Validator] from the configuration.
+// It returns nil if validation is disabled.
+func newValidator(cfg *configs.Config) (*validate.Validator, error) {
+	if !cfg.Command.Validation.IsEnabled() {
+		return nil, nil //nolint:nilnil // Validation is disabled.
+	}
+
+	v, err := validate.NewFromConfig(cfg.Command.Validation)
+	if err != nil {
+		return nil, fmt.Errorf("create validator: %w", err)
+	}
+
+	return v, nil
+}
+
+// newCache creates a [command.Cache] from the configuration.
+// It returns nil if caching is disabled.
+func newCache(cfg *configs.Config) *command.Cache {
+	cc := cfg.Command.Cache
+	if !cc.IsEnabled() {
+		return nil
+	}
+
+	dir := cc.Dir
+	if dir == "" {
+		dir = api.GetCachePath("renders")
+	}
+
+	return command.NewCache(dir)
+}
+
+// newWatcherConfig returns the configured [command.WatcherConfig], with the
+// backend overridden by the --watch-backend flag if it is set.
+func newWatcherConfig(cfg *configs.Config, rc *RunArgs) *command.WatcherConfig {
+	if rc.WatchBackend == "" {
+		return cfg.Command.Watch
+	}
+
+	wc := command.WatcherConfig{}
+	if cfg.Command.Watch != nil {
+		wc = *cfg.Command.Watch
+	}
+
+	wc.Backend = command.WatcherBackend(rc.WatchBackend)
+
+	return &wc
+}
+
 // setupTracerProvider creates and configures an OpenTelemetry tracer provider based on CLI arguments.
 func setupTracerProvider(rc *RunArgs) (*sdktrace.TracerProvider, error) {
 	res, err := resource.Merge(
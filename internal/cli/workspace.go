@@ -0,0 +1,405 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/goccy/go-yaml"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+
+	"github.com/macropower/kat/api/v1beta1"
+	"github.com/macropower/kat/api/v1beta1/configs"
+	"github.com/macropower/kat/pkg/command"
+	"github.com/macropower/kat/pkg/policy"
+)
+
+const (
+	workspaceCmdExamples = `  # Browse every project in the current directory:
+  kat workspace
+
+  # Browse every project in a directory, and watch for changes:
+  kat workspace ./deploy --watch
+
+  # List the projects and their profiles:
+  kat workspace ./deploy -o text
+
+  # Render every project, and fail if any of them fail to render:
+  kat workspace ./deploy --render
+
+  # Write the render results as JSON:
+  kat workspace ./deploy --render -o json`
+
+	// workspaceResultKind is the kind of the [WorkspaceResult] object.
+	workspaceResultKind = "WorkspaceResult"
+)
+
+var (
+	// ErrNoProjects is returned by the workspace command when no directory
+	// matches any rule.
+	ErrNoProjects = errors.New("no projects found")
+	// ErrProjectsFailed is returned by the workspace command when --render is
+	// set and any project failed to render.
+	ErrProjectsFailed = errors.New("projects failed to render")
+)
+
+// WorkspaceOutputFormats contains all valid output formats for the workspace
+// command. If no format is given, the UI is started instead, unless stdout is
+// not a terminal or --render is set, in which case "text" is used.
+var WorkspaceOutputFormats = []string{"text", "yaml", "json"}
+
+type WorkspaceArgs struct {
+	*RootArgs
+
+	Path       string
+	ConfigPath string
+	Output     string
+	Render     bool
+	Watch      bool
+	Trust      bool
+	NoTrust    bool
+}
+
+func NewWorkspaceArgs(rootArgs *RootArgs) *WorkspaceArgs {
+	return &WorkspaceArgs{
+		RootArgs: rootArgs,
+	}
+}
+
+func (wa *WorkspaceArgs) AddFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&wa.ConfigPath, "config", "", "Path to the kat configuration file")
+	cmd.Flags().StringVarP(&wa.Output, "output", "o", "",
+		"Output format, one of "+strings.Join(WorkspaceOutputFormats, ", ")+" (disables TUI)")
+	cmd.Flags().BoolVar(&wa.Render, "render", false, "Render every project and report the results (disables TUI)")
+	cmd.Flags().BoolVarP(&wa.Watch, "watch", "w", false, "Watch for changes and trigger reloading")
+	cmd.Flags().BoolVar(&wa.Trust, "trust", false, "Trust project configurations without prompting")
+	cmd.Flags().BoolVar(&wa.NoTrust, "no-trust", false, "Skip project configurations without prompting")
+
+	cmd.MarkFlagsMutuallyExclusive("trust", "no-trust")
+	cmd.MarkFlagsMutuallyExclusive("render", "watch")
+
+	err := cmd.MarkFlagFilename("config", "yaml", "yml")
+	if err != nil {
+		panic(fmt.Errorf("mark config flag: %w", err))
+	}
+
+	err = cmd.RegisterFlagCompletionFunc("output", workspaceOutputCompletion)
+	if err != nil {
+		panic(fmt.Errorf("register output completion: %w", err))
+	}
+}
+
+func workspaceOutputCompletion(*cobra.Command, []string, string) ([]cobra.Completion, cobra.ShellCompDirective) {
+	return WorkspaceOutputFormats, cobra.ShellCompDirectiveNoFileComp
+}
+
+func NewWorkspaceCmd(wa *WorkspaceArgs) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "workspace [dir]",
+		Short: "Find and browse every renderable project in a directory tree",
+		Long: `Recursively scan a directory for projects, i.e. directories that match at
+least one rule, and list them with the profiles that they match.
+
+In a terminal, the first project is rendered in the UI, and any other project
+can be selected from the menu. Otherwise, the projects are listed, or with
+--render, every project is rendered and the results are reported.
+
+Directories within a project, e.g. the templates of a Helm chart, are not
+scanned. Ignored directories are skipped.`,
+		Example: workspaceCmdExamples,
+		Args: func(_ *cobra.Command, args []string) error {
+			if len(args) > 1 {
+				return fmt.Errorf("accepts at most 1 directory, received %d", len(args))
+			}
+
+			if wa.Output != "" && !slices.Contains(WorkspaceOutputFormats, wa.Output) {
+				return fmt.Errorf("%w: %q", ErrInvalidOutputFormat, wa.Output)
+			}
+
+			return nil
+		},
+		ValidArgsFunction: func(_ *cobra.Command, args []string, _ string) ([]cobra.Completion, cobra.ShellCompDirective) {
+			if len(args) == 0 {
+				return nil, cobra.ShellCompDirectiveFilterDirs
+			}
+
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			wa.Path = "."
+			if len(args) > 0 {
+				wa.Path = args[0]
+			}
+
+			return runWorkspace(cmd, wa)
+		},
+	}
+	wa.AddFlags(cmd)
+
+	bindEnvVars(cmd)
+
+	return cmd
+}
+
+// WorkspaceResult describes the projects of a workspace for structured output
+// formats.
+type WorkspaceResult struct {
+	v1beta1.TypeMeta `json:",inline"`
+
+	Path     string              `json:"path"`
+	Projects []*WorkspaceProject `json:"projects"`
+}
+
+// WorkspaceProject describes a project of a workspace. If the projects were
+// rendered, it also describes the result of the render.
+type WorkspaceProject struct {
+	command.Project `json:",inline"`
+
+	// Resources is the number of rendered resources.
+	Resources *int `json:"resources,omitempty"`
+	// Error contains the error of a failed render.
+	Error    string `json:"error,omitempty"`
+	Duration string `json:"duration,omitempty"`
+}
+
+func runWorkspace(cmd *cobra.Command, wa *WorkspaceArgs) error {
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	configPath := wa.ConfigPath
+	if configPath == "" {
+		configPath = configs.GetPath()
+	}
+
+	trustMode := policy.TrustModePrompt
+	if wa.Trust {
+		trustMode = policy.TrustModeAllow
+	}
+
+	if wa.NoTrust {
+		trustMode = policy.TrustModeSkip
+	}
+
+	cfg, _, err := loadAnyRuntimeConfigs(configPath, wa.Path, trustMode)
+	if err != nil {
+		return err
+	}
+
+	err = cfg.Validate()
+	if err != nil {
+		return fmt.Errorf("invalid config: %w", err)
+	}
+
+	projects, err := findProjects(cfg, wa.Path)
+	if err != nil {
+		return err
+	}
+
+	interactive := wa.Output == "" && !wa.Render && term.IsTerminal(int(os.Stdout.Fd()))
+	if interactive {
+		err := cfg.UI.Validate()
+		if err != nil {
+			return fmt.Errorf("validate ui config: %w", err)
+		}
+
+		rc := &RunArgs{
+			RootArgs:  wa.RootArgs,
+			Path:      projects[0].Path,
+			Watch:     wa.Watch,
+			Workspace: wa.Path,
+		}
+
+		cr, err := setupCommandRunner(rc.Path, cfg, rc)
+		if err != nil {
+			return fmt.Errorf("create command runner: %w", err)
+		}
+
+		return runInteractive(cmd, cfg, cr, rc)
+	}
+
+	wr := &WorkspaceResult{
+		TypeMeta: v1beta1.TypeMeta{
+			APIVersion: v1beta1.APIVersion,
+			Kind:       workspaceResultKind,
+		},
+		Path: wa.Path,
+	}
+
+	for _, p := range projects {
+		wp := &WorkspaceProject{Project: p}
+		if wa.Render {
+			renderWorkspaceProject(ctx, cfg, wp)
+		}
+
+		wr.Projects = append(wr.Projects, wp)
+	}
+
+	format := wa.Output
+	if format == "" {
+		format = WorkspaceOutputFormats[0]
+	}
+
+	err = writeWorkspace(cmd.OutOrStdout(), format, wr, wa.Render)
+	if err != nil {
+		return err
+	}
+
+	failed := 0
+
+	for _, wp := range wr.Projects {
+		if wp.Error != "" {
+			failed++
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%w: %d of %d", ErrProjectsFailed, failed, len(wr.Projects))
+	}
+
+	return nil
+}
+
+// findProjects returns the projects in dir, relative to the current working
+// directory. It returns [ErrNoProjects] if there are none.
+func findProjects(cfg *configs.Config, dir string) ([]command.Project, error) {
+	wd, err := os.Getwd()
+	if err != nil {
+		return nil, fmt.Errorf("get current working directory: %w", err)
+	}
+
+	root, err := os.OpenRoot(wd)
+	if err != nil {
+		return nil, fmt.Errorf("open root directory %q: %w", wd, err)
+	}
+	defer root.Close() //nolint:errcheck // Ignore errors.
+
+	projects, err := cfg.Command.FindProjects(root, dir)
+	if err != nil {
+		return nil, fmt.Errorf("find projects: %w", err)
+	}
+
+	if len(projects) == 0 {
+		return nil, fmt.Errorf("%w in %s", ErrNoProjects, dir)
+	}
+
+	return projects, nil
+}
+
+// renderWorkspaceProject renders the project in place, and adds the result
+// to it.
+func renderWorkspaceProject(ctx context.Context, cfg *configs.Config, wp *WorkspaceProject) {
+	cr, err := setupCommandRunner(wp.Path, cfg, &RunArgs{})
+	if err != nil {
+		wp.Error = err.Error()
+
+		return
+	}
+	defer cr.Close()
+
+	run := cr.RunContext(ctx)
+
+	wp.Duration = time.Since(run.Timestamp).Round(time.Millisecond).String()
+
+	if run.Error != nil {
+		wp.Error = run.Error.Error()
+
+		return
+	}
+
+	n := len(run.Resources)
+	wp.Resources = &n
+}
+
+// writeWorkspace writes the workspace result to w using the given format.
+func writeWorkspace(w io.Writer, format string, wr *WorkspaceResult, rendered bool) error {
+	switch format {
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+
+		err := enc.Encode(wr)
+		if err != nil {
+			return fmt.Errorf("encode json: %w", err)
+		}
+
+		return nil
+
+	case "yaml":
+		b, err := yaml.Marshal(wr)
+		if err != nil {
+			return fmt.Errorf("encode yaml: %w", err)
+		}
+
+		_, err = w.Write(b)
+		if err != nil {
+			return fmt.Errorf("write yaml: %w", err)
+		}
+
+		return nil
+
+	case "text":
+		return writeWorkspaceText(w, wr, rendered)
+	}
+
+	return fmt.Errorf("%w: %q", ErrInvalidOutputFormat, format)
+}
+
+// writeWorkspaceText writes a table with one row per project, with the
+// profiles that match it. If the projects were rendered, the table also
+// contains the number of rendered resources and the first line of any error.
+func writeWorkspaceText(w io.Writer, wr *WorkspaceResult, rendered bool) error {
+	var b strings.Builder
+
+	b.WriteString("PATH\tPROFILE")
+
+	if rendered {
+		b.WriteString("\tRESOURCES\tSTATUS")
+	}
+
+	b.WriteString("\n")
+
+	for _, p := range wr.Projects {
+		b.WriteString(p.Path + "\t" + strings.Join(p.Profiles, ","))
+
+		if rendered {
+			resources := matrixAbsent
+			if p.Resources != nil {
+				resources = strconv.Itoa(*p.Resources)
+			}
+
+			status := "ok"
+			if p.Error != "" {
+				status, _, _ = strings.Cut(strings.TrimSpace(p.Error), "\n")
+			}
+
+			b.WriteString("\t" + resources + "\t" + status)
+		}
+
+		b.WriteString("\n")
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	_, err := io.WriteString(tw, b.String())
+	if err != nil {
+		return fmt.Errorf("write workspace: %w", err)
+	}
+
+	err = tw.Flush()
+	if err != nil {
+		return fmt.Errorf("write workspace: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,218 @@
+package check
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/common/types"
+	"github.com/google/cel-go/common/types/ref"
+
+	"github.com/macropower/kat/pkg/expr"
+	"github.com/macropower/kat/pkg/kube"
+)
+
+var (
+	// ErrMissingName is returned for checks without a name.
+	ErrMissingName = errors.New("name is required")
+	// ErrInvalidSeverity is returned for unknown check severities.
+	ErrInvalidSeverity = errors.New("invalid severity")
+)
+
+// Check uses a CEL expression to find rendered resources that violate a
+// policy.
+//
+// CEL expressions have access to variables:
+//   - `object` (map): The rendered object being checked
+//   - `resources` (list<map>): All rendered objects
+//
+// CEL expressions must return a boolean value, where true means the object
+// violates the check:
+//   - object.kind == "Deployment" && !has(object.spec.template.spec.securityContext) - Deployments without a pod securityContext
+//   - has(object.metadata.namespace) && object.metadata.namespace == "default" - objects in the default namespace
+//   - resources.filter(r, r.kind == object.kind && r.metadata.name == object.metadata.name).size() > 1 - duplicate names
+//
+// Expressions that fail to evaluate, e.g. because a field does not exist,
+// are not treated as violations. Use `has()` to test for optional fields.
+type Check struct {
+	program *expr.LazyProgram // Compiled CEL program for finding violations.
+
+	// Name identifies the check in diagnostics.
+	Name string `json:"name" jsonschema:"title=Name"`
+	// Expr is a CEL expression that returns true if the object violates the check.
+	Expr string `json:"expr" jsonschema:"title=Expression"`
+	// Severity is the severity of violations, either "error" or "warning".
+	// Defaults to "warning".
+	Severity kube.Severity `json:"severity,omitempty" jsonschema:"title=Severity,enum=error,enum=warning"`
+	// Message describes the violation.
+	Message string `json:"message,omitempty" jsonschema:"title=Message"`
+}
+
+// New creates a new check with the given name, expression and options.
+func New(name, expression string, opts ...CheckOpt) (*Check, error) {
+	c := &Check{
+		Name: name,
+		Expr: expression,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	err := c.Compile()
+	if err != nil {
+		return nil, fmt.Errorf("check %q: %w", name, err)
+	}
+
+	return c, nil
+}
+
+// MustNew creates a new check and panics if there's an error.
+func MustNew(name, expression string, opts ...CheckOpt) *Check {
+	c, err := New(name, expression, opts...)
+	if err != nil {
+		panic(err)
+	}
+
+	return c
+}
+
+// CheckOpt is a functional option for configuring a Check.
+type CheckOpt func(*Check)
+
+// WithSeverity sets the severity of violations.
+func WithSeverity(severity kube.Severity) CheckOpt {
+	return func(c *Check) {
+		c.Severity = severity
+	}
+}
+
+// WithMessage sets the message describing violations.
+func WithMessage(msg string) CheckOpt {
+	return func(c *Check) {
+		c.Message = msg
+	}
+}
+
+// Compile validates the check and compiles its expression into a CEL
+// program.
+func (c *Check) Compile() error {
+	if c.Name == "" {
+		return ErrMissingName
+	}
+
+	switch c.Severity {
+	case "", kube.SeverityError, kube.SeverityWarning:
+	default:
+		return fmt.Errorf("%w: %q", ErrInvalidSeverity, c.Severity)
+	}
+
+	if c.Expr == "" {
+		return errors.New("compile expression: expression is required")
+	}
+
+	if c.program == nil {
+		env, err := expr.NewEnvironment(
+			cel.Variable("object", cel.MapType(cel.StringType, cel.DynType)),
+			cel.Variable("resources", cel.ListType(cel.MapType(cel.StringType, cel.DynType))),
+		)
+		if err != nil {
+			return fmt.Errorf("create CEL environment: %w", err)
+		}
+
+		c.program = expr.NewLazyProgram(c.Expr, env)
+	}
+
+	_, err := c.program.Get()
+	if err != nil {
+		return fmt.Errorf("compile expression: %w", err)
+	}
+
+	return nil
+}
+
+// GetSeverity returns the severity of violations.
+func (c *Check) GetSeverity() kube.Severity {
+	if c.Severity == "" {
+		return kube.SeverityWarning
+	}
+
+	return c.Severity
+}
+
+// GetMessage returns the message describing violations.
+func (c *Check) GetMessage() string {
+	if c.Message == "" {
+		return fmt.Sprintf("violates check %q", c.Name)
+	}
+
+	return c.Message
+}
+
+// violates evaluates the check against a single object. Both arguments must
+// be converted to CEL values.
+func (c *Check) violates(object, resources ref.Val) (bool, error) {
+	if c.program == nil {
+		panic(errors.New("check missing an expression"))
+	}
+
+	program, err := c.program.Get()
+	if err != nil {
+		return false, fmt.Errorf("compile expression: %w", err)
+	}
+
+	result, _, err := program.Eval(map[string]any{
+		"object":    object,
+		"resources": resources,
+	})
+	if err != nil {
+		return false, fmt.Errorf("evaluate expression: %w", err)
+	}
+
+	// CEL expression must return a boolean value.
+	violates, ok := result.Value().(bool)
+	if !ok {
+		return false, errors.New("expression did not return a boolean value")
+	}
+
+	return violates, nil
+}
+
+// Run evaluates all checks against all resources, and appends a
+// [kube.Diagnostic] to each resource for every violated check.
+func Run(checks []*Check, resources []*kube.Resource) {
+	if len(checks) == 0 {
+		return
+	}
+
+	// Convert each object once, since every check sees every object.
+	objs := make([]ref.Val, 0, len(resources))
+	for _, r := range resources {
+		objs = append(objs, expr.ConvertToCELValue(map[string]any(*r.Object)))
+	}
+
+	all := types.NewDynamicList(types.DefaultTypeAdapter, objs)
+
+	for i, r := range resources {
+		for _, c := range checks {
+			violates, err := c.violates(objs[i], all)
+			if err != nil {
+				slog.Debug("skip check",
+					slog.String("check", c.Name),
+					slog.String("resource", r.Object.GetMetadata().String()),
+					slog.Any("err", err),
+				)
+
+				continue
+			}
+
+			if violates {
+				r.Diagnostics = append(r.Diagnostics, kube.Diagnostic{
+					Severity: c.GetSeverity(),
+					Source:   c.Name,
+					Message:  c.GetMessage(),
+				})
+			}
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package check_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/macropower/kat/pkg/check"
+	"github.com/macropower/kat/pkg/kube"
+)
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+
+	tcs := map[string]struct {
+		name     string
+		expr     string
+		severity kube.Severity
+		wantErr  bool
+	}{
+		"valid check": {
+			name: "no-default-namespace",
+			expr: `has(object.metadata.namespace) && object.metadata.namespace == "default"`,
+		},
+		"valid check with severity": {
+			name:     "no-default-namespace",
+			expr:     `object.kind == "Namespace"`,
+			severity: kube.SeverityError,
+		},
+		"invalid CEL expression": {
+			name:    "invalid",
+			expr:    "object.invalidFunction()",
+			wantErr: true,
+		},
+		"empty expression": {
+			name:    "empty",
+			wantErr: true,
+		},
+		"empty name": {
+			expr:    "true",
+			wantErr: true,
+		},
+		"invalid severity": {
+			name:     "invalid",
+			expr:     "true",
+			severity: "fatal",
+			wantErr:  true,
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			c, err := check.New(tc.name, tc.expr, check.WithSeverity(tc.severity))
+			if tc.wantErr {
+				require.Error(t, err)
+				assert.Nil(t, c)
+
+				return
+			}
+
+			require.NoError(t, err)
+			require.NotNil(t, c)
+			assert.Equal(t, tc.expr, c.Expr)
+		})
+	}
+}
+
+func TestRun(t *testing.T) {
+	t.Parallel()
+
+	resources, err := kube.SplitYAML([]byte(`apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: insecure
+spec:
+  template:
+    spec:
+      containers: []
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: secure
+spec:
+  template:
+    spec:
+      securityContext:
+        runAsNonRoot: true
+      containers: []
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: insecure
+`))
+	require.NoError(t, err)
+
+	checks := []*check.Check{
+		check.MustNew("pod-security-context",
+			`object.kind == "Deployment" && !has(object.spec.template.spec.securityContext)`,
+			check.WithSeverity(kube.SeverityError),
+			check.WithMessage("pods must set a securityContext"),
+		),
+		check.MustNew("unique-names",
+			`resources.filter(r, r.metadata.name == object.metadata.name).size() > 1`,
+		),
+		// Fails to evaluate for objects without a spec, which is not a violation.
+		check.MustNew("replicas", `object.spec.replicas > 1`),
+	}
+
+	check.Run(checks, resources)
+
+	require.Len(t, resources, 3)
+	assert.Equal(t, []kube.Diagnostic{
+		{
+			Severity: kube.SeverityError,
+			Source:   "pod-security-context",
+			Message:  "pods must set a securityContext",
+		},
+		{
+			Severity: kube.SeverityWarning,
+			Source:   "unique-names",
+			Message:  `violates check "unique-names"`,
+		},
+	}, resources[0].Diagnostics)
+	assert.Empty(t, resources[1].Diagnostics)
+	assert.Equal(t, []kube.Diagnostic{
+		{
+			Severity: kube.SeverityWarning,
+			Source:   "unique-names",
+			Message:  `violates check "unique-names"`,
+		},
+	}, resources[2].Diagnostics)
+}
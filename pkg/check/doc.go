@@ -0,0 +1,7 @@
+// Package check evaluates user-defined policy checks against rendered
+// resources, by using CEL (Common Expression Language) expressions.
+//
+// The expressions have access to each rendered object, as well as the full
+// list of rendered objects, allowing for simple guardrails without a separate
+// policy toolchain.
+package check
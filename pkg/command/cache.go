@@ -0,0 +1,354 @@
+package command
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/macropower/kat/pkg/execs"
+	"github.com/macropower/kat/pkg/log"
+	"github.com/macropower/kat/pkg/profile"
+)
+
+// cacheVersion is included in every cache key. It must be incremented when
+// the key or entry format changes, so that stale entries are not reused.
+const cacheVersion = 3
+
+// CacheConfig configures caching of render output.
+type CacheConfig struct {
+	// Enabled controls whether render output is cached.
+	// Caching is disabled by default.
+	Enabled *bool `json:"enabled,omitempty" jsonschema:"title=Enabled"`
+	// Dir is the directory where cached render output is stored.
+	// Defaults to the kat directory in the user's cache directory.
+	Dir string `json:"dir,omitempty" jsonschema:"title=Directory"`
+}
+
+// IsEnabled reports whether caching is enabled.
+func (c *CacheConfig) IsEnabled() bool {
+	return c != nil && c.Enabled != nil && *c.Enabled
+}
+
+// Cache stores the output of successful renders on disk, keyed by a hash of
+// the render inputs. It is safe for concurrent use, including by multiple
+// processes sharing the same directory.
+//
+// The render inputs are the command, arguments, environment and hooks of the
+// profile, plus the path and content of each file selected by the profile's
+// source expression, each file of the project's Kustomize and Helm
+// dependencies (see [FindDependencies]), and, for Helm charts, each file of
+// the chart, including the archives of its dependencies. Renders using
+// profiles without a source expression, or whose dependencies cannot be
+// found, are never cached, since their inputs are unknown.
+type Cache struct {
+	dir string
+}
+
+// NewCache creates a new [Cache] that stores entries in the given directory.
+// The directory is created when the first entry is written.
+func NewCache(dir string) *Cache {
+	return &Cache{dir: dir}
+}
+
+// Dir returns the directory where entries are stored.
+func (c *Cache) Dir() string {
+	return c.dir
+}
+
+// cacheEntry is a cached render output.
+type cacheEntry struct {
+	Stdout string `json:"stdout"`
+	Stderr string `json:"stderr"`
+}
+
+func (c *Cache) entryPath(key string) string {
+	return filepath.Join(c.dir, key+".json")
+}
+
+// get returns the entry for the given key, if one exists.
+func (c *Cache) get(key string) (cacheEntry, bool, error) {
+	var entry cacheEntry
+
+	data, err := os.ReadFile(c.entryPath(key))
+	if errors.Is(err, fs.ErrNotExist) {
+		return entry, false, nil
+	}
+	if err != nil {
+		return entry, false, fmt.Errorf("read cache entry: %w", err)
+	}
+
+	err = json.Unmarshal(data, &entry)
+	if err != nil {
+		return entry, false, fmt.Errorf("decode cache entry: %w", err)
+	}
+
+	return entry, true, nil
+}
+
+// put stores the entry for the given key. The entry is written to a temporary
+// file first, so that concurrent readers never observe partial entries.
+func (c *Cache) put(key string, entry cacheEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("encode cache entry: %w", err)
+	}
+
+	err = os.MkdirAll(c.dir, 0o750)
+	if err != nil {
+		return fmt.Errorf("create cache directory: %w", err)
+	}
+
+	f, err := os.CreateTemp(c.dir, key+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("create cache entry: %w", err)
+	}
+
+	_, err = f.Write(data)
+	if err != nil {
+		return errors.Join(fmt.Errorf("write cache entry: %w", err), f.Close(), os.Remove(f.Name()))
+	}
+
+	err = f.Close()
+	if err != nil {
+		return errors.Join(fmt.Errorf("close cache entry: %w", err), os.Remove(f.Name()))
+	}
+
+	err = os.Rename(f.Name(), c.entryPath(key))
+	if err != nil {
+		return errors.Join(fmt.Errorf("rename cache entry: %w", err), os.Remove(f.Name()))
+	}
+
+	return nil
+}
+
+// cacheKeyInput contains everything that may affect the output of a render.
+type cacheKeyInput struct {
+	Dir       string            `json:"dir"`
+	Command   cacheKeyCommand   `json:"command"`
+	Hooks     []cacheKeyCommand `json:"hooks,omitempty"`
+	Files     []cacheKeyFile    `json:"files"`
+	Variant   *cacheKeyVariant  `json:"variant,omitempty"`
+	ExtraArgs []string          `json:"extraArgs,omitempty"`
+	Version   int               `json:"version"`
+}
+
+type cacheKeyCommand struct {
+	Command string   `json:"command"`
+	Args    []string `json:"args,omitempty"`
+	Env     []string `json:"env,omitempty"`
+}
+
+type cacheKeyVariant struct {
+	Name string   `json:"name"`
+	Path string   `json:"path,omitempty"`
+	Args []string `json:"args,omitempty"`
+	Env  []string `json:"env,omitempty"`
+}
+
+type cacheKeyFile struct {
+	Path string `json:"path"`
+	Hash string `json:"hash"`
+}
+
+func newCacheKeyCommand(c execs.Command) cacheKeyCommand {
+	env := c.GetEnv()
+	slices.Sort(env)
+
+	return cacheKeyCommand{
+		Command: c.Command,
+		Args:    c.Args,
+		Env:     env,
+	}
+}
+
+// newCacheKeyVariant returns the key of the variant applied to the profile,
+// or nil if no variant is applied. The environment is that of the profile's
+// command with the variant applied, so that values from the caller are
+// resolved.
+func newCacheKeyVariant(p *profile.Profile) *cacheKeyVariant {
+	v := p.GetVariant()
+	if v == nil {
+		return nil
+	}
+
+	return &cacheKeyVariant{
+		Name: p.GetVariantName(),
+		Path: v.Path,
+		Args: v.Args,
+		Env:  newCacheKeyCommand(v.Apply(p.Command)).Env,
+	}
+}
+
+// cacheKey returns the cache key for rendering the path with the profile.
+// It returns an empty key if the render inputs cannot be determined.
+func (cr *Runner) cacheKey(path string, p *profile.Profile) (string, error) {
+	inputs, err := cr.cacheInputs(path, p)
+	if err != nil || len(inputs) == 0 {
+		return "", err
+	}
+
+	in := cacheKeyInput{
+		Version:   cacheVersion,
+		Dir:       filepath.Join(cr.root.Name(), path),
+		Command:   newCacheKeyCommand(p.Command),
+		Variant:   newCacheKeyVariant(p),
+		ExtraArgs: p.ExtraArgs,
+		Files:     make([]cacheKeyFile, 0, len(inputs)),
+	}
+
+	if p.Hooks != nil {
+		for _, hook := range slices.Concat(p.Hooks.PreRender, p.Hooks.PostRender) {
+			in.Hooks = append(in.Hooks, newCacheKeyCommand(hook.Command))
+		}
+	}
+
+	for _, file := range inputs {
+		data, err := fs.ReadFile(cr.root.FS(), filepath.ToSlash(file))
+		if err != nil {
+			return "", fmt.Errorf("read %q: %w", file, err)
+		}
+
+		sum := sha256.Sum256(data)
+		in.Files = append(in.Files, cacheKeyFile{
+			Path: file,
+			Hash: hex.EncodeToString(sum[:]),
+		})
+	}
+
+	data, err := json.Marshal(in)
+	if err != nil {
+		return "", fmt.Errorf("encode cache key: %w", err)
+	}
+
+	sum := sha256.Sum256(data)
+
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// cacheInputs returns the sorted paths of the files that rendering the path
+// with the profile may read, relative to the root. It returns no paths if the
+// profile has no source expression, or if its source matches no files.
+func (cr *Runner) cacheInputs(path string, p *profile.Profile) ([]string, error) {
+	files, err := cr.listFiles(path, p)
+	if err != nil {
+		return nil, err
+	}
+
+	ok, matchedFiles := p.MatchFiles(path, files)
+	if !ok || len(matchedFiles) == 0 {
+		return nil, nil
+	}
+
+	dir := p.VariantDir(path)
+
+	// Kustomize and Helm dependencies are often outside of the path, e.g.
+	// `../../base`, and are not necessarily matched by the source expression,
+	// e.g. the inputs of a configMapGenerator.
+	deps, err := cr.findDependencies(dir)
+	if err != nil {
+		return nil, fmt.Errorf("find dependencies: %w", err)
+	}
+
+	chart, err := cr.chartInputs(dir, p)
+	if err != nil {
+		return nil, err
+	}
+
+	inputs := slices.Concat(matchedFiles, deps, chart)
+	slices.Sort(inputs)
+
+	return slices.Compact(inputs), nil
+}
+
+// chartInputs returns the paths of the files of the Helm chart in dir,
+// relative to the root, or nil if dir does not contain a chart. Templates can
+// read any file of the chart, e.g. with `.Files.Get`. The archives of the
+// chart's dependencies are included even if they are ignored, since they are
+// usually excluded from version control.
+func (cr *Runner) chartInputs(dir string, p *profile.Profile) ([]string, error) {
+	isChart := slices.ContainsFunc(chartFiles, func(name string) bool {
+		_, err := cr.root.Stat(filepath.Join(dir, name))
+		return err == nil
+	})
+	if !isChart {
+		return nil, nil
+	}
+
+	files, err := cr.listFiles(dir, p)
+	if err != nil {
+		return nil, err
+	}
+
+	archives, err := fs.Glob(cr.root.FS(), filepath.ToSlash(filepath.Join(dir, "charts", "*.tgz")))
+	if err != nil {
+		return nil, fmt.Errorf("find chart archives: %w", err)
+	}
+
+	for _, archive := range archives {
+		files = append(files, filepath.FromSlash(archive))
+	}
+
+	return files, nil
+}
+
+// cacheKeyFor returns the cache key for rendering the path with the profile,
+// or an empty key if caching is disabled or not possible.
+func (cr *Runner) cacheKeyFor(ctx context.Context, cache *Cache, path string, p *profile.Profile) string {
+	if cache == nil {
+		return ""
+	}
+
+	key, err := cr.cacheKey(path, p)
+	if err != nil {
+		log.WithContext(ctx).WarnContext(ctx, "compute cache key",
+			slog.String("path", path),
+			slog.Any("error", err),
+		)
+	}
+
+	return key
+}
+
+// cacheGet returns the cached entry for the key, if one exists.
+func (cr *Runner) cacheGet(ctx context.Context, cache *Cache, key string) (cacheEntry, bool) {
+	if cache == nil || key == "" {
+		return cacheEntry{}, false
+	}
+
+	entry, ok, err := cache.get(key)
+	if err != nil {
+		log.WithContext(ctx).WarnContext(ctx, "read cache",
+			slog.String("key", key),
+			slog.Any("error", err),
+		)
+	}
+
+	if ok {
+		log.WithContext(ctx).DebugContext(ctx, "using cached output", slog.String("key", key))
+	}
+
+	return entry, ok
+}
+
+// cachePut stores the entry for the key.
+func (cr *Runner) cachePut(ctx context.Context, cache *Cache, key string, entry cacheEntry) {
+	if cache == nil || key == "" {
+		return
+	}
+
+	err := cache.put(key, entry)
+	if err != nil {
+		log.WithContext(ctx).WarnContext(ctx, "write cache",
+			slog.String("key", key),
+			slog.Any("error", err),
+		)
+	}
+}
@@ -0,0 +1,321 @@
+package command_test
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/macropower/kat/pkg/command"
+	"github.com/macropower/kat/pkg/kube"
+	"github.com/macropower/kat/pkg/profile"
+)
+
+func TestRunner_Cache(t *testing.T) {
+	t.Parallel()
+
+	const (
+		// The script counts its runs in a log outside of the project
+		// directory, so that the log is not a render input.
+		render     = "cat app.yaml; echo run >> %[1]s/runs.log"
+		sourceYAML = `files.filter(f, pathExt(f) == ".yaml")`
+		configMap  = "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: test\n"
+	)
+
+	tcs := map[string]struct {
+		between   func(t *testing.T, dir string)
+		script    string
+		source    string
+		want      string
+		wantRuns  int
+		newRunner bool
+		wantErr   bool
+	}{
+		"unchanged inputs reuse output": {
+			script:   render,
+			source:   sourceYAML,
+			want:     configMap,
+			wantRuns: 1,
+		},
+		"unchanged inputs reuse output across runners": {
+			script:    render,
+			source:    sourceYAML,
+			want:      configMap,
+			wantRuns:  1,
+			newRunner: true,
+		},
+		"changed input is rendered again": {
+			between: func(t *testing.T, dir string) {
+				t.Helper()
+
+				err := os.WriteFile(filepath.Join(dir, "app.yaml"),
+					[]byte(strings.Replace(configMap, "test", "changed", 1)), 0o644)
+				require.NoError(t, err)
+			},
+			script:   render,
+			source:   sourceYAML,
+			want:     strings.Replace(configMap, "test", "changed", 1),
+			wantRuns: 2,
+		},
+		"changed file outside source is ignored": {
+			between: func(t *testing.T, dir string) {
+				t.Helper()
+
+				err := os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("changed"), 0o644)
+				require.NoError(t, err)
+			},
+			script:   render,
+			source:   sourceYAML,
+			want:     configMap,
+			wantRuns: 1,
+		},
+		"profile without source is not cached": {
+			script:   render,
+			want:     configMap,
+			wantRuns: 2,
+		},
+		"failed render is not cached": {
+			script:   render + "; exit 1",
+			source:   sourceYAML,
+			wantRuns: 2,
+			wantErr:  true,
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			root, dir := testRoot(t)
+			require.NoError(t, os.WriteFile(filepath.Join(dir, "app.yaml"), []byte(configMap), 0o644))
+			require.NoError(t, os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("notes"), 0o644))
+
+			logDir := t.TempDir()
+
+			opts := []profile.ProfileOpt{profile.WithArgs("-c", fmt.Sprintf(tc.script, logDir))}
+			if tc.source != "" {
+				opts = append(opts, profile.WithSource(tc.source))
+			}
+
+			p, err := profile.New("sh", opts...)
+			require.NoError(t, err)
+
+			cache := command.NewCache(t.TempDir())
+
+			newRunner := func() *command.Runner {
+				runner, err := command.NewRunnerWithRoot(root, ".",
+					command.WithCustomProfile("sh", p),
+					command.WithCache(cache),
+				)
+				require.NoError(t, err)
+				t.Cleanup(runner.Close)
+
+				return runner
+			}
+
+			runner := newRunner()
+
+			first := runner.RunContext(t.Context())
+			if tc.wantErr {
+				require.Error(t, first.Error)
+			} else {
+				require.NoError(t, first.Error)
+			}
+
+			if tc.between != nil {
+				tc.between(t, dir)
+			}
+
+			if tc.newRunner {
+				runner = newRunner()
+			}
+
+			second := runner.RunContext(t.Context())
+			if tc.wantErr {
+				require.Error(t, second.Error)
+			} else {
+				require.NoError(t, second.Error)
+				assert.Equal(t, tc.want, second.Stdout)
+				require.Len(t, second.Resources, 1)
+				assert.Equal(t, "ConfigMap", second.Resources[0].Object.GetKind())
+			}
+
+			runs, err := os.ReadFile(filepath.Join(logDir, "runs.log"))
+			require.NoError(t, err)
+			assert.Equal(t, tc.wantRuns, strings.Count(string(runs), "run\n"))
+		})
+	}
+}
+
+func TestRunner_CacheDependencies(t *testing.T) {
+	t.Parallel()
+
+	const (
+		configMap = "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: test\n"
+		chart     = "apiVersion: v2\nname: app\nversion: 0.1.0\n"
+	)
+
+	tcs := map[string]struct {
+		files    map[string]string
+		path     string
+		change   string
+		ignore   []string
+		wantRuns int
+	}{
+		"changed kustomize base outside of path": {
+			files: map[string]string{
+				"app/kustomization.yaml":  "resources:\n- ../base\n",
+				"base/kustomization.yaml": "resources:\n- cm.yaml\n",
+				"base/cm.yaml":            configMap,
+			},
+			path:     "app",
+			change:   "base/cm.yaml",
+			wantRuns: 2,
+		},
+		"changed kustomize generator input": {
+			files: map[string]string{
+				"app/kustomization.yaml": "configMapGenerator:\n- name: test\n  files:\n  - data.json\n",
+				"app/data.json":          "{}",
+			},
+			path:     "app",
+			change:   "app/data.json",
+			wantRuns: 2,
+		},
+		"changed chart file": {
+			files: map[string]string{
+				"app/Chart.yaml":      chart,
+				"app/files/data.json": "{}",
+			},
+			path:     "app",
+			change:   "app/files/data.json",
+			wantRuns: 2,
+		},
+		"changed ignored chart archive": {
+			files: map[string]string{
+				"app/Chart.yaml":      chart,
+				"app/charts/dep.tgz":  "dep",
+				"app/charts/dep.yaml": configMap,
+			},
+			path:     "app",
+			change:   "app/charts/dep.tgz",
+			ignore:   []string{"*.tgz"},
+			wantRuns: 2,
+		},
+		"dependency outside of root is not cached": {
+			files: map[string]string{
+				"app/kustomization.yaml": "resources:\n- ../../base\n",
+				"app/notes.txt":          "notes",
+			},
+			path:     "app",
+			change:   "app/notes.txt",
+			wantRuns: 2,
+		},
+		"changed file outside of project": {
+			files: map[string]string{
+				"app/kustomization.yaml": "resources:\n- cm.yaml\n",
+				"other/cm.yaml":          configMap,
+			},
+			path:     "app",
+			change:   "other/cm.yaml",
+			wantRuns: 1,
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			root, dir := testRoot(t)
+
+			files := map[string]string{filepath.Join(tc.path, "cm.yaml"): configMap}
+			maps.Copy(files, tc.files)
+
+			for name, content := range files {
+				path := filepath.Join(dir, filepath.FromSlash(name))
+				require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+				require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+			}
+
+			logDir := t.TempDir()
+
+			p, err := profile.New("sh",
+				profile.WithArgs("-c", fmt.Sprintf("cat cm.yaml; echo run >> %s/runs.log", logDir)),
+				profile.WithSource(`files.filter(f, pathExt(f) == ".yaml")`),
+			)
+			require.NoError(t, err)
+
+			runner, err := command.NewRunnerWithRoot(root, tc.path,
+				command.WithCustomProfile("sh", p),
+				command.WithCache(command.NewCache(t.TempDir())),
+				command.WithIgnore(tc.ignore...),
+			)
+			require.NoError(t, err)
+			t.Cleanup(runner.Close)
+
+			require.NoError(t, runner.RunContext(t.Context()).Error)
+
+			path := filepath.Join(dir, filepath.FromSlash(tc.change))
+			require.NoError(t, os.WriteFile(path, []byte("changed"), 0o644))
+
+			require.NoError(t, runner.RunContext(t.Context()).Error)
+
+			runs, err := os.ReadFile(filepath.Join(logDir, "runs.log"))
+			require.NoError(t, err)
+			assert.Equal(t, tc.wantRuns, strings.Count(string(runs), "run\n"))
+		})
+	}
+}
+
+func TestRunner_CacheVariants(t *testing.T) {
+	t.Parallel()
+
+	root, dir := testRoot(t)
+	files := map[string]string{
+		"env-dev.yaml":  "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: app\ndata:\n  env: dev\n",
+		"env-prod.yaml": "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: app\ndata:\n  env: prod\n",
+	}
+	for name, content := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644))
+	}
+
+	p, err := profile.New("cat",
+		profile.WithSource(`files.filter(f, pathExt(f) == ".yaml")`),
+		profile.WithVariants(map[string]*profile.Variant{
+			"envs": {
+				Source: `files.filter(f, pathBase(f).startsWith("env-"))`,
+				Args:   []string{"$(file)"},
+			},
+		}),
+	)
+	require.NoError(t, err)
+
+	runner, err := command.NewRunnerWithRoot(root, ".",
+		command.WithProfiles(map[string]*profile.Profile{"cat": p}),
+		command.WithProfile("cat"),
+		command.WithCache(command.NewCache(t.TempDir())),
+	)
+	require.NoError(t, err)
+	t.Cleanup(runner.Close)
+
+	for name, want := range files {
+		err := runner.Configure(command.WithVariant(name))
+		require.NoError(t, err)
+
+		out := runner.Run()
+		require.NoError(t, out.Error)
+		assert.Equal(t, want, out.Stdout, name)
+	}
+
+	// The matrix renders every variant with the same cache.
+	out, err := runner.RunMatrix()
+	require.NoError(t, err)
+	require.NoError(t, out.Err())
+	require.Len(t, out.Matrix.Resources, 1)
+	assert.Equal(t, []kube.FieldDifference{{Path: ".data.env", Values: []any{"dev", "prod"}}},
+		out.Matrix.Resources[0].Fields)
+}
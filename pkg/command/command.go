@@ -4,6 +4,7 @@ import (
 	"context"
 	"time"
 
+	"github.com/macropower/kat/pkg/execs"
 	"github.com/macropower/kat/pkg/kube"
 	"github.com/macropower/kat/pkg/profile"
 )
@@ -16,12 +17,17 @@ type Commander interface {
 	Subscribe(ch chan<- Event)
 	GetProfiles() map[string]*profile.Profile
 	GetCurrentProfile() (string, *profile.Profile)
+	GetCurrentVariant() string
 	GetPath() string
+	GetWorkspace() string
+	FindVariants(path, profileName string) ([]string, error)
 	FindProfiles(path string) ([]ProfileMatch, error)
+	RunMatrix(names ...string) (MatrixOutput, error)
+	RunMatrixContext(ctx context.Context, names ...string) (MatrixOutput, error)
 	Configure(opts ...RunnerOpt) error
 	ConfigureContext(ctx context.Context, opts ...RunnerOpt) error
-	RunPlugin(name string) Output
-	RunPluginContext(ctx context.Context, name string) Output
+	RunPlugin(name string, opts ...PluginRunOpt) Output
+	RunPluginContext(ctx context.Context, name string, opts ...PluginRunOpt) Output
 	FS() (*FilteredFS, error)
 	SendEvent(evt Event)
 }
@@ -41,7 +47,10 @@ type Output struct {
 	Stdout    string
 	Stderr    string
 	Resources []*kube.Resource
-	Type      Type
+	// Warnings contains the lines of Stderr of a successful render, see
+	// [ParseWarnings].
+	Warnings []Warning
+	Type     Type
 }
 
 // NewOutput creates a new [Output] timestamped with the current time.
@@ -66,6 +75,64 @@ func WithError(err error) OutputOpt {
 	}
 }
 
+// PluginRun contains the options for a plugin execution.
+type PluginRun struct {
+	// Resource is the resource that is currently shown. Its metadata is
+	// passed to the plugin as environment variables.
+	Resource *kube.Resource
+	// Resources are the selected resources. They are written to the plugin's
+	// standard input as a multi-document YAML stream. If no resources are
+	// selected, the current Resource is written instead.
+	Resources []*kube.Resource
+}
+
+type PluginRunOpt func(*PluginRun)
+
+// WithPluginResource passes the current resource to the plugin.
+func WithPluginResource(resource *kube.Resource) PluginRunOpt {
+	return func(pr *PluginRun) {
+		pr.Resource = resource
+	}
+}
+
+// WithPluginResources passes the selected resources to the plugin on stdin.
+func WithPluginResources(resources ...*kube.Resource) PluginRunOpt {
+	return func(pr *PluginRun) {
+		pr.Resources = resources
+	}
+}
+
+// stdin returns the standard input of the plugin.
+func (pr *PluginRun) stdin() []byte {
+	switch {
+	case len(pr.Resources) > 0:
+		return []byte(kube.JoinResources(pr.Resources))
+	case pr.Resource != nil:
+		return []byte(pr.Resource.Content())
+	}
+
+	return nil
+}
+
+// env returns the environment variables of the plugin, which describe the
+// profile, path and current resource.
+func (pr *PluginRun) env(profileName, path string) []execs.EnvVar {
+	env := []execs.EnvVar{
+		{Name: profile.PluginEnvProfile, Value: profileName},
+		{Name: profile.PluginEnvPath, Value: path},
+	}
+
+	if pr.Resource != nil && pr.Resource.Object != nil {
+		env = append(env,
+			execs.EnvVar{Name: profile.PluginEnvKind, Value: pr.Resource.Object.GetKind()},
+			execs.EnvVar{Name: profile.PluginEnvName, Value: pr.Resource.Object.GetName()},
+			execs.EnvVar{Name: profile.PluginEnvNamespace, Value: pr.Resource.Object.GetNamespace()},
+		)
+	}
+
+	return env
+}
+
 // Event represents an event related to command execution.
 type Event interface {
 	GetContext() context.Context
@@ -123,11 +190,13 @@ func (e EventCancel) GetContext() context.Context {
 // EventConfigure indicates that a command has been configured (or re-configured).
 type EventConfigure struct {
 	context context.Context
+	// Path is the path that the command is configured for.
+	Path string
 }
 
-// NewEventConfigure creates a new EventConfigure with the given context.
-func NewEventConfigure(ctx context.Context) EventConfigure {
-	return EventConfigure{context: ctx}
+// NewEventConfigure creates a new EventConfigure with the given context and path.
+func NewEventConfigure(ctx context.Context, path string) EventConfigure {
+	return EventConfigure{context: ctx, Path: path}
 }
 
 // GetContext returns the context associated with the EventConfigure.
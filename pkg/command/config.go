@@ -1,15 +1,20 @@
 package command
 
 import (
+	"errors"
 	"fmt"
 	"maps"
+	"slices"
 
 	"go.jacobcolvin.com/niceyaml"
 	"go.jacobcolvin.com/niceyaml/paths"
 
+	"github.com/macropower/kat/pkg/check"
 	"github.com/macropower/kat/pkg/execs"
+	"github.com/macropower/kat/pkg/ignore"
 	"github.com/macropower/kat/pkg/profile"
 	"github.com/macropower/kat/pkg/rule"
+	"github.com/macropower/kat/pkg/validate"
 )
 
 const (
@@ -25,6 +30,13 @@ const (
 
 	existsYAMLFiles = `files.exists(f,
   pathExt(f) in [".yaml", ".yml"])`
+
+	discoverHelmValuesFiles = `files.filter(f,
+  pathBase(f).matches("^values-.+[.]ya?ml$"))`
+
+	discoverKustomizeOverlays = `files.filter(f,
+  pathBase(f) in ["kustomization.yaml", "kustomization.yml"] &&
+  pathDir(f).contains("overlays")).map(f, pathDir(f))`
 )
 
 // Config defines the core (non-UI) kat configuration.
@@ -33,6 +45,22 @@ type Config struct {
 	Profiles map[string]*profile.Profile `json:"profiles,omitempty" jsonschema:"title=Profiles"`
 	// Rules defines the rules for matching files to profiles.
 	Rules []*rule.Rule `json:"rules,omitempty" jsonschema:"title=Rules"`
+	// Checks contains CEL policy checks that are evaluated against rendered resources.
+	Checks []*check.Check `json:"checks,omitempty" jsonschema:"title=Checks"`
+	// Validation configures schema validation of rendered resources.
+	Validation *validate.Config `json:"validation,omitempty" jsonschema:"title=Validation"`
+	// Cache configures caching of render output.
+	Cache *CacheConfig `json:"cache,omitempty" jsonschema:"title=Cache"`
+	// Watch configures how filesystem changes are detected when watch is enabled.
+	Watch *WatcherConfig `json:"watch,omitempty" jsonschema:"title=Watch"`
+	// Ignore contains gitignore-style patterns of files and directories that
+	// are skipped when matching rules and sources, e.g. `node_modules/`.
+	// Ignored directories are not traversed.
+	Ignore []string `json:"ignore,omitempty" jsonschema:"title=Ignore"`
+	// IgnoreFiles contains the names of gitignore-style files whose patterns
+	// are also honored, e.g. `.gitignore`. Each file applies to the directory
+	// that contains it. Defaults to `.katignore`.
+	IgnoreFiles []string `json:"ignoreFiles,omitempty" jsonschema:"title=Ignore Files"`
 }
 
 // NewConfig creates a new [Config] with default profiles and rules.
@@ -49,6 +77,7 @@ func (c *Config) EnsureDefaults() {
 			"ks": profile.MustNew("kustomize",
 				profile.WithArgs("build", "."),
 				profile.WithSource(filterYAMLFiles),
+				profile.WithVariants(kustomizeVariants()),
 				profile.WithHooks(
 					profile.MustNewHooks(
 						profile.WithInit(
@@ -59,6 +88,7 @@ func (c *Config) EnsureDefaults() {
 			"ks-helm": profile.MustNew("kustomize",
 				profile.WithArgs("build", ".", "--enable-helm"),
 				profile.WithSource(filterYAMLFiles),
+				profile.WithVariants(kustomizeVariants()),
 				profile.WithHooks(
 					profile.MustNewHooks(
 						profile.WithInit(
@@ -70,6 +100,12 @@ func (c *Config) EnsureDefaults() {
 				profile.WithArgs("template", "."),
 				profile.WithExtraArgs("-g"),
 				profile.WithSource(filterHelmFiles),
+				profile.WithVariants(map[string]*profile.Variant{
+					"values": {
+						Source: discoverHelmValuesFiles,
+						Args:   []string{"-f", profile.VariantFile},
+					},
+				}),
 				profile.WithEnvFrom([]execs.EnvFromSource{
 					{
 						CallerRef: &execs.CallerRef{
@@ -116,11 +152,35 @@ func (c *Config) EnsureDefaults() {
 			rule.MustNew("yaml", existsYAMLFiles),
 		}
 	}
+
+	if c.Ignore == nil {
+		c.Ignore = slices.Clone(defaultIgnore)
+	}
+
+	if c.IgnoreFiles == nil {
+		c.IgnoreFiles = slices.Clone(defaultIgnoreFiles)
+	}
+}
+
+// kustomizeVariants returns the default variants for Kustomize profiles.
+func kustomizeVariants() map[string]*profile.Variant {
+	return map[string]*profile.Variant{
+		"overlays": {
+			Source: discoverKustomizeOverlays,
+			Path:   profile.VariantFile,
+		},
+	}
 }
 
 // Merge merges another Config into this one.
 // Project profiles override global profiles with the same key.
 // Project rules are prepended to global rules (evaluated first, higher priority).
+// Project checks replace global checks with the same name, and are otherwise appended.
+// Project validation settings replace global validation settings.
+// Project cache settings replace global cache settings.
+// Project watch settings replace global watch settings.
+// Project ignore patterns are appended to global ignore patterns.
+// Project ignore files replace global ignore files.
 func (c *Config) Merge(project *Config) {
 	if project == nil {
 		return
@@ -138,6 +198,35 @@ func (c *Config) Merge(project *Config) {
 	if len(project.Rules) > 0 {
 		c.Rules = append(project.Rules, c.Rules...)
 	}
+
+	for _, pc := range project.Checks {
+		i := slices.IndexFunc(c.Checks, func(gc *check.Check) bool {
+			return gc.Name == pc.Name
+		})
+		if i >= 0 {
+			c.Checks[i] = pc
+		} else {
+			c.Checks = append(c.Checks, pc)
+		}
+	}
+
+	if project.Validation != nil {
+		c.Validation = project.Validation
+	}
+
+	if project.Cache != nil {
+		c.Cache = project.Cache
+	}
+
+	if project.Watch != nil {
+		c.Watch = project.Watch
+	}
+
+	c.Ignore = append(c.Ignore, project.Ignore...)
+
+	if project.IgnoreFiles != nil {
+		c.IgnoreFiles = project.IgnoreFiles
+	}
 }
 
 func (c *Config) Validate() error {
@@ -214,5 +303,50 @@ func (c *Config) Validate() error {
 		}
 	}
 
+	for i, chk := range c.Checks {
+		err := chk.Compile()
+		if err != nil {
+			field := "expr"
+
+			switch {
+			case errors.Is(err, check.ErrMissingName):
+				field = "name"
+			case errors.Is(err, check.ErrInvalidSeverity):
+				field = "severity"
+			}
+
+			return niceyaml.NewErrorFrom(
+				fmt.Errorf("invalid check: %w", err),
+				niceyaml.WithPath(paths.Root().Child("checks").Index(i).Child(field).Key()),
+			)
+		}
+	}
+
+	_, err := ignore.New(c.Ignore...)
+	if err != nil {
+		return niceyaml.NewErrorFrom(
+			fmt.Errorf("invalid ignore: %w", err),
+			niceyaml.WithPath(paths.Root().Child("ignore").Key()),
+		)
+	}
+
+	if c.Watch != nil {
+		err := c.Watch.Backend.Validate()
+		if err != nil {
+			return niceyaml.NewErrorFrom(
+				fmt.Errorf("invalid watch: %w", err),
+				niceyaml.WithPath(paths.Root().Child("watch", "backend").Key()),
+			)
+		}
+	}
+
+	err = c.Validation.Validate()
+	if err != nil {
+		return niceyaml.NewErrorFrom(
+			fmt.Errorf("invalid validation: %w", err),
+			niceyaml.WithPath(paths.Root().Child("validation", "schemaDirs").Key()),
+		)
+	}
+
 	return nil
 }
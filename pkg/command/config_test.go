@@ -6,9 +6,11 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/macropower/kat/pkg/check"
 	"github.com/macropower/kat/pkg/command"
 	"github.com/macropower/kat/pkg/profile"
 	"github.com/macropower/kat/pkg/rule"
+	"github.com/macropower/kat/pkg/validate"
 )
 
 // TestConfigError_Error tests that ConfigError properly formats error messages.
@@ -118,6 +120,8 @@ func TestConfig_EnsureDefaults(t *testing.T) {
 func TestConfig_ValidationErrors(t *testing.T) {
 	t.Parallel()
 
+	enabled := true
+
 	tests := map[string]struct {
 		config      *command.Config
 		errorPath   string
@@ -162,6 +166,15 @@ func TestConfig_ValidationErrors(t *testing.T) {
 			expectError: true,
 			errorPath:   "rules[0].profile",
 		},
+		"validation without schema dirs": {
+			config: &command.Config{
+				Profiles:   map[string]*profile.Profile{},
+				Rules:      []*rule.Rule{},
+				Validation: &validate.Config{Enabled: &enabled},
+			},
+			expectError: true,
+			errorPath:   "validation.schemaDirs",
+		},
 		"valid config": {
 			config: &command.Config{
 				Profiles: map[string]*profile.Profile{
@@ -361,6 +374,56 @@ func TestConfig_Merge(t *testing.T) {
 				assert.Len(t, c.Rules, 1)
 			},
 		},
+		"project checks override global checks with the same name": {
+			global: &command.Config{
+				Checks: []*check.Check{
+					check.MustNew("shared", `true`),
+					check.MustNew("global", `true`),
+				},
+			},
+			project: &command.Config{
+				Checks: []*check.Check{
+					check.MustNew("shared", `false`),
+					check.MustNew("project", `true`),
+				},
+			},
+			checkFn: func(t *testing.T, c *command.Config) {
+				t.Helper()
+				require.Len(t, c.Checks, 3)
+				assert.Equal(t, "shared", c.Checks[0].Name)
+				assert.Equal(t, "false", c.Checks[0].Expr)
+				assert.Equal(t, "global", c.Checks[1].Name)
+				assert.Equal(t, "project", c.Checks[2].Name)
+			},
+		},
+		"project watch settings replace global": {
+			global: &command.Config{
+				Watch: &command.WatcherConfig{Backend: command.WatcherBackendFSNotify},
+			},
+			project: &command.Config{
+				Watch: &command.WatcherConfig{Backend: command.WatcherBackendPoll},
+			},
+			checkFn: func(t *testing.T, c *command.Config) {
+				t.Helper()
+				require.NotNil(t, c.Watch)
+				assert.Equal(t, command.WatcherBackendPoll, c.Watch.Backend)
+			},
+		},
+		"project ignore patterns are appended": {
+			global: &command.Config{
+				Ignore:      []string{".git/"},
+				IgnoreFiles: []string{".katignore"},
+			},
+			project: &command.Config{
+				Ignore:      []string{"vendor/"},
+				IgnoreFiles: []string{".katignore", ".gitignore"},
+			},
+			checkFn: func(t *testing.T, c *command.Config) {
+				t.Helper()
+				assert.Equal(t, []string{".git/", "vendor/"}, c.Ignore)
+				assert.Equal(t, []string{".katignore", ".gitignore"}, c.IgnoreFiles)
+			},
+		},
 		"global nil profiles": {
 			global: &command.Config{
 				Profiles: nil,
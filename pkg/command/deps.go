@@ -0,0 +1,297 @@
+package command
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/goccy/go-yaml"
+)
+
+// ErrDependencyOutsideRoot is returned by [FindDependencies] when a reference
+// cannot be followed because it is outside of the root.
+var ErrDependencyOutsideRoot = errors.New("dependency outside of root")
+
+var (
+	// kustomizationFiles contains the names of Kustomize's kustomization
+	// files, in the order Kustomize looks for them.
+	kustomizationFiles = []string{"kustomization.yaml", "kustomization.yml", "Kustomization"}
+
+	// chartFiles contains the names of Helm's chart metadata files.
+	chartFiles = []string{"Chart.yaml", "Chart.yml"}
+)
+
+// kustomization contains the fields of a Kustomize kustomization file that
+// reference local files or directories.
+type kustomization struct {
+	Resources             []string             `yaml:"resources"`
+	Bases                 []string             `yaml:"bases"`
+	Components            []string             `yaml:"components"`
+	Crds                  []string             `yaml:"crds"`
+	Generators            []string             `yaml:"generators"`
+	Transformers          []string             `yaml:"transformers"`
+	Validators            []string             `yaml:"validators"`
+	PatchesStrategicMerge []string             `yaml:"patchesStrategicMerge"`
+	Patches               []kustomizePath      `yaml:"patches"`
+	PatchesJSON6902       []kustomizePath      `yaml:"patchesJson6902"`
+	Replacements          []kustomizePath      `yaml:"replacements"`
+	ConfigMapGenerator    []kustomizeGenerator `yaml:"configMapGenerator"`
+	SecretGenerator       []kustomizeGenerator `yaml:"secretGenerator"`
+}
+
+// kustomizePath is an entry of a kustomization that may reference a file.
+type kustomizePath struct {
+	Path string `yaml:"path"`
+}
+
+// kustomizeGenerator is a ConfigMap or Secret generator of a kustomization.
+type kustomizeGenerator struct {
+	Env   string   `yaml:"env"`
+	Files []string `yaml:"files"`
+	Envs  []string `yaml:"envs"`
+}
+
+// refs returns all references of the kustomization, relative to its
+// directory.
+func (k *kustomization) refs() []string {
+	var refs []string
+
+	refs = append(refs, k.Resources...)
+	refs = append(refs, k.Bases...)
+	refs = append(refs, k.Components...)
+	refs = append(refs, k.Crds...)
+	refs = append(refs, k.Generators...)
+	refs = append(refs, k.Transformers...)
+	refs = append(refs, k.Validators...)
+
+	for _, p := range k.PatchesStrategicMerge {
+		// Strategic merge patches can also be inline YAML.
+		if !strings.Contains(p, "\n") {
+			refs = append(refs, p)
+		}
+	}
+
+	for _, paths := range [][]kustomizePath{k.Patches, k.PatchesJSON6902, k.Replacements} {
+		for _, p := range paths {
+			if p.Path != "" {
+				refs = append(refs, p.Path)
+			}
+		}
+	}
+
+	for _, gen := range append(k.ConfigMapGenerator, k.SecretGenerator...) {
+		for _, f := range gen.Files {
+			// Files can be given a key, e.g. `key=path`.
+			_, p, ok := strings.Cut(f, "=")
+			if ok {
+				f = p
+			}
+
+			refs = append(refs, f)
+		}
+
+		refs = append(refs, gen.Envs...)
+		if gen.Env != "" {
+			refs = append(refs, gen.Env)
+		}
+	}
+
+	return refs
+}
+
+// chart contains the fields of a Helm Chart.yaml that reference local charts.
+type chart struct {
+	Dependencies []struct {
+		Repository string `yaml:"repository"`
+	} `yaml:"dependencies"`
+}
+
+// FindDependencies returns the files that the Kustomize or Helm project in dir
+// depends on, following kustomization references and file:// chart
+// dependencies. The returned paths are relative to the root of fsys.
+//
+// Dependencies are often outside of dir, e.g. a Kustomize overlay that
+// references `../../base`, so they are not found by walking dir. References
+// that are remote or that do not exist are ignored. References that are
+// outside of fsys cannot be followed, so the dependencies that were found are
+// returned along with an error wrapping [ErrDependencyOutsideRoot]. To find
+// them, fsys must be a common ancestor of the project and its dependencies,
+// e.g. the top of the repository.
+func FindDependencies(fsys fs.FS, dir string) ([]string, error) {
+	df := &dependencyFinder{
+		fsys:    fsys,
+		visited: map[string]struct{}{},
+		files:   map[string]struct{}{},
+	}
+
+	err := df.findDir(path.Clean(dir))
+	if err != nil {
+		return nil, err
+	}
+
+	files := slices.Sorted(maps.Keys(df.files))
+	if len(df.outside) > 0 {
+		return files, fmt.Errorf("%w: %s", ErrDependencyOutsideRoot, strings.Join(df.outside, ", "))
+	}
+
+	return files, nil
+}
+
+type dependencyFinder struct {
+	fsys    fs.FS
+	visited map[string]struct{}
+	files   map[string]struct{}
+	outside []string
+}
+
+// findDir adds the dependencies of the Kustomize or Helm project in dir.
+func (df *dependencyFinder) findDir(dir string) error {
+	if _, ok := df.visited[dir]; ok {
+		return nil
+	}
+
+	df.visited[dir] = struct{}{}
+
+	err := df.findKustomization(dir)
+	if err != nil {
+		return err
+	}
+
+	return df.findChart(dir)
+}
+
+// findKustomization adds the kustomization file in dir, and every file that it
+// references.
+func (df *dependencyFinder) findKustomization(dir string) error {
+	name, b, err := df.readFirst(dir, kustomizationFiles)
+	if err != nil || b == nil {
+		return err
+	}
+
+	df.files[name] = struct{}{}
+
+	var k kustomization
+
+	err = yaml.Unmarshal(b, &k)
+	if err != nil {
+		return fmt.Errorf("parse %s: %w", name, err)
+	}
+
+	for _, ref := range k.refs() {
+		err := df.addRef(dir, ref)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// findChart adds every file of the file:// dependencies of the chart in dir.
+func (df *dependencyFinder) findChart(dir string) error {
+	name, b, err := df.readFirst(dir, chartFiles)
+	if err != nil || b == nil {
+		return err
+	}
+
+	var c chart
+
+	err = yaml.Unmarshal(b, &c)
+	if err != nil {
+		return fmt.Errorf("parse %s: %w", name, err)
+	}
+
+	for _, dep := range c.Dependencies {
+		ref, ok := strings.CutPrefix(dep.Repository, "file://")
+		if !ok {
+			continue
+		}
+
+		err := df.addRef(dir, ref)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// addRef adds the file or directory referenced from dir. Directories are
+// added recursively, and their own dependencies are followed.
+func (df *dependencyFinder) addRef(dir, ref string) error {
+	if ref == "" || strings.Contains(ref, "://") {
+		return nil
+	}
+
+	if path.IsAbs(ref) {
+		df.outside = append(df.outside, strconv.Quote(ref))
+
+		return nil
+	}
+
+	p := path.Join(dir, ref)
+	if !fs.ValidPath(p) {
+		df.outside = append(df.outside, strconv.Quote(p))
+
+		return nil
+	}
+
+	info, err := fs.Stat(df.fsys, p)
+	if err != nil {
+		// The reference is remote, e.g. `github.com/org/repo`, or missing.
+		return nil //nolint:nilerr // Ignore references that cannot be watched.
+	}
+
+	if !info.IsDir() {
+		df.files[p] = struct{}{}
+
+		return nil
+	}
+
+	if _, ok := df.visited[p]; ok {
+		return nil
+	}
+
+	err = fs.WalkDir(df.fsys, p, func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if !d.IsDir() {
+			df.files[name] = struct{}{}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("walk %q: %w", p, err)
+	}
+
+	return df.findDir(p)
+}
+
+// readFirst reads the first of the named files that exists in dir. It returns
+// nil if none of the files exist.
+func (df *dependencyFinder) readFirst(dir string, names []string) (string, []byte, error) {
+	for _, name := range names {
+		p := path.Join(dir, name)
+
+		b, err := fs.ReadFile(df.fsys, p)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+
+		if err != nil {
+			return "", nil, fmt.Errorf("read %s: %w", p, err)
+		}
+
+		return p, b, nil
+	}
+
+	return "", nil, nil
+}
@@ -0,0 +1,140 @@
+package command_test
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/macropower/kat/pkg/command"
+)
+
+func TestFindDependencies(t *testing.T) {
+	t.Parallel()
+
+	file := func(s string) *fstest.MapFile {
+		return &fstest.MapFile{Data: []byte(s)}
+	}
+
+	tcs := map[string]struct {
+		fsys        fstest.MapFS
+		dir         string
+		want        []string
+		wantErr     bool
+		wantOutside bool
+	}{
+		"no project": {
+			fsys: fstest.MapFS{
+				"app/deploy.yaml": file("kind: Deployment"),
+			},
+			dir:  "app",
+			want: nil,
+		},
+		"kustomize overlay with base": {
+			fsys: fstest.MapFS{
+				"overlays/prod/kustomization.yaml": file("resources:\n- ../../base\npatches:\n- path: patch.yaml\n"),
+				"overlays/prod/patch.yaml":         file("kind: Deployment"),
+				"base/kustomization.yaml":          file("resources:\n- deploy.yaml\n"),
+				"base/deploy.yaml":                 file("kind: Deployment"),
+				"unrelated/deploy.yaml":            file("kind: Deployment"),
+			},
+			dir: "overlays/prod",
+			want: []string{
+				"base/deploy.yaml",
+				"base/kustomization.yaml",
+				"overlays/prod/kustomization.yaml",
+				"overlays/prod/patch.yaml",
+			},
+		},
+		"kustomize components and generators": {
+			fsys: fstest.MapFS{
+				"app/kustomization.yml": file(
+					"components:\n- ../components/x\n" +
+						"configMapGenerator:\n- name: cfg\n  files:\n  - key=../config/app.conf\n  envs:\n  - ../config/app.env\n" +
+						"patchesStrategicMerge:\n- |\n  kind: Deployment\n",
+				),
+				"components/x/kustomization.yaml": file("kind: Component\n"),
+				"config/app.conf":                 file("a=b"),
+				"config/app.env":                  file("A=B"),
+			},
+			dir: "app",
+			want: []string{
+				"app/kustomization.yml",
+				"components/x/kustomization.yaml",
+				"config/app.conf",
+				"config/app.env",
+			},
+		},
+		"kustomize remote and missing references": {
+			fsys: fstest.MapFS{
+				"app/kustomization.yaml": file(
+					"resources:\n- https://example.com/app.yaml\n- github.com/org/repo//base?ref=v1\n- missing.yaml\n",
+				),
+			},
+			dir:  "app",
+			want: []string{"app/kustomization.yaml"},
+		},
+		"references outside of the root": {
+			fsys: fstest.MapFS{
+				"kustomization.yaml": file("resources:\n- ../base\n- /etc/base\n"),
+			},
+			dir:         ".",
+			want:        []string{"kustomization.yaml"},
+			wantOutside: true,
+		},
+		"kustomize cycle": {
+			fsys: fstest.MapFS{
+				"a/kustomization.yaml": file("resources:\n- ../b\n"),
+				"b/kustomization.yaml": file("resources:\n- ../a\n"),
+			},
+			dir:  "a",
+			want: []string{"a/kustomization.yaml", "b/kustomization.yaml"},
+		},
+		"helm file dependencies": {
+			fsys: fstest.MapFS{
+				"charts/app/Chart.yaml": file(
+					"dependencies:\n- name: common\n  repository: file://../common\n" +
+						"- name: redis\n  repository: https://charts.example.com\n",
+				),
+				"charts/app/values.yaml":               file("a: b"),
+				"charts/common/Chart.yaml":             file("name: common\n"),
+				"charts/common/templates/_helpers.tpl": file(""),
+			},
+			dir: "charts/app",
+			want: []string{
+				"charts/common/Chart.yaml",
+				"charts/common/templates/_helpers.tpl",
+			},
+		},
+		"invalid kustomization": {
+			fsys: fstest.MapFS{
+				"app/kustomization.yaml": file("resources: {"),
+			},
+			dir:     "app",
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := command.FindDependencies(tc.fsys, tc.dir)
+			if tc.wantErr {
+				require.Error(t, err)
+
+				return
+			}
+
+			if tc.wantOutside {
+				require.ErrorIs(t, err, command.ErrDependencyOutsideRoot)
+				require.ErrorContains(t, err, `"../base", "/etc/base"`)
+			} else {
+				require.NoError(t, err)
+			}
+
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
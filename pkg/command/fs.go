@@ -6,6 +6,7 @@ import (
 	"os"
 	"path/filepath"
 
+	"github.com/macropower/kat/pkg/ignore"
 	"github.com/macropower/kat/pkg/rule"
 )
 
@@ -26,10 +27,15 @@ type RootFS interface {
 // hides files and directories that do not match any rules. Note that it does
 // not directly prevent access to any files in the tree. (However, [os.Root]
 // will prevent leaving the initially provided directory tree.)
+//
+// Files and directories that match the ignore patterns, or the patterns of
+// the ignore files, are also hidden.
 type FilteredFS struct {
-	root     RootFS
-	rules    []*rule.Rule
-	maxDepth uint // Maximum depth to traverse directories. 0 means no limit.
+	root        RootFS
+	rules       []*rule.Rule
+	ignore      []string
+	ignoreFiles []string
+	maxDepth    uint // Maximum depth to traverse directories. 0 means no limit.
 }
 
 // NewFilteredFS creates a new FilteredFS with the given directory path and rules.
@@ -79,8 +85,13 @@ func (f *FilteredFS) Stat(name string) (fs.FileInfo, error) {
 }
 
 // ReadDir reads the named directory, returning any entries that match at least
-// one [rule.Rule] (recursively), sorted by filename.
+// one [rule.Rule] (recursively) and are not ignored, sorted by filename.
 func (f *FilteredFS) ReadDir(name string) ([]os.DirEntry, error) {
+	m, err := newIgnoreMatcher(f.root.FS(), name, f.ignoreFiles, f.ignore...)
+	if err != nil {
+		return nil, err
+	}
+
 	file, err := f.Open(name)
 	if err != nil {
 		return nil, err
@@ -94,14 +105,19 @@ func (f *FilteredFS) ReadDir(name string) ([]os.DirEntry, error) {
 	}
 
 	// Check which entries are allowed based on rules.
-	allowed := f.filterEntries(name, entries, 0)
+	allowed := f.filterEntries(name, entries, m, 0)
 
 	return allowed, nil
 }
 
 // filterEntries filters directory entries based on rules, returning only those that match.
 // It recursively checks subdirectories up to maxDepth.
-func (f *FilteredFS) filterEntries(dirPath string, entries []os.DirEntry, depth uint) []os.DirEntry {
+func (f *FilteredFS) filterEntries(
+	dirPath string,
+	entries []os.DirEntry,
+	m *ignore.Matcher,
+	depth uint,
+) []os.DirEntry {
 	var (
 		files  []os.DirEntry
 		dirs   []os.DirEntry
@@ -109,6 +125,10 @@ func (f *FilteredFS) filterEntries(dirPath string, entries []os.DirEntry, depth
 	)
 
 	for _, entry := range entries {
+		if m.Match(filepath.ToSlash(filepath.Join(dirPath, entry.Name())), entry.IsDir()) {
+			continue
+		}
+
 		if entry.IsDir() {
 			dirs = append(dirs, entry)
 		} else {
@@ -128,7 +148,7 @@ func (f *FilteredFS) filterEntries(dirPath string, entries []os.DirEntry, depth
 
 	for _, dir := range dirs {
 		subPath := filepath.Join(dirPath, dir.Name())
-		if f.hasAllowedContent(subPath, depth+1) {
+		if f.hasAllowedContent(subPath, m, depth+1) {
 			result = append(result, dir)
 		}
 	}
@@ -137,12 +157,18 @@ func (f *FilteredFS) filterEntries(dirPath string, entries []os.DirEntry, depth
 }
 
 // hasAllowedContent checks if a directory contains any files that match rules,
-// either directly or in subdirectories (up to maxDepth).
-func (f *FilteredFS) hasAllowedContent(dirPath string, depth uint) bool {
+// either directly or in subdirectories (up to maxDepth). Ignored files and
+// directories are skipped.
+func (f *FilteredFS) hasAllowedContent(dirPath string, m *ignore.Matcher, depth uint) bool {
 	if f.maxDepth > 0 && depth > f.maxDepth {
 		return false
 	}
 
+	err := addIgnoreFiles(m, f.root.FS(), filepath.ToSlash(dirPath), f.ignoreFiles)
+	if err != nil {
+		return false
+	}
+
 	file, err := f.Open(dirPath)
 	if err != nil {
 		return false
@@ -156,13 +182,18 @@ func (f *FilteredFS) hasAllowedContent(dirPath string, depth uint) bool {
 
 	files := []string{}
 	for _, entry := range entries {
-		if entry.IsDir() && f.hasAllowedContent(filepath.Join(dirPath, entry.Name()), depth+1) {
+		entryPath := filepath.Join(dirPath, entry.Name())
+		if m.Match(filepath.ToSlash(entryPath), entry.IsDir()) {
+			continue
+		}
+
+		if entry.IsDir() && f.hasAllowedContent(entryPath, m, depth+1) {
 			// If the subdirectory matches, this directory is also implicitly allowed.
 			// So, we can exit early.
 			return true
 		}
 
-		files = append(files, filepath.Join(dirPath, entry.Name()))
+		files = append(files, entryPath)
 	}
 
 	// Check if this directory matches any rules.
@@ -443,6 +443,50 @@ func TestFilteredFS_ReadDir(t *testing.T) {
 	}
 }
 
+func TestFilteredFS_ReadDir_Ignore(t *testing.T) {
+	t.Parallel()
+
+	root, tempDir := testRoot(t)
+
+	files := map[string]string{
+		"app.yaml":            "kind: Deployment",
+		"app.yaml.swp":        "",
+		"generated.yaml":      "kind: Deployment",
+		".katignore":          "generated.yaml\n",
+		"vendor/dep.yaml":     "kind: Deployment",
+		"config/app.yaml":     "kind: ConfigMap",
+		"config/.katignore":   "*.yaml\n",
+		"charts/c/Chart.yaml": "name: c",
+	}
+	for name, content := range files {
+		require.NoError(t, os.MkdirAll(filepath.Join(tempDir, filepath.Dir(name)), 0o755))
+		require.NoError(t, os.WriteFile(filepath.Join(tempDir, name), []byte(content), 0o644))
+	}
+
+	runner, err := command.NewRunnerWithRoot(root, ".",
+		command.WithRules([]*rule.Rule{
+			rule.MustNew("yaml", `files.exists(f, pathExt(f) in [".yaml", ".yml"])`),
+		}),
+		command.WithProfiles(testProfiles),
+		command.WithIgnore("vendor/", "*.swp"),
+		command.WithIgnoreFiles(".katignore"),
+	)
+	require.NoError(t, err)
+
+	fsys, err := runner.FS()
+	require.NoError(t, err)
+
+	entries, err := fsys.ReadDir(".")
+	require.NoError(t, err)
+
+	names := []string{}
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+
+	assert.ElementsMatch(t, []string{"app.yaml", "charts"}, names)
+}
+
 func TestFilteredFS_ReadDir_NonExistentDirectory(t *testing.T) {
 	t.Parallel()
 
@@ -0,0 +1,119 @@
+package command
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/macropower/kat/pkg/ignore"
+)
+
+var (
+	// defaultIgnore contains the default ignore patterns. They skip version
+	// control and dependency directories, which are slow to traverse, and
+	// editor swap files, which cause spurious reloads.
+	defaultIgnore = []string{".git/", "node_modules/", "*.swp", "*.swx", "*~", "4913"}
+
+	// defaultIgnoreFiles contains the names of the ignore files that are
+	// honored by default.
+	defaultIgnoreFiles = []string{".katignore"}
+)
+
+// newIgnoreMatcher returns an [ignore.Matcher] for the patterns, plus the
+// patterns of the named ignore files in dir and each of its parents, up to
+// the root of fsys. If dir is a file, its directory is used.
+func newIgnoreMatcher(fsys fs.FS, dir string, files []string, patterns ...string) (*ignore.Matcher, error) {
+	m, err := ignore.New(patterns...)
+	if err != nil {
+		return nil, fmt.Errorf("ignore: %w", err)
+	}
+
+	if len(files) == 0 {
+		return m, nil
+	}
+
+	dir = path.Clean(filepath.ToSlash(dir))
+
+	info, err := fs.Stat(fsys, dir)
+	if err == nil && !info.IsDir() {
+		dir = path.Dir(dir)
+	}
+
+	err = addIgnoreFiles(m, fsys, ".", files)
+	if err != nil {
+		return nil, err
+	}
+
+	if dir == "." {
+		return m, nil
+	}
+
+	parts := strings.Split(dir, "/")
+	for i := range parts {
+		err := addIgnoreFiles(m, fsys, path.Join(parts[:i+1]...), files)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return m, nil
+}
+
+// addIgnoreFiles adds the patterns of the named ignore files in dir.
+func addIgnoreFiles(m *ignore.Matcher, fsys fs.FS, dir string, files []string) error {
+	for _, name := range files {
+		err := m.AddFile(fsys, path.Join(dir, name))
+		if err != nil {
+			return fmt.Errorf("ignore: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// walkFiles returns the paths of all files under dir that are not ignored by
+// m. Ignored directories are not traversed, and the named ignore files are
+// honored in each directory that is traversed. If recursive is false, only
+// the files directly within dir are returned.
+func walkFiles(fsys fs.FS, dir string, m *ignore.Matcher, files []string, recursive bool) ([]string, error) {
+	var paths []string
+
+	err := fs.WalkDir(fsys, dir, func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		// The directory itself is never ignored, and its ignore files were
+		// already added to the matcher.
+		if name == dir {
+			if !d.IsDir() {
+				paths = append(paths, name)
+			}
+
+			return nil
+		}
+
+		slashName := filepath.ToSlash(name)
+
+		if d.IsDir() {
+			if !recursive || m.Match(slashName, true) {
+				return fs.SkipDir
+			}
+
+			return addIgnoreFiles(m, fsys, slashName, files)
+		}
+
+		if !m.Match(slashName, false) {
+			paths = append(paths, name)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("walk %q: %w", dir, err)
+	}
+
+	return paths, nil
+}
@@ -0,0 +1,199 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/macropower/kat/pkg/kube"
+	"github.com/macropower/kat/pkg/profile"
+)
+
+// ErrNoVariants is returned when a matrix is rendered for a profile without
+// variants.
+var ErrNoVariants = errors.New("profile has no variants")
+
+// MatrixOutput contains the results of rendering several variants of a
+// profile.
+type MatrixOutput struct {
+	// Matrix compares the resources rendered by each variant.
+	Matrix *kube.Matrix
+	// Outputs contains the output of each variant, in the order of
+	// [kube.Matrix.Names].
+	Outputs []Output
+}
+
+// Err returns the errors of all failed variants, or nil.
+func (mo MatrixOutput) Err() error {
+	var errs []error
+
+	for i, out := range mo.Outputs {
+		if out.Error != nil {
+			errs = append(errs, fmt.Errorf("variant %q: %w", mo.Matrix.Names[i], out.Error))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// RunMatrix renders variants of the current profile. See [Runner.RunMatrixContext].
+func (cr *Runner) RunMatrix(names ...string) (MatrixOutput, error) {
+	return cr.RunMatrixContext(context.Background(), names...)
+}
+
+// RunMatrixContext renders the named variants of the current profile
+// concurrently, and compares the resulting resources. If no names are given,
+// every variant is rendered. Unlike [Runner.RunContext], no events are
+// broadcast, and a running command is not canceled.
+func (cr *Runner) RunMatrixContext(ctx context.Context, names ...string) (MatrixOutput, error) {
+	cr.mu.Lock()
+
+	var (
+		path        = cr.path
+		p           = cr.currentProfile
+		validator   = cr.validator
+		cache       = cr.cache
+		checks      = cr.checks
+		profileName = cr.currentProfileName
+	)
+
+	cr.mu.Unlock()
+
+	ctx, span := cr.tracer.Start(ctx, "run matrix", trace.WithAttributes(
+		attribute.String("command", p.Command.Command),
+		attribute.String("path", path),
+	))
+	defer span.End()
+
+	variants, err := cr.findVariants(path, p)
+	if err != nil {
+		return MatrixOutput{}, err
+	}
+
+	if len(variants) == 0 {
+		return MatrixOutput{}, fmt.Errorf("%w: %s", ErrNoVariants, profileName)
+	}
+
+	if len(names) == 0 {
+		names = slices.Sorted(maps.Keys(variants))
+	}
+
+	span.SetAttributes(attribute.StringSlice("variants", names))
+
+	profiles := make([]*profile.Profile, 0, len(names))
+
+	for _, name := range names {
+		v, ok := variants[name]
+		if !ok {
+			return MatrixOutput{}, fmt.Errorf("unknown variant: %s", name)
+		}
+
+		// Each variant has its own status, so that the renders do not update
+		// the status of the current profile.
+		vp, err := p.ApplyVariant(name, v, profile.WithStatusManager(&profile.Status{}))
+		if err != nil {
+			return MatrixOutput{}, fmt.Errorf("variant %q: %w", name, err)
+		}
+
+		profiles = append(profiles, vp)
+	}
+
+	dir := cr.execDir(path)
+	outputs := make([]Output, len(profiles))
+	keys := make([]string, len(profiles))
+	cached := make([]bool, len(profiles))
+
+	for i, vp := range profiles {
+		outputs[i] = NewOutput(TypeRun)
+		keys[i] = cr.cacheKeyFor(ctx, cache, path, vp)
+
+		entry, ok := cr.cacheGet(ctx, cache, keys[i])
+		if ok {
+			outputs[i].Stdout = entry.Stdout
+			outputs[i].Stderr = entry.Stderr
+			cached[i] = true
+		}
+	}
+
+	// PreRender hooks, e.g. "helm dependency build", may write to the project
+	// directory, so they must not run concurrently. They run once for each
+	// directory that is rendered, before the variants are rendered without them.
+	preRenders := make(map[string]Output)
+
+	for i, vp := range profiles {
+		vdir := vp.VariantDir(dir)
+		if _, ok := preRenders[vdir]; ok || cached[i] {
+			continue
+		}
+
+		co := NewOutput(TypeRun)
+
+		result, err := vp.ExecPreRender(ctx, dir)
+		co.Error = err
+		if result != nil {
+			co.Stdout = result.Stdout
+			co.Stderr = result.Stderr
+		}
+
+		preRenders[vdir] = co
+	}
+
+	var wg sync.WaitGroup
+
+	for i, vp := range profiles {
+		wg.Go(func() {
+			co := &outputs[i]
+
+			if !cached[i] {
+				renderVariant(ctx, vp, dir, preRenders[vp.VariantDir(dir)], co)
+
+				if co.Error == nil {
+					cr.cachePut(ctx, cache, keys[i], cacheEntry{Stdout: co.Stdout, Stderr: co.Stderr})
+				}
+			}
+
+			if co.Error != nil {
+				co.Error = fmt.Errorf("%s: %w", vp.Command.Command, co.Error)
+			} else {
+				loadResources(co, validator, checks)
+			}
+		})
+	}
+
+	wg.Wait()
+
+	renders := make([][]*kube.Resource, 0, len(outputs))
+	for _, out := range outputs {
+		renders = append(renders, out.Resources)
+	}
+
+	return MatrixOutput{
+		Matrix:  kube.NewMatrix(names, renders),
+		Outputs: outputs,
+	}, nil
+}
+
+// renderVariant renders the variant's profile in dir, without its preRender
+// hooks, unless the preRender hooks failed.
+func renderVariant(ctx context.Context, vp *profile.Profile, dir string, preRender Output, co *Output) {
+	if preRender.Error != nil {
+		co.Error = preRender.Error
+		co.Stdout = preRender.Stdout
+		co.Stderr = preRender.Stderr
+
+		return
+	}
+
+	result, err := vp.ExecRender(ctx, dir)
+	co.Error = err
+	if result != nil {
+		co.Stdout = result.Stdout
+		co.Stderr = result.Stderr
+	}
+}
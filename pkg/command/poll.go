@@ -0,0 +1,252 @@
+package command
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// pollHashMaxSize is the maximum size of files whose content is hashed by
+// the polling [Watcher]. Larger files are only compared by size and
+// modification time.
+const pollHashMaxSize = 1 << 20
+
+// pollFileState describes a file at the time it was scanned.
+type pollFileState struct {
+	modTime time.Time
+	size    int64
+	hash    [sha256.Size]byte
+}
+
+// pollWatcher is a [Watcher] that scans watched paths at a fixed interval,
+// for filesystems that do not deliver OS-level notifications. Like
+// [fsnotify.Watcher], watching a directory reports changes to the files
+// directly within it.
+//
+// Files are compared by size, modification time and (for small files)
+// content, so that changes are detected even if the modification time is
+// not updated, or has a low resolution.
+type pollWatcher struct {
+	// Watched path -> file path -> state.
+	paths    map[string]map[string]pollFileState
+	events   chan fsnotify.Event
+	errors   chan error
+	done     chan struct{}
+	wg       sync.WaitGroup
+	interval time.Duration
+	mu       sync.Mutex
+	closed   bool
+}
+
+func newPollWatcher(interval time.Duration) *pollWatcher {
+	w := &pollWatcher{
+		paths:    map[string]map[string]pollFileState{},
+		events:   make(chan fsnotify.Event),
+		errors:   make(chan error),
+		done:     make(chan struct{}),
+		interval: interval,
+	}
+
+	w.wg.Go(w.run)
+
+	return w
+}
+
+func (w *pollWatcher) Add(path string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return fsnotify.ErrClosed
+	}
+
+	if _, ok := w.paths[path]; ok {
+		return nil
+	}
+
+	files, err := scanPath(path)
+	if err != nil {
+		return fmt.Errorf("add watch: %w", err)
+	}
+
+	w.paths[path] = files
+
+	return nil
+}
+
+func (w *pollWatcher) Remove(path string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if _, ok := w.paths[path]; !ok {
+		return fmt.Errorf("remove watch: %w: %s", fsnotify.ErrNonExistentWatch, path)
+	}
+
+	delete(w.paths, path)
+
+	return nil
+}
+
+func (w *pollWatcher) Close() error {
+	w.mu.Lock()
+
+	if w.closed {
+		w.mu.Unlock()
+
+		return nil
+	}
+
+	w.closed = true
+	close(w.done)
+	w.mu.Unlock()
+
+	w.wg.Wait()
+	close(w.events)
+	close(w.errors)
+
+	return nil
+}
+
+func (w *pollWatcher) Events() <-chan fsnotify.Event { return w.events }
+func (w *pollWatcher) Errors() <-chan error          { return w.errors }
+
+func (w *pollWatcher) run() {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			for _, evt := range w.poll() {
+				select {
+				case w.events <- evt:
+				case <-w.done:
+					return
+				}
+			}
+
+		case <-w.done:
+			return
+		}
+	}
+}
+
+// poll scans all watched paths, and returns an event for each file that was
+// created, written or removed since the previous scan.
+func (w *pollWatcher) poll() []fsnotify.Event {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	var events []fsnotify.Event
+
+	for path, prev := range w.paths {
+		files, err := scanPath(path)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			select {
+			case w.errors <- fmt.Errorf("poll %s: %w", path, err):
+			default:
+			}
+
+			continue
+		}
+
+		for name, state := range files {
+			prevState, ok := prev[name]
+
+			switch {
+			case !ok:
+				events = append(events, fsnotify.Event{Name: name, Op: fsnotify.Create})
+			case state != prevState:
+				events = append(events, fsnotify.Event{Name: name, Op: fsnotify.Write})
+			}
+		}
+
+		for name := range prev {
+			if _, ok := files[name]; !ok {
+				events = append(events, fsnotify.Event{Name: name, Op: fsnotify.Remove})
+			}
+		}
+
+		w.paths[path] = files
+	}
+
+	return events
+}
+
+// scanPath returns the state of the file at path, or of each file directly
+// within it if it is a directory.
+func scanPath(path string) (map[string]pollFileState, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err //nolint:wrapcheck // Wrapped by the caller.
+	}
+
+	files := map[string]pollFileState{}
+
+	if !info.IsDir() {
+		files[path] = scanFile(path, info)
+
+		return files, nil
+	}
+
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return nil, err //nolint:wrapcheck // Wrapped by the caller.
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+
+		name := filepath.Join(path, entry.Name())
+
+		info, err := entry.Info()
+		if err != nil {
+			// The file was removed while scanning.
+			continue
+		}
+
+		files[name] = scanFile(name, info)
+	}
+
+	return files, nil
+}
+
+// scanFile returns the state of the file. Files that are too large, or that
+// cannot be read, are not hashed.
+func scanFile(name string, info fs.FileInfo) pollFileState {
+	state := pollFileState{
+		modTime: info.ModTime(),
+		size:    info.Size(),
+	}
+
+	if !info.Mode().IsRegular() || info.Size() > pollHashMaxSize {
+		return state
+	}
+
+	f, err := os.Open(name)
+	if err != nil {
+		return state
+	}
+	defer f.Close() //nolint:errcheck // Ignore errors.
+
+	h := sha256.New()
+
+	_, err = io.Copy(h, f)
+	if err != nil {
+		return state
+	}
+
+	h.Sum(state.hash[:0])
+
+	return state
+}
@@ -4,10 +4,11 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"io/fs"
 	"log/slog"
+	"maps"
 	"os"
 	"path/filepath"
+	"slices"
 	"sync"
 	"time"
 
@@ -16,10 +17,14 @@ import (
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/trace"
 
+	"github.com/macropower/kat/pkg/check"
+	"github.com/macropower/kat/pkg/execs"
+	"github.com/macropower/kat/pkg/ignore"
 	"github.com/macropower/kat/pkg/kube"
 	"github.com/macropower/kat/pkg/log"
 	"github.com/macropower/kat/pkg/profile"
 	"github.com/macropower/kat/pkg/rule"
+	"github.com/macropower/kat/pkg/validate"
 )
 
 // ErrNoCommandForPath is returned when no command is found for a path.
@@ -30,9 +35,12 @@ var ErrNoCommandForPath = errors.New("no command for path")
 //   - Filesystem notifications / watching.
 //   - Concurrent command execution.
 type Runner struct {
-	tracer   trace.Tracer
-	profiles map[string]*profile.Profile
-	watcher  Watcher
+	tracer    trace.Tracer
+	profiles  map[string]*profile.Profile
+	watcher   Watcher
+	validator *validate.Validator
+	cache     *Cache
+	checks    []*check.Check
 
 	// The root filesystem to operate on. This prevents later re-configuration
 	// from escaping the originally configured root path.
@@ -49,12 +57,16 @@ type Runner struct {
 	watchedDirs map[string]struct{}
 
 	currentProfile     *profile.Profile
+	currentVariantName string
 	cancelFunc         context.CancelFunc
 	path               string
 	currentProfileName string
 	listeners          []chan<- Event
 	allRules           []*rule.Rule
 	extraArgs          []string
+	ignore             []string
+	ignoreFiles        []string
+	workspace          string
 	mu                 sync.Mutex
 
 	// Batch duration for file system events.
@@ -83,9 +95,9 @@ func NewRunner(path string, opts ...RunnerOpt) (*Runner, error) {
 // This is not a normal opt since it cannot be re-configured after the runner
 // has been created.
 func NewRunnerWithRoot(root RootFS, path string, opts ...RunnerOpt) (*Runner, error) {
-	watcher, err := newFSNotifyWatcher()
+	watcher, err := NewWatcher(nil)
 	if err != nil {
-		return nil, fmt.Errorf("create fsnotify watcher: %w", err)
+		return nil, fmt.Errorf("create watcher: %w", err)
 	}
 
 	cr := &Runner{
@@ -103,7 +115,9 @@ func NewRunnerWithRoot(root RootFS, path string, opts ...RunnerOpt) (*Runner, er
 		defaultCfg := NewConfig()
 		opts = append(opts,
 			WithRules(defaultCfg.Rules),
-			WithProfiles(defaultCfg.Profiles))
+			WithProfiles(defaultCfg.Profiles),
+			WithIgnore(defaultCfg.Ignore...),
+			WithIgnoreFiles(defaultCfg.IgnoreFiles...))
 	}
 
 	opts = append(opts, WithPath(path))
@@ -178,6 +192,11 @@ func (cr *Runner) ConfigureContext(ctx context.Context, opts ...RunnerOpt) error
 		}
 	}
 
+	err := cr.setVariant()
+	if err != nil {
+		return err
+	}
+
 	if cr.watch {
 		err := cr.watchSource(ctx)
 		if err != nil {
@@ -187,7 +206,7 @@ func (cr *Runner) ConfigureContext(ctx context.Context, opts ...RunnerOpt) error
 
 	if cr.currentProfile != nil && cr.currentProfile.Hooks != nil {
 		for _, hook := range cr.currentProfile.Hooks.Init {
-			hr, err := hook.Exec(ctx, cr.path)
+			hr, err := hook.Exec(ctx, cr.execDir(cr.path))
 			if err != nil && hr != nil {
 				return fmt.Errorf("%w: init: %w\n%s\n%s", profile.ErrHookExecution, err, hr.Stdout, hr.Stderr)
 			} else if err != nil {
@@ -196,7 +215,7 @@ func (cr *Runner) ConfigureContext(ctx context.Context, opts ...RunnerOpt) error
 		}
 	}
 
-	cr.broadcast(NewEventConfigure(ctx))
+	cr.broadcast(NewEventConfigure(ctx, cr.path))
 	logger.DebugContext(ctx, "configured runner",
 		slog.String("path", cr.path),
 		slog.String("profile", cr.currentProfile.String()),
@@ -244,6 +263,42 @@ func WithWatcherBatchDuration(dur time.Duration) RunnerOpt {
 	}
 }
 
+// WithWorkspace sets the directory that the runner's path was selected from,
+// e.g. with `kat workspace`. Any project found in the workspace can be
+// selected from the menu. See [FilteredFS.FindProjects].
+func WithWorkspace(dir string) RunnerOpt {
+	return func(cr *Runner) error {
+		cr.workspace = dir
+
+		return nil
+	}
+}
+
+// WithIgnore sets gitignore-style patterns for files and directories that are
+// not watched or matched by rules. Patterns are relative to the root.
+func WithIgnore(patterns ...string) RunnerOpt {
+	return func(cr *Runner) error {
+		_, err := ignore.New(patterns...)
+		if err != nil {
+			return fmt.Errorf("ignore: %w", err)
+		}
+
+		cr.ignore = patterns
+
+		return nil
+	}
+}
+
+// WithIgnoreFiles sets the names of ignore files, e.g. `.gitignore`, whose
+// patterns are applied to the directory that contains them.
+func WithIgnoreFiles(names ...string) RunnerOpt {
+	return func(cr *Runner) error {
+		cr.ignoreFiles = names
+
+		return nil
+	}
+}
+
 // WithWatcher replaces the runner's filesystem [Watcher]. The existing watcher
 // is closed before the replacement is installed.
 func WithWatcher(w Watcher) RunnerOpt {
@@ -261,6 +316,19 @@ func WithWatcher(w Watcher) RunnerOpt {
 	}
 }
 
+// WithWatcherConfig replaces the runner's filesystem [Watcher] with one
+// created from the given [WatcherConfig]. See [NewWatcher].
+func WithWatcherConfig(c *WatcherConfig) RunnerOpt {
+	return func(cr *Runner) error {
+		w, err := NewWatcher(c)
+		if err != nil {
+			return fmt.Errorf("create watcher: %w", err)
+		}
+
+		return WithWatcher(w)(cr)
+	}
+}
+
 // WithProfile sets a specific profile to use.
 func WithProfile(name string) RunnerOpt {
 	return func(cr *Runner) error {
@@ -301,6 +369,16 @@ func WithExtraArgs(args ...string) RunnerOpt {
 	}
 }
 
+// WithVariant sets the name of the profile variant to use. An empty name
+// uses the profile without a variant.
+func WithVariant(name string) RunnerOpt {
+	return func(cr *Runner) error {
+		cr.currentVariantName = name
+
+		return nil
+	}
+}
+
 // WithRules sets multiple rules from which the first matching rule will be used.
 func WithRules(rs []*rule.Rule) RunnerOpt {
 	return func(cr *Runner) error {
@@ -323,6 +401,38 @@ func WithProfiles(profiles map[string]*profile.Profile) RunnerOpt {
 	}
 }
 
+// WithValidator sets a [validate.Validator] that is used to validate
+// resources after each run. Problems are added to each resource's
+// diagnostics. Passing nil disables validation.
+func WithValidator(v *validate.Validator) RunnerOpt {
+	return func(cr *Runner) error {
+		cr.validator = v
+
+		return nil
+	}
+}
+
+// WithCache sets a [Cache] that is used to reuse the output of previous runs
+// when none of their inputs have changed. Passing nil disables caching.
+func WithCache(c *Cache) RunnerOpt {
+	return func(cr *Runner) error {
+		cr.cache = c
+
+		return nil
+	}
+}
+
+// WithChecks sets the [check.Check] policies that are evaluated against
+// resources after each run. Violations are added to each resource's
+// diagnostics.
+func WithChecks(checks []*check.Check) RunnerOpt {
+	return func(cr *Runner) error {
+		cr.checks = checks
+
+		return nil
+	}
+}
+
 type ProfileMatch struct {
 	Profile *profile.Profile
 	Name    string
@@ -425,6 +535,12 @@ func (cr *Runner) GetPath() string {
 	return cr.path
 }
 
+// GetWorkspace returns the workspace directory, or an empty string if the
+// runner was not started from a workspace. See [WithWorkspace].
+func (cr *Runner) GetWorkspace() string {
+	return cr.workspace
+}
+
 func (cr *Runner) SetProfile(name string) error {
 	cr.mu.Lock()
 	defer cr.mu.Unlock()
@@ -441,19 +557,22 @@ func (cr *Runner) SetProfile(name string) error {
 }
 
 // FS creates a [FilteredFS] for the runner that hides directories and files
-// unless they match at least one of the configured rules.
+// unless they match at least one of the configured rules, or are ignored.
 func (cr *Runner) FS() (*FilteredFS, error) {
 	fsys, err := NewFilteredFS(cr.root, cr.allRules...)
 	if err != nil {
 		return nil, err
 	}
 
+	fsys.ignore = cr.ignore
+	fsys.ignoreFiles = cr.ignoreFiles
+
 	return fsys, nil
 }
 
 // RunPlugin executes a plugin by name.
-func (cr *Runner) RunPlugin(name string) Output {
-	return cr.RunPluginContext(context.Background(), name)
+func (cr *Runner) RunPlugin(name string, opts ...PluginRunOpt) Output {
+	return cr.RunPluginContext(context.Background(), name, opts...)
 }
 
 func (cr *Runner) setExtraArgs() error {
@@ -473,18 +592,97 @@ func (cr *Runner) setExtraArgs() error {
 	return nil
 }
 
+// setVariant applies the current variant to the current profile, or removes
+// a previously applied variant if no variant is set.
+func (cr *Runner) setVariant() error {
+	var variant *profile.Variant
+
+	if cr.currentVariantName != "" {
+		variants, err := cr.findVariants(cr.path, cr.currentProfile)
+		if err != nil {
+			return err
+		}
+
+		var ok bool
+
+		variant, ok = variants[cr.currentVariantName]
+		if !ok {
+			return fmt.Errorf("unknown variant: %s", cr.currentVariantName)
+		}
+	} else if cr.currentProfile.GetVariant() == nil {
+		return nil
+	}
+
+	p, err := cr.currentProfile.ApplyVariant(cr.currentVariantName, variant)
+	if err != nil {
+		return err //nolint:wrapcheck // Already wrapped.
+	}
+
+	cr.currentProfile = p
+
+	return nil
+}
+
+// findVariants returns the variants of the profile for the path.
+func (cr *Runner) findVariants(path string, p *profile.Profile) (map[string]*profile.Variant, error) {
+	if len(p.Variants) == 0 {
+		return nil, nil //nolint:nilnil // The profile has no variants.
+	}
+
+	files, err := cr.listFiles(path, p)
+	if err != nil {
+		return nil, err
+	}
+
+	variants, err := p.FindVariants(path, files)
+	if err != nil {
+		return nil, fmt.Errorf("find variants: %w", err)
+	}
+
+	return variants, nil
+}
+
+// FindVariants returns the sorted names of the variants of the named profile
+// for the given path.
+func (cr *Runner) FindVariants(path, profileName string) ([]string, error) {
+	p, ok := cr.profiles[profileName]
+	if !ok {
+		return nil, fmt.Errorf("profile %q not found", profileName)
+	}
+
+	variants, err := cr.findVariants(path, p)
+	if err != nil {
+		return nil, err
+	}
+
+	return slices.Sorted(maps.Keys(variants)), nil
+}
+
+// GetCurrentVariant returns the name of the current variant, or an empty
+// string if no variant is used.
+func (cr *Runner) GetCurrentVariant() string {
+	return cr.currentVariantName
+}
+
 // RunPluginContext executes a plugin by name with the provided context.
-func (cr *Runner) RunPluginContext(ctx context.Context, name string) Output {
+func (cr *Runner) RunPluginContext(ctx context.Context, name string, opts ...PluginRunOpt) Output {
+	run := &PluginRun{}
+	for _, opt := range opts {
+		opt(run)
+	}
+
 	cr.mu.Lock()
 
 	var (
-		path = cr.path
-		p    = cr.currentProfile
+		path        = cr.path
+		p           = cr.currentProfile
+		profileName = cr.currentProfileName
 	)
 
 	ctx, span := cr.tracer.Start(ctx, "plugin", trace.WithAttributes(
 		attribute.String("name", name),
 		attribute.String("path", path),
+		attribute.Int("resources", len(run.Resources)),
 	))
 	defer span.End()
 
@@ -519,7 +717,10 @@ func (cr *Runner) RunPluginContext(ctx context.Context, name string) Output {
 		return co
 	}
 
-	result, err := plugin.Exec(ctx, path)
+	result, err := plugin.ExecWithOptions(ctx, cr.execDir(path), execs.ExecOptions{
+		Stdin: run.stdin(),
+		Env:   run.env(profileName, cr.execDir(path)),
+	})
 	co.Error = err
 	co.Stdout = result.Stdout
 	co.Stderr = result.Stderr
@@ -541,67 +742,132 @@ func (cr *Runner) RunPluginContext(ctx context.Context, name string) Output {
 	return co
 }
 
-func (cr *Runner) watchSource(ctx context.Context) error {
-	var (
-		files []string
-		p     = cr.currentProfile
-	)
+// execDir returns the directory in which commands for the path are executed.
+// Paths are relative to the root, which is not necessarily the current
+// working directory, e.g. for fetched remote sources.
+func (cr *Runner) execDir(path string) string {
+	return filepath.Join(cr.root.Name(), path)
+}
 
-	err := fs.WalkDir(cr.root.FS(), cr.path, func(path string, d fs.DirEntry, err error) error {
-		if err != nil {
-			return err
-		}
+// listFiles returns the paths of all files under the given path, relative to
+// the root. Files that are ignored globally or by the profile are skipped.
+func (cr *Runner) listFiles(dir string, p *profile.Profile) ([]string, error) {
+	m, err := cr.ignoreMatcher(dir, p)
+	if err != nil {
+		return nil, err
+	}
 
-		if d.IsDir() {
-			// Skip directories, we only want to match against files.
-			return nil
-		}
+	return walkFiles(cr.root.FS(), dir, m, cr.ignoreFiles, true)
+}
 
-		files = append(files, path)
+// ignoreMatcher returns an [ignore.Matcher] for the global ignore patterns,
+// the ignore patterns of the profile (if any), and the ignore files in dir and
+// each of its parents.
+func (cr *Runner) ignoreMatcher(dir string, p *profile.Profile) (*ignore.Matcher, error) {
+	patterns := cr.ignore
+	if p != nil {
+		patterns = slices.Concat(cr.ignore, p.Ignore)
+	}
 
-		return nil
-	})
+	return newIgnoreMatcher(cr.root.FS(), dir, cr.ignoreFiles, patterns...)
+}
+
+func (cr *Runner) watchSource(ctx context.Context) error {
+	p := cr.currentProfile
+
+	files, err := cr.listFiles(cr.path, p)
 	if err != nil {
-		return fmt.Errorf("walk %q: %w", cr.path, err)
+		return err
 	}
 
-	cr.watchedFiles = make(map[string]struct{})
+	var watchFiles []string
 	if ok, matchedFiles := p.MatchFiles(cr.path, files); ok {
-		for _, file := range matchedFiles {
-			dir := filepath.Dir(file)
+		watchFiles = matchedFiles
+	}
 
-			// Convert relative path to absolute path for fsnotify.
-			absDir, err := cr.root.Open(dir)
-			if err != nil {
-				return fmt.Errorf("open directory %q in root: %w", dir, err)
-			}
+	// Also watch the files of Kustomize and Helm dependencies, which are
+	// often outside of the path, e.g. `../../base`. Dependencies outside of
+	// the root are not watched.
+	deps, err := cr.findDependencies(cr.path)
+	if err != nil {
+		log.WithContext(ctx).WarnContext(ctx, "find dependencies",
+			slog.String("path", cr.path),
+			slog.Any("err", err),
+		)
+	}
 
-			absDirPath := absDir.Name()
-			absFilePath := filepath.Join(absDirPath, filepath.Base(file))
+	m, err := cr.ignoreMatcher(".", p)
+	if err != nil {
+		return err
+	}
 
-			err = absDir.Close()
-			if err != nil {
-				return fmt.Errorf("close directory %q: %w", absDirPath, err)
-			}
+	for _, dep := range deps {
+		if !m.Match(filepath.ToSlash(dep), false) {
+			watchFiles = append(watchFiles, dep)
+		}
+	}
 
-			err = cr.watcher.Add(absDirPath)
-			if err != nil {
-				return fmt.Errorf("add path to watcher: %w", err)
-			}
+	cr.watchedFiles = make(map[string]struct{})
+	for _, file := range watchFiles {
+		dir := filepath.Dir(file)
 
-			cr.watchedDirs[absDirPath] = struct{}{}
-			cr.watchedFiles[absFilePath] = struct{}{}
+		// Convert relative path to absolute path for fsnotify.
+		absDir, err := cr.root.Open(dir)
+		if err != nil {
+			return fmt.Errorf("open directory %q in root: %w", dir, err)
 		}
+
+		absDirPath := absDir.Name()
+		absFilePath := filepath.Join(absDirPath, filepath.Base(file))
+
+		err = absDir.Close()
+		if err != nil {
+			return fmt.Errorf("close directory %q: %w", absDirPath, err)
+		}
+
+		err = cr.watcher.Add(absDirPath)
+		if err != nil {
+			return fmt.Errorf("add path to watcher: %w", err)
+		}
+
+		cr.watchedDirs[absDirPath] = struct{}{}
+		cr.watchedFiles[absFilePath] = struct{}{}
 	}
 
 	log.WithContext(ctx).DebugContext(ctx, "added file watchers",
 		slog.String("path", cr.path),
 		slog.Int("count", len(cr.watchedDirs)),
+		slog.Int("dependencies", len(deps)),
 	)
 
 	return nil
 }
 
+// findDependencies returns the paths of the files that the project at the
+// path depends on, relative to the root. See [FindDependencies].
+// If some dependencies are outside of the root, the others are returned
+// with an error.
+func (cr *Runner) findDependencies(path string) ([]string, error) {
+	dir := path
+
+	info, err := cr.root.Stat(dir)
+	if err != nil {
+		return nil, fmt.Errorf("stat %q: %w", dir, err)
+	}
+
+	if !info.IsDir() {
+		dir = filepath.Dir(dir)
+	}
+
+	// Dependencies outside of the root are returned with the error.
+	deps, err := FindDependencies(cr.root.FS(), filepath.ToSlash(dir))
+	for i, dep := range deps {
+		deps[i] = filepath.FromSlash(dep)
+	}
+
+	return deps, err
+}
+
 func (cr *Runner) removeWatchers(ctx context.Context) {
 	if cr.watcher == nil || len(cr.watchedDirs) == 0 {
 		return
@@ -804,9 +1070,12 @@ func (cr *Runner) RunContext(ctx context.Context) Output {
 	cr.mu.Lock()
 
 	var (
-		path = cr.path
-		p    = cr.currentProfile
-		cmd  = p.Command.Command
+		path      = cr.path
+		p         = cr.currentProfile
+		cmd       = p.Command.Command
+		validator = cr.validator
+		cache     = cr.cache
+		checks    = cr.checks
 	)
 
 	ctx, span := cr.tracer.Start(ctx, "run", trace.WithAttributes(
@@ -838,12 +1107,9 @@ func (cr *Runner) RunContext(ctx context.Context) Output {
 		return co
 	}
 
-	result, err := p.Exec(ctx, path)
-	co.Error = err
-	if result != nil {
-		co.Stdout = result.Stdout
-		co.Stderr = result.Stderr
-	}
+	cached := cr.execProfile(ctx, cache, path, p, &co)
+
+	span.SetAttributes(attribute.Bool("cached", cached))
 
 	// Check if the command was canceled.
 	if co.Error != nil && errors.Is(ctx.Err(), context.Canceled) {
@@ -857,41 +1123,75 @@ func (cr *Runner) RunContext(ctx context.Context) Output {
 		return co
 	}
 
+	loadResources(&co, validator, checks)
+	cr.broadcast(NewEventEnd(ctx, co))
+
+	return co
+}
+
+// execProfile executes the profile's command for the path, or reads its
+// output from the cache. Successful outputs are added to the cache. It
+// reports whether the output was cached.
+func (cr *Runner) execProfile(ctx context.Context, cache *Cache, path string, p *profile.Profile, co *Output) bool {
+	key := cr.cacheKeyFor(ctx, cache, path, p)
+
+	entry, cached := cr.cacheGet(ctx, cache, key)
+	if cached {
+		co.Stdout = entry.Stdout
+		co.Stderr = entry.Stderr
+
+		return true
+	}
+
+	result, err := p.Exec(ctx, cr.execDir(path))
+	co.Error = err
+	if result != nil {
+		co.Stdout = result.Stdout
+		co.Stderr = result.Stderr
+	}
+
+	if err == nil {
+		cr.cachePut(ctx, cache, key, cacheEntry{Stdout: co.Stdout, Stderr: co.Stderr})
+	}
+
+	return false
+}
+
+// loadResources splits the output's stdout into resources, and adds
+// diagnostics from the validator, checks and references. The output's stderr
+// is parsed into warnings.
+func loadResources(co *Output, validator *validate.Validator, checks []*check.Check) {
+	co.Warnings = ParseWarnings(co.Stderr)
+
 	objects, err := kube.SplitYAML([]byte(co.Stdout))
 	if err != nil {
 		co.Error = fmt.Errorf("%w: %w", err, co.Error)
 	}
 
-	co.Resources = objects
-	cr.broadcast(NewEventEnd(ctx, co))
+	if validator != nil {
+		validator.Validate(objects)
+	}
 
-	return co
+	check.Run(checks, objects)
+	kube.NewGraph(objects).AddDiagnostics()
+
+	co.Resources = objects
 }
 
 // findMatchInDirectory looks for matching files in a directory.
 // It collects all files and allows CEL expressions to operate on the entire collection.
 // Returns (rule, files) where files contains the specific files to process, or nil to use profile.source.
+// Ignored files are not considered.
 func (cr *Runner) findMatchInDirectory(dirPath string) ([]*rule.Rule, error) {
-	var files []string
+	m, err := cr.ignoreMatcher(dirPath, nil)
+	if err != nil {
+		return nil, err
+	}
 
 	// Collect all files in the directory (non-recursive).
-	err := fs.WalkDir(cr.root.FS(), dirPath, func(path string, d fs.DirEntry, err error) error {
-		if err != nil {
-			return err
-		}
-
-		if d.IsDir() && path != dirPath {
-			return filepath.SkipDir // Skip subdirectories.
-		}
-
-		if !d.IsDir() {
-			files = append(files, path)
-		}
-
-		return nil
-	})
+	files, err := walkFiles(cr.root.FS(), dirPath, m, cr.ignoreFiles, false)
 	if err != nil {
-		return nil, fmt.Errorf("walk %q: %w", dirPath, err)
+		return nil, err
 	}
 
 	// Try each rule with the full file collection.
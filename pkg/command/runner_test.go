@@ -2,6 +2,8 @@ package command_test
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
@@ -14,6 +16,7 @@ import (
 
 	"github.com/macropower/kat/pkg/command"
 	"github.com/macropower/kat/pkg/execs"
+	"github.com/macropower/kat/pkg/kube"
 	"github.com/macropower/kat/pkg/profile"
 	"github.com/macropower/kat/pkg/rule"
 )
@@ -28,7 +31,11 @@ func (m *mockExecutor) Exec(ctx context.Context, dir string) (*execs.Result, err
 	return m.ExecWithStdin(ctx, dir, nil)
 }
 
-func (m *mockExecutor) ExecWithStdin(_ context.Context, _ string, _ []byte) (*execs.Result, error) {
+func (m *mockExecutor) ExecWithStdin(ctx context.Context, dir string, stdin []byte) (*execs.Result, error) {
+	return m.ExecWithOptions(ctx, dir, execs.ExecOptions{Stdin: stdin})
+}
+
+func (m *mockExecutor) ExecWithOptions(_ context.Context, _ string, _ execs.ExecOptions) (*execs.Result, error) {
 	return m.result, m.err
 }
 
@@ -36,6 +43,35 @@ func (m *mockExecutor) String() string {
 	return "mock executor"
 }
 
+// recordingExecutor is a test implementation of the Executor interface that
+// records the options of each execution.
+type recordingExecutor struct {
+	result *execs.Result
+	calls  []execs.ExecOptions
+}
+
+func (r *recordingExecutor) Exec(ctx context.Context, dir string) (*execs.Result, error) {
+	return r.ExecWithOptions(ctx, dir, execs.ExecOptions{})
+}
+
+func (r *recordingExecutor) ExecWithStdin(ctx context.Context, dir string, stdin []byte) (*execs.Result, error) {
+	return r.ExecWithOptions(ctx, dir, execs.ExecOptions{Stdin: stdin})
+}
+
+func (r *recordingExecutor) ExecWithOptions(
+	_ context.Context,
+	_ string,
+	opts execs.ExecOptions,
+) (*execs.Result, error) {
+	r.calls = append(r.calls, opts)
+
+	return r.result, nil
+}
+
+func (r *recordingExecutor) String() string {
+	return "recording executor"
+}
+
 // newMockExecutor creates a mock executor that returns the specified result and error.
 func newMockExecutor(stdout, stderr string, err error) *mockExecutor {
 	var result *execs.Result
@@ -240,6 +276,44 @@ func TestCommandRunner_WithCommand(t *testing.T) {
 	assert.Equal(t, "Resource", output.Resources[0].Object.GetKind())
 }
 
+func TestRunner_RunContext_Warnings(t *testing.T) {
+	t.Parallel()
+
+	tcs := map[string]struct {
+		executor *mockExecutor
+		want     []command.Warning
+	}{
+		"success with stderr": {
+			executor: newMockExecutor("kind: ConfigMap", "walk.go:74: found symbolic link\n", nil),
+			want:     []command.Warning{{Location: "walk.go:74", Message: "found symbolic link"}},
+		},
+		"success without stderr": {
+			executor: newMockExecutor("kind: ConfigMap", "", nil),
+			want:     nil,
+		},
+		"failure": {
+			executor: newMockExecutor("", "", errors.New("exit status 1")),
+			want:     nil,
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			p, err := profile.New("echo", profile.WithExecutor(tc.executor))
+			require.NoError(t, err)
+
+			root, _ := testRoot(t)
+			runner, err := command.NewRunnerWithRoot(root, ".", command.WithCustomProfile("echo", p))
+			require.NoError(t, err)
+
+			output := runner.RunContext(t.Context())
+			assert.Equal(t, tc.want, output.Warnings)
+		})
+	}
+}
+
 func TestCommandRunner_RunContext(t *testing.T) {
 	t.Parallel()
 
@@ -475,6 +549,122 @@ func TestCommandRunner_FileWatcher(t *testing.T) {
 	}
 }
 
+func TestRunner_WatchDependencies(t *testing.T) {
+	t.Parallel()
+
+	batchDuration := 50 * time.Millisecond
+
+	root, tempDir := testRoot(t)
+
+	files := map[string]string{
+		"overlay/kustomization.yaml": "resources:\n- ../base\n",
+		"base/kustomization.yaml":    "resources:\n- deploy.yaml\n",
+		"base/deploy.yaml":           "kind: Deployment",
+	}
+	for name, content := range files {
+		require.NoError(t, os.MkdirAll(filepath.Join(tempDir, filepath.Dir(name)), 0o755))
+		require.NoError(t, os.WriteFile(filepath.Join(tempDir, name), []byte(content), 0o644))
+	}
+
+	fw := newFakeWatcher()
+
+	p, err := profile.New("echo",
+		profile.WithSource(`files.filter(f, pathExt(f) == ".yaml")`),
+		profile.WithExecutor(newMockExecutor("foo: bar", "", nil)),
+	)
+	require.NoError(t, err)
+
+	runner, err := command.NewRunnerWithRoot(
+		root,
+		"overlay",
+		command.WithCustomProfile("echo", p),
+		command.WithWatch(true),
+		command.WithWatcherBatchDuration(batchDuration),
+		command.WithWatcher(fw),
+	)
+	require.NoError(t, err)
+
+	t.Cleanup(runner.Close)
+
+	results := make(chan command.Event, 100)
+	runner.Subscribe(results)
+
+	go runner.RunOnEvent()
+
+	// Files outside of the path that are not dependencies are ignored.
+	fw.events <- fsnotify.Event{Name: filepath.Join(tempDir, "unrelated.yaml"), Op: fsnotify.Write}
+
+	time.Sleep(3 * batchDuration)
+
+	// Files of the base, outside of the path, trigger a run.
+	fw.events <- fsnotify.Event{Name: filepath.Join(tempDir, "base", "deploy.yaml"), Op: fsnotify.Write}
+
+	events := collectRunnerEventsWithTimeout(results, 2, 5*time.Second)
+	require.Len(t, events, 2)
+	assert.IsType(t, command.EventStart{}, events[0])
+	assert.IsType(t, command.EventEnd{}, events[1])
+}
+
+func TestRunner_WatchIgnore(t *testing.T) {
+	t.Parallel()
+
+	batchDuration := 50 * time.Millisecond
+
+	root, tempDir := testRoot(t)
+
+	files := map[string]string{
+		"app/deploy.yaml":          "kind: Deployment",
+		"app/generated.yaml":       "kind: Deployment",
+		"app/.katignore":           "generated.yaml\n",
+		"app/charts/c/values.yaml": "a: b",
+	}
+	for name, content := range files {
+		require.NoError(t, os.MkdirAll(filepath.Join(tempDir, filepath.Dir(name)), 0o755))
+		require.NoError(t, os.WriteFile(filepath.Join(tempDir, name), []byte(content), 0o644))
+	}
+
+	fw := newFakeWatcher()
+
+	p, err := profile.New("echo",
+		profile.WithSource(`files.filter(f, pathExt(f) == ".yaml")`),
+		profile.WithIgnore("app/charts/"),
+		profile.WithExecutor(newMockExecutor("foo: bar", "", nil)),
+	)
+	require.NoError(t, err)
+
+	runner, err := command.NewRunnerWithRoot(
+		root,
+		"app",
+		command.WithCustomProfile("echo", p),
+		command.WithIgnoreFiles(".katignore"),
+		command.WithWatch(true),
+		command.WithWatcherBatchDuration(batchDuration),
+		command.WithWatcher(fw),
+	)
+	require.NoError(t, err)
+
+	t.Cleanup(runner.Close)
+
+	results := make(chan command.Event, 100)
+	runner.Subscribe(results)
+
+	go runner.RunOnEvent()
+
+	// Files ignored by an ignore file or the profile do not trigger a run.
+	fw.events <- fsnotify.Event{Name: filepath.Join(tempDir, "app", "generated.yaml"), Op: fsnotify.Write}
+	fw.events <- fsnotify.Event{Name: filepath.Join(tempDir, "app", "charts", "c", "values.yaml"), Op: fsnotify.Write}
+
+	time.Sleep(3 * batchDuration)
+
+	// Files that are not ignored trigger a run.
+	fw.events <- fsnotify.Event{Name: filepath.Join(tempDir, "app", "deploy.yaml"), Op: fsnotify.Write}
+
+	events := collectRunnerEventsWithTimeout(results, 2, 5*time.Second)
+	require.Len(t, events, 2)
+	assert.IsType(t, command.EventStart{}, events[0])
+	assert.IsType(t, command.EventEnd{}, events[1])
+}
+
 func TestCommandRunner_String(t *testing.T) {
 	t.Parallel()
 
@@ -633,6 +823,174 @@ func TestCommandRunner_RunPluginContext(t *testing.T) {
 	}
 }
 
+func TestRunner_ExecDir(t *testing.T) {
+	t.Parallel()
+
+	const configMap = "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: test\n"
+
+	// Each command fails unless it is executed in the project directory,
+	// which is not the current working directory.
+	inProject := profile.MustNewHookCommand("test", profile.WithHookArgs("-f", "app.yaml"))
+
+	tcs := map[string]struct {
+		path string
+	}{
+		"root path": {
+			path: ".",
+		},
+		"nested path": {
+			path: "app",
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			root, tempDir := testRoot(t)
+			dir := filepath.Join(tempDir, tc.path)
+			require.NoError(t, os.MkdirAll(dir, 0o755))
+			require.NoError(t, os.WriteFile(filepath.Join(dir, "app.yaml"), []byte(configMap), 0o644))
+
+			p := profile.MustNew("cat",
+				profile.WithArgs("app.yaml"),
+				profile.WithHooks(profile.MustNewHooks(
+					profile.WithInit(inProject),
+					profile.WithPreRender(inProject),
+					profile.WithPostRender(inProject),
+				)),
+				profile.WithPlugins(map[string]*profile.Plugin{
+					"pwd": profile.MustNewPlugin("sh", "print directory",
+						profile.WithPluginArgs("-c", "pwd -P")),
+				}))
+
+			runner, err := command.NewRunnerWithRoot(root, tc.path,
+				command.WithCustomProfile("custom", p))
+			require.NoError(t, err)
+			t.Cleanup(runner.Close)
+
+			output := runner.RunContext(t.Context())
+			require.NoError(t, output.Error)
+			assert.Equal(t, configMap, output.Stdout)
+
+			want, err := filepath.EvalSymlinks(dir)
+			require.NoError(t, err)
+
+			output = runner.RunPluginContext(t.Context(), "pwd")
+			require.NoError(t, output.Error)
+			assert.Equal(t, want+"\n", output.Stdout)
+		})
+	}
+}
+
+func TestRunner_RunPluginContext_Resources(t *testing.T) {
+	t.Parallel()
+
+	root, tempDir := testRoot(t)
+
+	p := profile.MustNew("echo",
+		profile.WithPlugins(map[string]*profile.Plugin{
+			"cat": profile.MustNewPlugin("cat", "print stdin"),
+			"env": profile.MustNewPlugin("sh", "print env",
+				profile.WithPluginArgs("-c", `echo "$KAT_PROFILE $KAT_PATH $KAT_KIND $KAT_NAME $KAT_NAMESPACE"`)),
+		}))
+
+	runner, err := command.NewRunnerWithRoot(root, ".",
+		command.WithCustomProfile("custom", p))
+	require.NoError(t, err)
+
+	resources, err := kube.SplitYAML([]byte("kind: A\n---\nkind: B\n"))
+	require.NoError(t, err)
+
+	current, err := kube.SplitYAML([]byte("kind: Pod\nmetadata:\n  name: web\n  namespace: prod\n"))
+	require.NoError(t, err)
+
+	tcs := map[string]struct {
+		plugin string
+		want   string
+		opts   []command.PluginRunOpt
+	}{
+		"without resources": {
+			plugin: "cat",
+			want:   "",
+		},
+		"with resources": {
+			plugin: "cat",
+			opts:   []command.PluginRunOpt{command.WithPluginResources(resources...)},
+			want:   "kind: A\n---\nkind: B\n",
+		},
+		"with current resource": {
+			plugin: "cat",
+			opts:   []command.PluginRunOpt{command.WithPluginResource(current[0])},
+			want:   "kind: Pod\nmetadata:\n  name: web\n  namespace: prod\n",
+		},
+		"selection takes precedence on stdin": {
+			plugin: "cat",
+			opts: []command.PluginRunOpt{
+				command.WithPluginResource(current[0]),
+				command.WithPluginResources(resources...),
+			},
+			want: "kind: A\n---\nkind: B\n",
+		},
+		"env without current resource": {
+			plugin: "env",
+			want:   "custom " + tempDir + "   \n",
+		},
+		"env with current resource": {
+			plugin: "env",
+			opts:   []command.PluginRunOpt{command.WithPluginResource(current[0])},
+			want:   "custom " + tempDir + " Pod web prod\n",
+		},
+	}
+
+	for name, tc := range tcs {
+		//nolint:paralleltest // Running a plugin cancels the previous run.
+		t.Run(name, func(t *testing.T) {
+			output := runner.RunPluginContext(t.Context(), tc.plugin, tc.opts...)
+			require.NoError(t, output.Error)
+			assert.Equal(t, tc.want, output.Stdout)
+		})
+	}
+}
+
+func TestRunner_RunPluginContext_Executor(t *testing.T) {
+	t.Parallel()
+
+	root, tempDir := testRoot(t)
+
+	// Executors set with WithPluginExecutor receive the same input as the
+	// default executor.
+	executor := &recordingExecutor{result: &execs.Result{Stdout: "ok"}}
+
+	p := profile.MustNew("echo",
+		profile.WithPlugins(map[string]*profile.Plugin{
+			"custom": profile.MustNewPlugin("custom", "custom executor",
+				profile.WithPluginExecutor(executor)),
+		}))
+
+	runner, err := command.NewRunnerWithRoot(root, ".",
+		command.WithCustomProfile("custom", p))
+	require.NoError(t, err)
+	t.Cleanup(runner.Close)
+
+	current, err := kube.SplitYAML([]byte("kind: Pod\nmetadata:\n  name: web\n  namespace: prod\n"))
+	require.NoError(t, err)
+
+	output := runner.RunPluginContext(t.Context(), "custom", command.WithPluginResource(current[0]))
+	require.NoError(t, output.Error)
+	assert.Equal(t, "ok", output.Stdout)
+
+	require.Len(t, executor.calls, 1)
+	assert.Equal(t, []byte(current[0].Content()), executor.calls[0].Stdin)
+	assert.Equal(t, []execs.EnvVar{
+		{Name: profile.PluginEnvProfile, Value: "custom"},
+		{Name: profile.PluginEnvPath, Value: tempDir},
+		{Name: profile.PluginEnvKind, Value: "Pod"},
+		{Name: profile.PluginEnvName, Value: "web"},
+		{Name: profile.PluginEnvNamespace, Value: "prod"},
+	}, executor.calls[0].Env)
+}
+
 func TestRunner_GetProfiles(t *testing.T) {
 	t.Parallel()
 
@@ -1465,3 +1823,149 @@ func TestRunner_SymlinkPathEscapePrevention(t *testing.T) {
 		})
 	}
 }
+
+func TestRunner_WithVariant(t *testing.T) {
+	t.Parallel()
+
+	root, tempDir := testRoot(t)
+	for _, name := range []string{"values.yaml", "values-dev.yaml", "values-prod.yaml"} {
+		require.NoError(t, os.WriteFile(filepath.Join(tempDir, name), []byte("key: value"), 0o644))
+	}
+
+	p, err := profile.New("echo",
+		profile.WithArgs("template"),
+		profile.WithVariants(map[string]*profile.Variant{
+			"values": {
+				Source: `files.filter(f, pathBase(f).matches("^values-.+[.]yaml$"))`,
+				Args:   []string{"-f", "$(file)"},
+			},
+		}),
+	)
+	require.NoError(t, err)
+
+	runner, err := command.NewRunnerWithRoot(root, ".",
+		command.WithProfiles(map[string]*profile.Profile{"echo": p}),
+		command.WithProfile("echo"))
+	require.NoError(t, err)
+	t.Cleanup(runner.Close)
+
+	variants, err := runner.FindVariants(".", "echo")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"values-dev.yaml", "values-prod.yaml"}, variants)
+
+	err = runner.Configure(command.WithVariant("values-prod.yaml"))
+	require.NoError(t, err)
+	assert.Equal(t, "values-prod.yaml", runner.GetCurrentVariant())
+	assert.Equal(t, "template -f values-prod.yaml\n", runner.Run().Stdout)
+
+	// Reconfiguring does not apply the variant twice.
+	err = runner.Configure(command.WithPath("."))
+	require.NoError(t, err)
+	assert.Equal(t, "template -f values-prod.yaml\n", runner.Run().Stdout)
+
+	err = runner.Configure(command.WithVariant(""))
+	require.NoError(t, err)
+	assert.Equal(t, "template\n", runner.Run().Stdout)
+
+	err = runner.Configure(command.WithVariant("values-staging.yaml"))
+	require.ErrorContains(t, err, "unknown variant")
+}
+
+func TestRunner_RunMatrix(t *testing.T) {
+	t.Parallel()
+
+	root, tempDir := testRoot(t)
+	files := map[string]string{
+		"env-dev.yaml":  "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: app\ndata:\n  env: dev\n",
+		"env-prod.yaml": "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: app\ndata:\n  env: prod\n---\napiVersion: v1\nkind: Secret\nmetadata:\n  name: prod\n",
+	}
+	for name, content := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(tempDir, name), []byte(content), 0o644))
+	}
+
+	p, err := profile.New("cat",
+		profile.WithVariants(map[string]*profile.Variant{
+			"envs": {
+				Source: `files.filter(f, pathBase(f).startsWith("env-"))`,
+				Args:   []string{"$(file)"},
+			},
+		}),
+	)
+	require.NoError(t, err)
+
+	runner, err := command.NewRunnerWithRoot(root, ".",
+		command.WithProfiles(map[string]*profile.Profile{"cat": p}),
+		command.WithProfile("cat"))
+	require.NoError(t, err)
+	t.Cleanup(runner.Close)
+
+	out, err := runner.RunMatrix()
+	require.NoError(t, err)
+	require.NoError(t, out.Err())
+
+	m := out.Matrix
+	assert.Equal(t, []string{"env-dev.yaml", "env-prod.yaml"}, m.Names)
+	require.Len(t, m.Resources, 2)
+	assert.Equal(t, "v1/ConfigMap/app", m.Resources[0].Metadata.String())
+	assert.Equal(t, []kube.FieldDifference{{Path: ".data.env", Values: []any{"dev", "prod"}}}, m.Resources[0].Fields)
+	assert.Equal(t, []bool{false, true}, m.Resources[1].Present)
+
+	out, err = runner.RunMatrix("env-dev.yaml")
+	require.NoError(t, err)
+	assert.True(t, out.Matrix.Consistent())
+
+	_, err = runner.RunMatrix("env-staging.yaml")
+	require.ErrorContains(t, err, "unknown variant")
+
+	err = runner.Configure(command.WithCustomProfile("plain", profile.MustNew("cat")))
+	require.NoError(t, err)
+
+	_, err = runner.RunMatrix()
+	require.ErrorIs(t, err, command.ErrNoVariants)
+}
+
+func TestRunner_RunMatrixPreRender(t *testing.T) {
+	t.Parallel()
+
+	root, tempDir := testRoot(t)
+	for _, env := range []string{"dev", "staging", "prod"} {
+		content := fmt.Sprintf("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: app\ndata:\n  env: %s\n", env)
+		require.NoError(t, os.WriteFile(filepath.Join(tempDir, "env-"+env+".yaml"), []byte(content), 0o644))
+	}
+
+	// Log the preRender hook runs outside of the project directory.
+	logDir := t.TempDir()
+	hook := profile.MustNewHookCommand("sh",
+		profile.WithHookArgs("-c", fmt.Sprintf("echo run >> %s/hooks.log", logDir)))
+
+	status := &profile.Status{}
+	p := profile.MustNew("cat",
+		profile.WithHooks(profile.MustNewHooks(profile.WithPreRender(hook))),
+		profile.WithStatusManager(status),
+		profile.WithVariants(map[string]*profile.Variant{
+			"envs": {
+				Source: `files.filter(f, pathBase(f).startsWith("env-"))`,
+				Args:   []string{"$(file)"},
+			},
+		}),
+	)
+
+	runner, err := command.NewRunnerWithRoot(root, ".",
+		command.WithProfiles(map[string]*profile.Profile{"cat": p}),
+		command.WithProfile("cat"))
+	require.NoError(t, err)
+	t.Cleanup(runner.Close)
+
+	out, err := runner.RunMatrix()
+	require.NoError(t, err)
+	require.NoError(t, out.Err())
+	assert.Len(t, out.Matrix.Names, 3)
+
+	// The hook runs once for the project directory, not once per variant.
+	runs, err := os.ReadFile(filepath.Join(logDir, "hooks.log"))
+	require.NoError(t, err)
+	assert.Equal(t, "run\n", string(runs))
+
+	// The variants do not update the status of the current profile.
+	assert.Equal(t, (&profile.Status{}).RenderMap(), status.RenderMap())
+}
@@ -56,6 +56,10 @@ func (rg *Static) GetPath() string {
 	return "."
 }
 
+func (rg *Static) GetWorkspace() string {
+	return ""
+}
+
 func (rg *Static) FindProfile(_ string) (string, *profile.Profile, error) {
 	// Static resources do not have a profile.
 	return "", nil, errors.ErrUnsupported
@@ -66,6 +70,25 @@ func (rg *Static) FindProfiles(_ string) ([]ProfileMatch, error) {
 	return nil, errors.ErrUnsupported
 }
 
+func (rg *Static) GetCurrentVariant() string {
+	// Static resources do not have variants.
+	return ""
+}
+
+func (rg *Static) FindVariants(_, _ string) ([]string, error) {
+	// Static resources do not have variants.
+	return nil, errors.ErrUnsupported
+}
+
+func (rg *Static) RunMatrix(names ...string) (MatrixOutput, error) {
+	return rg.RunMatrixContext(context.Background(), names...)
+}
+
+func (rg *Static) RunMatrixContext(_ context.Context, _ ...string) (MatrixOutput, error) {
+	// Static resources do not have variants.
+	return MatrixOutput{}, errors.ErrUnsupported
+}
+
 func (rg *Static) Run() Output {
 	return rg.RunContext(context.Background())
 }
@@ -114,11 +137,11 @@ func (rg *Static) SendEvent(evt Event) {
 	rg.broadcast(evt)
 }
 
-func (rg *Static) RunPlugin(_ string) Output {
+func (rg *Static) RunPlugin(_ string, _ ...PluginRunOpt) Output {
 	return rg.RunPluginContext(context.Background(), "")
 }
 
-func (rg *Static) RunPluginContext(ctx context.Context, _ string) Output {
+func (rg *Static) RunPluginContext(ctx context.Context, _ string, _ ...PluginRunOpt) Output {
 	rg.broadcast(NewEventStart(ctx, TypePlugin))
 
 	out := NewOutput(TypePlugin, WithError(errors.New("plugins not supported in static resource mode")))
@@ -0,0 +1,73 @@
+package command
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	// warningLocation matches a source location at the start of a line, e.g.
+	// "coalesce.go:298: ", as printed by Helm.
+	warningLocation = regexp.MustCompile(`^([\w.-]+:\d+):\s*`)
+
+	// warningPrefix matches a level prefix at the start of a line, e.g.
+	// "warning: " or "# Warning: ", as printed by Helm and Kustomize.
+	warningPrefix = regexp.MustCompile(`(?i)^(?:#\s*)?warn(?:ing)?\s*:\s*`)
+)
+
+// Warning is a line printed to stderr by a command that succeeded.
+type Warning struct {
+	// Location is the source location reported by the command, e.g.
+	// `coalesce.go:298`. It is empty if no location was reported.
+	Location string `json:"location,omitempty"`
+	// Message is the warning, without its location or level prefix.
+	Message string `json:"message"`
+}
+
+// String returns the warning in the format `location: message`.
+func (w Warning) String() string {
+	if w.Location == "" {
+		return w.Message
+	}
+
+	return w.Location + ": " + w.Message
+}
+
+// ParseWarnings parses each non-empty line of stderr into a [Warning].
+// Repeated lines are only included once.
+func ParseWarnings(stderr string) []Warning {
+	var (
+		warnings []Warning
+		seen     = map[Warning]struct{}{}
+	)
+
+	for line := range strings.SplitSeq(stderr, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		var w Warning
+
+		match := warningLocation.FindStringSubmatch(line)
+		if match != nil {
+			w.Location = match[1]
+			line = line[len(match[0]):]
+		}
+
+		w.Message = warningPrefix.ReplaceAllString(line, "")
+		if w.Message == "" {
+			continue
+		}
+
+		_, ok := seen[w]
+		if ok {
+			continue
+		}
+
+		seen[w] = struct{}{}
+		warnings = append(warnings, w)
+	}
+
+	return warnings
+}
@@ -0,0 +1,74 @@
+package command_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/macropower/kat/pkg/command"
+)
+
+func TestParseWarnings(t *testing.T) {
+	t.Parallel()
+
+	tcs := map[string]struct {
+		stderr string
+		want   []command.Warning
+	}{
+		"empty": {
+			stderr: "",
+			want:   nil,
+		},
+		"blank lines": {
+			stderr: "\n  \n",
+			want:   nil,
+		},
+		"helm location": {
+			stderr: "walk.go:74: found symbolic link in path: /chart/templates/link\n",
+			want: []command.Warning{
+				{Location: "walk.go:74", Message: "found symbolic link in path: /chart/templates/link"},
+			},
+		},
+		"helm location and level": {
+			stderr: "coalesce.go:298: warning: skipped value for app.config: Not a table.\n",
+			want: []command.Warning{
+				{Location: "coalesce.go:298", Message: "skipped value for app.config: Not a table."},
+			},
+		},
+		"kustomize": {
+			stderr: "# Warning: 'commonLabels' is deprecated. Please use 'labels' instead.\n",
+			want: []command.Warning{
+				{Message: "'commonLabels' is deprecated. Please use 'labels' instead."},
+			},
+		},
+		"plain lines": {
+			stderr: "first\nWARNING: second",
+			want: []command.Warning{
+				{Message: "first"},
+				{Message: "second"},
+			},
+		},
+		"repeated lines": {
+			stderr: "warning: same\nwarning: same\nother\n",
+			want: []command.Warning{
+				{Message: "same"},
+				{Message: "other"},
+			},
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tc.want, command.ParseWarnings(tc.stderr))
+		})
+	}
+}
+
+func TestWarning_String(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "message", command.Warning{Message: "message"}.String())
+	assert.Equal(t, "walk.go:74: message", command.Warning{Location: "walk.go:74", Message: "message"}.String())
+}
@@ -1,11 +1,81 @@
 package command
 
 import (
+	"errors"
 	"fmt"
+	"log/slog"
+	"sync"
+	"syscall"
+	"time"
 
 	"github.com/fsnotify/fsnotify"
 )
 
+// DefaultPollInterval is the default interval at which watched files are
+// scanned by the polling [Watcher].
+const DefaultPollInterval = time.Second
+
+// ErrInvalidWatcherBackend is returned when an unknown [WatcherBackend] is
+// configured.
+var ErrInvalidWatcherBackend = errors.New("invalid watcher backend")
+
+// WatcherBackend selects how filesystem changes are detected.
+type WatcherBackend string
+
+const (
+	// WatcherBackendAuto uses fsnotify, and falls back to polling if fsnotify
+	// is unavailable or the inotify watch limit is reached.
+	WatcherBackendAuto WatcherBackend = "auto"
+	// WatcherBackendFSNotify uses OS-level filesystem notifications.
+	WatcherBackendFSNotify WatcherBackend = "fsnotify"
+	// WatcherBackendPoll periodically scans watched files for changes.
+	WatcherBackendPoll WatcherBackend = "poll"
+)
+
+// WatcherBackends contains all valid [WatcherBackend] values.
+var WatcherBackends = []WatcherBackend{WatcherBackendAuto, WatcherBackendFSNotify, WatcherBackendPoll}
+
+// Validate returns an error if the backend is not a known [WatcherBackend].
+// An empty backend is valid, and is treated as [WatcherBackendAuto].
+func (b WatcherBackend) Validate() error {
+	switch b {
+	case "", WatcherBackendAuto, WatcherBackendFSNotify, WatcherBackendPoll:
+		return nil
+	}
+
+	return fmt.Errorf("%w: %q", ErrInvalidWatcherBackend, b)
+}
+
+// WatcherConfig configures how filesystem changes are detected when watch is
+// enabled.
+type WatcherConfig struct {
+	// Backend selects how filesystem changes are detected. Polling works on
+	// filesystems that do not deliver notifications, e.g. bind mounts in
+	// containers or network filesystems. Defaults to "auto".
+	Backend WatcherBackend `json:"backend,omitempty" jsonschema:"title=Backend,enum=auto,enum=fsnotify,enum=poll"`
+	// Interval is how often watched files are scanned for changes when
+	// polling. Defaults to 1s.
+	Interval *time.Duration `json:"interval,omitempty" jsonschema:"title=Interval,type=string,default=1s"`
+}
+
+// GetInterval returns the configured poll interval, or [DefaultPollInterval].
+func (c *WatcherConfig) GetInterval() time.Duration {
+	if c == nil || c.Interval == nil || *c.Interval <= 0 {
+		return DefaultPollInterval
+	}
+
+	return *c.Interval
+}
+
+// GetBackend returns the configured backend, or [WatcherBackendAuto].
+func (c *WatcherConfig) GetBackend() WatcherBackend {
+	if c == nil || c.Backend == "" {
+		return WatcherBackendAuto
+	}
+
+	return c.Backend
+}
+
 // Watcher abstracts filesystem event notification so that consumers can
 // optionally inject alternate implementations instead of relying on OS-level
 // notifications.
@@ -17,6 +87,39 @@ type Watcher interface {
 	Errors() <-chan error
 }
 
+// NewWatcher creates a [Watcher] using the configured backend. If c is nil,
+// the defaults are used.
+func NewWatcher(c *WatcherConfig) (Watcher, error) {
+	backend := c.GetBackend()
+
+	err := backend.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	interval := c.GetInterval()
+
+	switch backend {
+	case WatcherBackendPoll:
+		return newPollWatcher(interval), nil
+
+	case WatcherBackendFSNotify:
+		return newFSNotifyWatcher()
+
+	default:
+		w, err := newFSNotifyWatcher()
+		if err != nil {
+			slog.Warn("fsnotify is unavailable, falling back to polling", slog.Any("err", err))
+
+			return newPollWatcher(interval), nil
+		}
+
+		return newFallbackWatcher(w, func() Watcher {
+			return newPollWatcher(interval)
+		}), nil
+	}
+}
+
 // fsnotifyWatcher adapts [fsnotify.Watcher] to the [Watcher] interface.
 type fsnotifyWatcher struct {
 	w *fsnotify.Watcher
@@ -60,3 +163,161 @@ func (f *fsnotifyWatcher) Close() error {
 
 func (f *fsnotifyWatcher) Events() <-chan fsnotify.Event { return f.w.Events }
 func (f *fsnotifyWatcher) Errors() <-chan error          { return f.w.Errors }
+
+// fallbackWatcher forwards the events of a primary [Watcher]. If the primary
+// watcher cannot add a path because a system limit was reached, e.g. the
+// inotify watch limit, all paths are moved to a fallback watcher. The
+// channels returned by Events and Errors are the same before and after
+// falling back.
+type fallbackWatcher struct {
+	active   Watcher
+	fallback func() Watcher
+	paths    map[string]struct{}
+	events   chan fsnotify.Event
+	errors   chan error
+	done     chan struct{}
+	wg       sync.WaitGroup
+	mu       sync.Mutex
+	fellBack bool
+}
+
+func newFallbackWatcher(primary Watcher, fallback func() Watcher) *fallbackWatcher {
+	w := &fallbackWatcher{
+		active:   primary,
+		fallback: fallback,
+		paths:    map[string]struct{}{},
+		events:   make(chan fsnotify.Event),
+		errors:   make(chan error),
+		done:     make(chan struct{}),
+	}
+
+	w.forward(primary)
+
+	return w
+}
+
+func (w *fallbackWatcher) Add(path string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	err := w.active.Add(path)
+	if err != nil && !w.fellBack && isWatchLimitError(err) {
+		slog.Warn("watch limit reached, falling back to polling",
+			slog.String("path", path),
+			slog.Any("err", err),
+		)
+
+		err = w.fallBack()
+		if err != nil {
+			return err
+		}
+
+		err = w.active.Add(path)
+	}
+
+	if err != nil {
+		return err //nolint:wrapcheck // Already wrapped.
+	}
+
+	w.paths[path] = struct{}{}
+
+	return nil
+}
+
+func (w *fallbackWatcher) Remove(path string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	delete(w.paths, path)
+
+	return w.active.Remove(path) //nolint:wrapcheck // Already wrapped.
+}
+
+func (w *fallbackWatcher) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	close(w.done)
+
+	err := w.active.Close()
+
+	w.wg.Wait()
+	close(w.events)
+	close(w.errors)
+
+	return err //nolint:wrapcheck // Already wrapped.
+}
+
+func (w *fallbackWatcher) Events() <-chan fsnotify.Event { return w.events }
+func (w *fallbackWatcher) Errors() <-chan error          { return w.errors }
+
+// fallBack replaces the active watcher with the fallback watcher, and adds
+// every path to it. Must be called with the lock held.
+func (w *fallbackWatcher) fallBack() error {
+	fw := w.fallback()
+
+	for path := range w.paths {
+		err := fw.Add(path)
+		if err != nil {
+			return errors.Join(err, fw.Close())
+		}
+	}
+
+	err := w.active.Close()
+	if err != nil {
+		slog.Error("close watcher", slog.Any("err", err))
+	}
+
+	w.active = fw
+	w.fellBack = true
+	w.forward(fw)
+
+	return nil
+}
+
+// forward sends the events and errors of src to the watcher's channels, until
+// src is closed or the watcher is closed.
+func (w *fallbackWatcher) forward(src Watcher) {
+	w.wg.Go(func() {
+		events, errs := src.Events(), src.Errors()
+
+		for events != nil || errs != nil {
+			select {
+			case evt, ok := <-events:
+				if !ok {
+					events = nil
+
+					continue
+				}
+
+				select {
+				case w.events <- evt:
+				case <-w.done:
+					return
+				}
+
+			case err, ok := <-errs:
+				if !ok {
+					errs = nil
+
+					continue
+				}
+
+				select {
+				case w.errors <- err:
+				case <-w.done:
+					return
+				}
+
+			case <-w.done:
+				return
+			}
+		}
+	})
+}
+
+// isWatchLimitError reports whether err was caused by reaching a system limit
+// on the number of watches or open files.
+func isWatchLimitError(err error) bool {
+	return errors.Is(err, syscall.ENOSPC) || errors.Is(err, syscall.EMFILE)
+}
@@ -0,0 +1,140 @@
+package command_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/macropower/kat/pkg/command"
+)
+
+func TestNewWatcher(t *testing.T) {
+	t.Parallel()
+
+	tcs := map[string]struct {
+		config *command.WatcherConfig
+		err    error
+	}{
+		"default": {
+			config: nil,
+		},
+		"auto": {
+			config: &command.WatcherConfig{Backend: command.WatcherBackendAuto},
+		},
+		"fsnotify": {
+			config: &command.WatcherConfig{Backend: command.WatcherBackendFSNotify},
+		},
+		"poll": {
+			config: &command.WatcherConfig{Backend: command.WatcherBackendPoll},
+		},
+		"invalid backend": {
+			config: &command.WatcherConfig{Backend: "inotify"},
+			err:    command.ErrInvalidWatcherBackend,
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			w, err := command.NewWatcher(tc.config)
+			if tc.err != nil {
+				require.ErrorIs(t, err, tc.err)
+
+				return
+			}
+
+			require.NoError(t, err)
+			require.NoError(t, w.Add(t.TempDir()))
+			require.NoError(t, w.Close())
+		})
+	}
+}
+
+func TestWatcherConfig_Defaults(t *testing.T) {
+	t.Parallel()
+
+	var nilConfig *command.WatcherConfig
+
+	assert.Equal(t, command.WatcherBackendAuto, nilConfig.GetBackend())
+	assert.Equal(t, command.DefaultPollInterval, nilConfig.GetInterval())
+
+	interval := 5 * time.Second
+	c := &command.WatcherConfig{Backend: command.WatcherBackendPoll, Interval: &interval}
+
+	assert.Equal(t, command.WatcherBackendPoll, c.GetBackend())
+	assert.Equal(t, interval, c.GetInterval())
+}
+
+func TestConfig_Validate_WatcherBackend(t *testing.T) {
+	t.Parallel()
+
+	c := &command.Config{
+		Watch: &command.WatcherConfig{Backend: "inotify"},
+	}
+
+	err := c.Validate()
+	require.ErrorIs(t, err, command.ErrInvalidWatcherBackend)
+}
+
+func TestPollWatcher(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	file := filepath.Join(dir, "app.yaml")
+	require.NoError(t, os.WriteFile(file, []byte("a: b"), 0o644))
+
+	interval := 10 * time.Millisecond
+
+	w, err := command.NewWatcher(&command.WatcherConfig{
+		Backend:  command.WatcherBackendPoll,
+		Interval: &interval,
+	})
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		require.NoError(t, w.Close())
+	})
+
+	require.NoError(t, w.Add(dir))
+
+	next := func(t *testing.T) fsnotify.Event {
+		t.Helper()
+
+		select {
+		case evt := <-w.Events():
+			return evt
+		case err := <-w.Errors():
+			require.NoError(t, err)
+		case <-time.After(5 * time.Second):
+			require.FailNow(t, "timed out waiting for event")
+		}
+
+		return fsnotify.Event{}
+	}
+
+	// The content changes, but the size and modification time do not.
+	info, err := os.Stat(file)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(file, []byte("a: c"), 0o644))
+	require.NoError(t, os.Chtimes(file, info.ModTime(), info.ModTime()))
+
+	assert.Equal(t, fsnotify.Event{Name: file, Op: fsnotify.Write}, next(t))
+
+	created := filepath.Join(dir, "new.yaml")
+	require.NoError(t, os.WriteFile(created, []byte("c: d"), 0o644))
+
+	assert.Equal(t, fsnotify.Event{Name: created, Op: fsnotify.Create}, next(t))
+
+	require.NoError(t, os.Remove(created))
+
+	assert.Equal(t, fsnotify.Event{Name: created, Op: fsnotify.Remove}, next(t))
+
+	require.NoError(t, w.Remove(dir))
+	require.ErrorIs(t, w.Remove(dir), fsnotify.ErrNonExistentWatch)
+}
@@ -0,0 +1,128 @@
+package command
+
+import (
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"slices"
+
+	"github.com/macropower/kat/pkg/ignore"
+)
+
+// Project is a directory that can be rendered, i.e. a directory whose files
+// match at least one rule.
+type Project struct {
+	// Path is the path of the directory, relative to the root.
+	Path string `json:"path"`
+	// Profiles contains the names of the profiles of the matching rules, in
+	// order of priority. The first profile is used to render the project.
+	Profiles []string `json:"profiles"`
+}
+
+// Profile returns the name of the profile used to render the [Project].
+func (p Project) Profile() string {
+	if len(p.Profiles) == 0 {
+		return ""
+	}
+
+	return p.Profiles[0]
+}
+
+// FindProjects recursively scans dir for directories that match at least one
+// [rule.Rule]. Projects are returned in the order they are found, with the
+// entries of each directory scanned in lexical order.
+//
+// Directories within a project are not scanned, since they usually belong to
+// it, e.g. the templates of a Helm chart. The only exception is dir itself,
+// which may contain configuration files that match a rule. Ignored
+// directories are skipped, and directories deeper than the maximum depth are
+// not scanned.
+func (f *FilteredFS) FindProjects(dir string) ([]Project, error) {
+	dir = filepath.Clean(dir)
+
+	m, err := newIgnoreMatcher(f.root.FS(), dir, f.ignoreFiles, f.ignore...)
+	if err != nil {
+		return nil, err
+	}
+
+	var projects []Project
+
+	err = f.findProjects(dir, m, 0, &projects)
+	if err != nil {
+		return nil, err
+	}
+
+	return projects, nil
+}
+
+func (f *FilteredFS) findProjects(dirPath string, m *ignore.Matcher, depth uint, projects *[]Project) error {
+	if f.maxDepth > 0 && depth > f.maxDepth {
+		return nil
+	}
+
+	if depth > 0 {
+		err := addIgnoreFiles(m, f.root.FS(), filepath.ToSlash(dirPath), f.ignoreFiles)
+		if err != nil {
+			return err
+		}
+	}
+
+	entries, err := fs.ReadDir(f.root.FS(), filepath.ToSlash(dirPath))
+	if err != nil {
+		return fmt.Errorf("read directory %q: %w", dirPath, err)
+	}
+
+	var files, dirs []string
+
+	for _, entry := range entries {
+		entryPath := filepath.Join(dirPath, entry.Name())
+		if m.Match(filepath.ToSlash(entryPath), entry.IsDir()) {
+			continue
+		}
+
+		if entry.IsDir() {
+			dirs = append(dirs, entryPath)
+		} else {
+			files = append(files, entryPath)
+		}
+	}
+
+	var profiles []string
+
+	for _, r := range f.rules {
+		if !slices.Contains(profiles, r.Profile) && r.MatchFiles(dirPath, files) {
+			profiles = append(profiles, r.Profile)
+		}
+	}
+
+	if len(profiles) > 0 {
+		*projects = append(*projects, Project{Path: dirPath, Profiles: profiles})
+
+		if depth > 0 {
+			return nil
+		}
+	}
+
+	for _, sub := range dirs {
+		err := f.findProjects(sub, m, depth+1, projects)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// FindProjects scans dir in root for projects, using the rules and ignore
+// settings of the [Config]. See [FilteredFS.FindProjects].
+func (c *Config) FindProjects(root RootFS, dir string) ([]Project, error) {
+	fsys, err := NewFilteredFS(root, c.Rules...)
+	if err != nil {
+		return nil, err
+	}
+
+	fsys.ignore = c.Ignore
+	fsys.ignoreFiles = c.IgnoreFiles
+
+	return fsys.FindProjects(dir)
+}
@@ -0,0 +1,121 @@
+package command_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/macropower/kat/pkg/command"
+	"github.com/macropower/kat/pkg/rule"
+)
+
+func TestConfig_FindProjects(t *testing.T) {
+	t.Parallel()
+
+	rules := []*rule.Rule{
+		rule.MustNew("ks", `files.exists(f, pathBase(f) == "kustomization.yaml")`),
+		rule.MustNew("helm", `files.exists(f, pathBase(f) == "Chart.yaml")`),
+		rule.MustNew("yaml", `files.exists(f, pathExt(f) == ".yaml")`),
+	}
+
+	tcs := map[string]struct {
+		files       map[string]string
+		dir         string
+		ignore      []string
+		ignoreFiles []string
+		want        []command.Project
+		wantErr     bool
+	}{
+		"kustomize overlays and helm charts": {
+			files: map[string]string{
+				"apps/a/base/kustomization.yaml":          "resources: []",
+				"apps/a/overlays/prod/kustomization.yaml": "resources: []",
+				"charts/b/Chart.yaml":                     "name: b",
+				"charts/b/templates/deploy.yaml":          "kind: Deployment",
+				"docs/README.md":                          "# Docs",
+			},
+			dir: ".",
+			want: []command.Project{
+				{Path: "apps/a/base", Profiles: []string{"ks", "yaml"}},
+				{Path: "apps/a/overlays/prod", Profiles: []string{"ks", "yaml"}},
+				{Path: "charts/b", Profiles: []string{"helm", "yaml"}},
+			},
+		},
+		"root is scanned when it matches": {
+			files: map[string]string{
+				"deploy/.katrc.yaml":     "kind: RuntimeConfig",
+				"deploy/app/deploy.yaml": "kind: Deployment",
+			},
+			dir: "deploy",
+			want: []command.Project{
+				{Path: "deploy", Profiles: []string{"yaml"}},
+				{Path: "deploy/app", Profiles: []string{"yaml"}},
+			},
+		},
+		"ignored directories are skipped": {
+			files: map[string]string{
+				"app/deploy.yaml":            "kind: Deployment",
+				"vendor/dep/deploy.yaml":     "kind: Deployment",
+				"generated/out/deploy.yaml":  "kind: Deployment",
+				".katignore":                 "generated/\n",
+				"node_modules/x/deploy.yaml": "kind: Deployment",
+			},
+			dir:         ".",
+			ignore:      []string{"vendor/", "node_modules/"},
+			ignoreFiles: []string{".katignore"},
+			want: []command.Project{
+				{Path: "app", Profiles: []string{"yaml"}},
+			},
+		},
+		"no projects": {
+			files: map[string]string{
+				"docs/README.md": "# Docs",
+			},
+			dir:  ".",
+			want: nil,
+		},
+		"missing directory": {
+			dir:     "missing",
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			root, tempDir := testRoot(t)
+
+			for file, content := range tc.files {
+				require.NoError(t, os.MkdirAll(filepath.Join(tempDir, filepath.Dir(file)), 0o755))
+				require.NoError(t, os.WriteFile(filepath.Join(tempDir, file), []byte(content), 0o644))
+			}
+
+			cfg := &command.Config{
+				Rules:       rules,
+				Ignore:      tc.ignore,
+				IgnoreFiles: tc.ignoreFiles,
+			}
+
+			got, err := cfg.FindProjects(root, tc.dir)
+			if tc.wantErr {
+				require.Error(t, err)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestProject_Profile(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "ks", command.Project{Path: "a", Profiles: []string{"ks", "yaml"}}.Profile())
+	assert.Empty(t, command.Project{Path: "a"}.Profile())
+}
@@ -1406,3 +1406,54 @@ func TestCommand_ApplyEnvFrom_EdgeCases(t *testing.T) {
 		}
 	})
 }
+
+func TestCommand_ExecWithOptions(t *testing.T) {
+	t.Parallel()
+
+	tcs := map[string]struct {
+		want  string
+		env   []execs.EnvVar
+		stdin []byte
+	}{
+		"no options": {
+			want: "command \n",
+		},
+		"added env": {
+			env:  []execs.EnvVar{{Name: "EXTRA", Value: "extra"}},
+			want: "command extra\n",
+		},
+		"env takes precedence over command env": {
+			env:  []execs.EnvVar{{Name: "NAME", Value: "option"}},
+			want: "option \n",
+		},
+		"stdin": {
+			stdin: []byte("stdin"),
+			want:  "command \nstdin",
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			cmd := execs.NewCommand([]string{"PATH=/usr/bin:/bin"})
+			cmd.Command = "sh"
+			cmd.Args = []string{"-c", `echo "$NAME $EXTRA"; cat`}
+			cmd.AddEnvVar(execs.EnvVar{Name: "NAME", Value: "command"})
+
+			exe := execs.NewExecutor(cmd)
+
+			result, err := exe.ExecWithOptions(t.Context(), "", execs.ExecOptions{
+				Stdin: tc.stdin,
+				Env:   tc.env,
+			})
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, result.Stdout)
+
+			// Options do not change the command.
+			result, err = exe.Exec(t.Context(), "")
+			require.NoError(t, err)
+			assert.Equal(t, "command \n", result.Stdout)
+		})
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"log/slog"
 	"os/exec"
+	"slices"
 	"strings"
 	"time"
 
@@ -16,6 +17,15 @@ import (
 	"github.com/macropower/kat/pkg/log"
 )
 
+// ExecOptions contains options for a single execution of a command.
+type ExecOptions struct {
+	// Stdin is written to the command's standard input.
+	Stdin []byte
+	// Env contains environment variables that are added to the command's
+	// environment, taking precedence over the command's own variables.
+	Env []EnvVar
+}
+
 type Executor struct {
 	tracer    trace.Tracer
 	cmd       Command
@@ -35,6 +45,10 @@ func (e Executor) Exec(ctx context.Context, dir string) (*Result, error) {
 }
 
 func (e Executor) ExecWithStdin(ctx context.Context, dir string, stdin []byte) (*Result, error) {
+	return e.ExecWithOptions(ctx, dir, ExecOptions{Stdin: stdin})
+}
+
+func (e Executor) ExecWithOptions(ctx context.Context, dir string, opts ExecOptions) (*Result, error) {
 	ctx, span := e.tracer.Start(ctx, "exec", trace.WithAttributes(
 		attribute.String("command", e.String()),
 		attribute.String("path", dir),
@@ -53,7 +67,9 @@ func (e Executor) ExecWithStdin(ctx context.Context, dir string, stdin []byte) (
 	start := time.Now()
 
 	// Get environment variables for command execution.
-	env := e.cmd.GetEnv()
+	c := e.cmd
+	c.Env = slices.Concat(c.Env, opts.Env)
+	env := c.GetEnv()
 
 	// Combine Args and ExtraArgs to get the full command arguments.
 	allArgs := append([]string{}, e.cmd.Args...)
@@ -64,7 +80,7 @@ func (e Executor) ExecWithStdin(ctx context.Context, dir string, stdin []byte) (
 	cmd := exec.CommandContext(ctx, e.cmd.Command, allArgs...)
 	cmd.Dir = dir
 	cmd.Env = env
-	cmd.Stdin = bytes.NewReader(stdin)
+	cmd.Stdin = bytes.NewReader(opts.Stdin)
 
 	var stdout, stderr bytes.Buffer
 
@@ -0,0 +1,6 @@
+// Package ignore matches file paths against patterns using the gitignore
+// syntax.
+//
+// Patterns can be loaded from ignore files, such as `.gitignore`, which
+// apply to the directory that contains them, like they do in Git.
+package ignore
@@ -0,0 +1,284 @@
+package ignore
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// ErrInvalidPattern is returned when a pattern cannot be compiled.
+var ErrInvalidPattern = errors.New("invalid pattern")
+
+// Matcher matches slash-separated paths against gitignore patterns. Patterns
+// are evaluated in the order they were added, and the last matching pattern
+// decides whether a path is ignored. Paths within an ignored directory are
+// always ignored, even if a later pattern negates them.
+//
+// A nil Matcher does not ignore any paths.
+type Matcher struct {
+	patterns []pattern
+}
+
+// pattern is a single compiled line of an ignore file.
+type pattern struct {
+	re *regexp.Regexp
+	// base is the directory that the pattern applies to. It is empty for the
+	// root directory.
+	base    string
+	negate  bool
+	dirOnly bool
+}
+
+// New creates a new [Matcher] for the given patterns, which apply to the
+// root directory.
+func New(patterns ...string) (*Matcher, error) {
+	m := &Matcher{}
+
+	err := m.Add("", patterns...)
+	if err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// MustNew creates a new [Matcher] and panics if any pattern is invalid.
+func MustNew(patterns ...string) *Matcher {
+	m, err := New(patterns...)
+	if err != nil {
+		panic(err)
+	}
+
+	return m
+}
+
+// Add adds patterns that apply to paths within the base directory. Blank
+// patterns and comments are skipped.
+func (m *Matcher) Add(base string, patterns ...string) error {
+	base = cleanBase(base)
+
+	for _, line := range patterns {
+		p, ok, err := parsePattern(base, line)
+		if err != nil {
+			return err
+		}
+
+		if ok {
+			m.patterns = append(m.patterns, p)
+		}
+	}
+
+	return nil
+}
+
+// AddFile adds the patterns of the named ignore file in fsys. The patterns
+// apply to paths within the directory that contains the file. It does
+// nothing if the file does not exist.
+func (m *Matcher) AddFile(fsys fs.FS, name string) error {
+	b, err := fs.ReadFile(fsys, name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+
+	if err != nil {
+		return fmt.Errorf("read %s: %w", name, err)
+	}
+
+	err = m.Add(path.Dir(name), strings.Split(string(b), "\n")...)
+	if err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+
+	return nil
+}
+
+// Match reports whether the slash-separated path is ignored. isDir must be
+// set if the path is a directory.
+func (m *Matcher) Match(name string, isDir bool) bool {
+	if m == nil || len(m.patterns) == 0 {
+		return false
+	}
+
+	name = path.Clean(name)
+	if name == "." {
+		return false
+	}
+
+	// Paths within an ignored directory are ignored.
+	for i := range len(name) {
+		if name[i] == '/' && m.match(name[:i], true) {
+			return true
+		}
+	}
+
+	return m.match(name, isDir)
+}
+
+// match reports whether the last pattern that matches the path ignores it.
+func (m *Matcher) match(name string, isDir bool) bool {
+	for i := len(m.patterns) - 1; i >= 0; i-- {
+		p := m.patterns[i]
+		if p.dirOnly && !isDir {
+			continue
+		}
+
+		rel, ok := relPath(p.base, name)
+		if !ok {
+			continue
+		}
+
+		if p.re.MatchString(rel) {
+			return !p.negate
+		}
+	}
+
+	return false
+}
+
+// relPath returns the path relative to base, and reports whether the path is
+// within base.
+func relPath(base, name string) (string, bool) {
+	if base == "" {
+		return name, true
+	}
+
+	rel, ok := strings.CutPrefix(name, base+"/")
+
+	return rel, ok && rel != ""
+}
+
+func cleanBase(base string) string {
+	base = path.Clean(base)
+	if base == "." || base == "/" {
+		return ""
+	}
+
+	return strings.TrimPrefix(base, "./")
+}
+
+// parsePattern parses a line of an ignore file. It returns false if the line
+// is blank or a comment.
+func parsePattern(base, line string) (pattern, bool, error) {
+	line = strings.TrimSuffix(line, "\r")
+	line = trimTrailingSpaces(line)
+
+	if line == "" || strings.HasPrefix(line, "#") {
+		return pattern{}, false, nil
+	}
+
+	p := pattern{base: base}
+
+	if strings.HasPrefix(line, "!") {
+		p.negate = true
+		line = line[1:]
+	} else if strings.HasPrefix(line, `\!`) || strings.HasPrefix(line, `\#`) {
+		line = line[1:]
+	}
+
+	if strings.HasSuffix(line, "/") {
+		p.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+
+	if line == "" {
+		return pattern{}, false, nil
+	}
+
+	// Patterns with a separator at the start or in the middle are relative
+	// to the base. Other patterns match at any level below it.
+	anchored := strings.Contains(line, "/")
+	line = strings.TrimPrefix(line, "/")
+
+	expr := globToRegexp(line)
+	if !anchored {
+		expr = "(?:.*/)?" + expr
+	}
+
+	re, err := regexp.Compile("^" + expr + "$")
+	if err != nil {
+		return pattern{}, false, fmt.Errorf("%w %q: %w", ErrInvalidPattern, line, err)
+	}
+
+	p.re = re
+
+	return p, true, nil
+}
+
+// trimTrailingSpaces removes trailing spaces, unless they are escaped with a
+// backslash.
+func trimTrailingSpaces(line string) string {
+	for strings.HasSuffix(line, " ") && !strings.HasSuffix(line, `\ `) {
+		line = line[:len(line)-1]
+	}
+
+	return line
+}
+
+// globToRegexp converts a gitignore glob to a regular expression.
+func globToRegexp(glob string) string {
+	var b strings.Builder
+
+	for i := 0; i < len(glob); i++ {
+		c := glob[i]
+
+		switch c {
+		case '*':
+			if !strings.HasPrefix(glob[i:], "**") {
+				b.WriteString("[^/]*")
+
+				continue
+			}
+
+			leading := i == 0 || glob[i-1] == '/'
+			trailing := i+2 == len(glob) || glob[i+2] == '/'
+
+			switch {
+			case leading && i+2 == len(glob):
+				// "**" or "foo/**" matches everything inside.
+				b.WriteString(".*")
+			case leading && trailing:
+				// "**/" matches zero or more directories.
+				b.WriteString("(?:.*/)?")
+				i++ // Skip the separator.
+			default:
+				// Other consecutive asterisks are regular asterisks.
+				b.WriteString("[^/]*")
+			}
+
+			i++
+
+		case '?':
+			b.WriteString("[^/]")
+
+		case '[':
+			end := strings.IndexByte(glob[i+1:], ']')
+			if end < 0 {
+				b.WriteString(`\[`)
+
+				continue
+			}
+
+			class := glob[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+
+			b.WriteString("[" + strings.ReplaceAll(class, `\`, `\\`) + "]")
+			i += end + 1
+
+		case '\\':
+			if i+1 < len(glob) {
+				i++
+				b.WriteString(regexp.QuoteMeta(string(glob[i])))
+			}
+
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+
+	return b.String()
+}
@@ -0,0 +1,170 @@
+package ignore_test
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/macropower/kat/pkg/ignore"
+)
+
+func TestMatcher_Match(t *testing.T) {
+	t.Parallel()
+
+	tcs := map[string]struct {
+		patterns []string
+		path     string
+		isDir    bool
+		want     bool
+	}{
+		"no patterns": {
+			path: "app.yaml",
+			want: false,
+		},
+		"name at any level": {
+			patterns: []string{"4913"},
+			path:     "charts/app/4913",
+			want:     true,
+		},
+		"extension glob": {
+			patterns: []string{"*.swp"},
+			path:     "templates/.deploy.yaml.swp",
+			want:     true,
+		},
+		"glob does not cross separators": {
+			patterns: []string{"charts/*.tgz"},
+			path:     "charts/sub/app.tgz",
+			want:     false,
+		},
+		"anchored pattern": {
+			patterns: []string{"charts/*.tgz"},
+			path:     "charts/app-1.0.0.tgz",
+			want:     true,
+		},
+		"anchored pattern does not match nested path": {
+			patterns: []string{"/build"},
+			path:     "app/build",
+			want:     false,
+		},
+		"directory pattern matches directory": {
+			patterns: []string{"node_modules/"},
+			path:     "web/node_modules",
+			isDir:    true,
+			want:     true,
+		},
+		"directory pattern does not match file": {
+			patterns: []string{"node_modules/"},
+			path:     "web/node_modules",
+			want:     false,
+		},
+		"files in ignored directory": {
+			patterns: []string{"node_modules/"},
+			path:     "web/node_modules/pkg/index.js",
+			want:     true,
+		},
+		"leading double asterisk": {
+			patterns: []string{"**/tmp/*.yaml"},
+			path:     "a/b/tmp/x.yaml",
+			want:     true,
+		},
+		"middle double asterisk": {
+			patterns: []string{"a/**/x.yaml"},
+			path:     "a/x.yaml",
+			want:     true,
+		},
+		"trailing double asterisk": {
+			patterns: []string{"vendor/**"},
+			path:     "vendor/a/b.yaml",
+			want:     true,
+		},
+		"negation": {
+			patterns: []string{"*.yaml", "!values.yaml"},
+			path:     "values.yaml",
+			want:     false,
+		},
+		"negation cannot re-include files in ignored directory": {
+			patterns: []string{"tmp/", "!tmp/values.yaml"},
+			path:     "tmp/values.yaml",
+			want:     true,
+		},
+		"last pattern wins": {
+			patterns: []string{"!values.yaml", "*.yaml"},
+			path:     "values.yaml",
+			want:     true,
+		},
+		"question mark and class": {
+			patterns: []string{"file-?.[ty]ml"},
+			path:     "file-1.yml",
+			want:     true,
+		},
+		"negated class": {
+			patterns: []string{"[!a]*.yaml"},
+			path:     "app.yaml",
+			want:     false,
+		},
+		"comments and blank lines": {
+			patterns: []string{"# app.yaml", "", "   "},
+			path:     "# app.yaml",
+			want:     false,
+		},
+		"escaped characters": {
+			patterns: []string{`\#notes`, `\!important`},
+			path:     "!important",
+			want:     true,
+		},
+		"trailing spaces": {
+			patterns: []string{"app.yaml  "},
+			path:     "app.yaml",
+			want:     true,
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			m, err := ignore.New(tc.patterns...)
+			require.NoError(t, err)
+
+			assert.Equal(t, tc.want, m.Match(tc.path, tc.isDir))
+		})
+	}
+}
+
+func TestMatcher_AddFile(t *testing.T) {
+	t.Parallel()
+
+	fsys := fstest.MapFS{
+		".gitignore":     {Data: []byte("*.tgz\n")},
+		"app/.gitignore": {Data: []byte("# Local files.\n/local.yaml\n!keep.tgz\n")},
+	}
+
+	m := ignore.MustNew()
+	require.NoError(t, m.AddFile(fsys, ".gitignore"))
+	require.NoError(t, m.AddFile(fsys, "app/.gitignore"))
+	require.NoError(t, m.AddFile(fsys, "missing/.gitignore"))
+
+	assert.True(t, m.Match("chart.tgz", false))
+	assert.True(t, m.Match("app/local.yaml", false))
+	assert.False(t, m.Match("local.yaml", false), "patterns only apply within their directory")
+	assert.False(t, m.Match("app/sub/local.yaml", false), "anchored to the directory of the file")
+	assert.False(t, m.Match("app/keep.tgz", false))
+	assert.True(t, m.Match("other/keep.tgz", false))
+}
+
+func TestMatcher_Nil(t *testing.T) {
+	t.Parallel()
+
+	var m *ignore.Matcher
+
+	assert.False(t, m.Match("app.yaml", false))
+}
+
+func TestNew_InvalidPattern(t *testing.T) {
+	t.Parallel()
+
+	_, err := ignore.New("[z-a]")
+	require.ErrorIs(t, err, ignore.ErrInvalidPattern)
+}
@@ -0,0 +1,51 @@
+package kube
+
+// Severity describes how serious a [Diagnostic] is.
+type Severity string
+
+const (
+	// SeverityError is used for problems that make a resource invalid.
+	SeverityError Severity = "error"
+	// SeverityWarning is used for problems that should be reviewed, but do not
+	// make a resource invalid.
+	SeverityWarning Severity = "warning"
+)
+
+// Diagnostic describes a problem found in a rendered resource.
+type Diagnostic struct {
+	// Severity describes how serious the problem is.
+	Severity Severity `json:"severity"`
+	// Source identifies what reported the problem, e.g. "schema" or the name
+	// of a check.
+	Source string `json:"source"`
+	// Path is the YAML path of the offending field, e.g. `$.spec.replicas`.
+	// It is empty if the problem applies to the whole resource.
+	Path string `json:"path,omitempty"`
+	// Message describes the problem.
+	Message string `json:"message"`
+}
+
+// String returns the diagnostic in the format `path: message`.
+func (d Diagnostic) String() string {
+	if d.Path == "" {
+		return d.Message
+	}
+
+	return d.Path + ": " + d.Message
+}
+
+// CountDiagnostics returns the number of diagnostics with the given severity
+// across all resources.
+func CountDiagnostics(resources []*Resource, severity Severity) int {
+	n := 0
+
+	for _, r := range resources {
+		for _, d := range r.Diagnostics {
+			if d.Severity == severity {
+				n++
+			}
+		}
+	}
+
+	return n
+}
@@ -0,0 +1,97 @@
+package kube
+
+import (
+	"reflect"
+)
+
+// DiffType describes how a resource differs between two renders.
+type DiffType int
+
+const (
+	// DiffUnchanged indicates the resource is identical in both renders.
+	DiffUnchanged DiffType = iota
+	// DiffAdded indicates the resource only exists in the new render.
+	DiffAdded
+	// DiffRemoved indicates the resource only exists in the old render.
+	DiffRemoved
+	// DiffModified indicates the resource exists in both renders, but its
+	// contents differ.
+	DiffModified
+)
+
+// String returns a human-readable name for the [DiffType].
+func (t DiffType) String() string {
+	switch t {
+	case DiffUnchanged:
+		return "unchanged"
+	case DiffAdded:
+		return "added"
+	case DiffRemoved:
+		return "removed"
+	case DiffModified:
+		return "modified"
+	}
+
+	return "unknown"
+}
+
+// ResourceDiff pairs the old and new versions of a resource, matched by
+// their [ResourceMetadata]. Old is nil for added resources, and New is nil
+// for removed resources.
+type ResourceDiff struct {
+	Old      *Resource
+	New      *Resource
+	Metadata ResourceMetadata
+	Type     DiffType
+}
+
+// DiffResources matches resources from two renders by their
+// [ResourceMetadata] and reports how each one changed.
+//
+// Results follow the order of newResources, with removed resources appended
+// in the order of oldResources. If multiple resources share the same
+// metadata, they are matched in the order they appear.
+func DiffResources(oldResources, newResources []*Resource) []ResourceDiff {
+	pending := make(map[ResourceMetadata][]*Resource, len(oldResources))
+	for _, r := range oldResources {
+		md := r.Object.GetMetadata()
+		pending[md] = append(pending[md], r)
+	}
+
+	diffs := make([]ResourceDiff, 0, max(len(oldResources), len(newResources)))
+
+	for _, r := range newResources {
+		md := r.Object.GetMetadata()
+
+		candidates := pending[md]
+		if len(candidates) == 0 {
+			diffs = append(diffs, ResourceDiff{New: r, Metadata: md, Type: DiffAdded})
+
+			continue
+		}
+
+		old := candidates[0]
+		pending[md] = candidates[1:]
+
+		dt := DiffUnchanged
+		if !reflect.DeepEqual(*old.Object, *r.Object) {
+			dt = DiffModified
+		}
+
+		diffs = append(diffs, ResourceDiff{Old: old, New: r, Metadata: md, Type: dt})
+	}
+
+	for _, r := range oldResources {
+		md := r.Object.GetMetadata()
+
+		candidates := pending[md]
+		if len(candidates) == 0 || candidates[0] != r {
+			continue
+		}
+
+		pending[md] = candidates[1:]
+		diffs = append(diffs, ResourceDiff{Old: r, Metadata: md, Type: DiffRemoved})
+	}
+
+	return diffs
+}
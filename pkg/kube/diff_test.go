@@ -0,0 +1,135 @@
+package kube_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/macropower/kat/pkg/kube"
+)
+
+func newResource(apiVersion, kind, namespace, name string, data map[string]any) *kube.Resource {
+	metadata := map[string]any{"name": name}
+	if namespace != "" {
+		metadata["namespace"] = namespace
+	}
+
+	obj := kube.Object{
+		"apiVersion": apiVersion,
+		"kind":       kind,
+		"metadata":   metadata,
+	}
+	if data != nil {
+		obj["data"] = data
+	}
+
+	return &kube.Resource{Object: &obj}
+}
+
+func TestDiffResources(t *testing.T) {
+	t.Parallel()
+
+	type result struct {
+		id   string
+		diff kube.DiffType
+	}
+
+	tcs := map[string]struct {
+		old  []*kube.Resource
+		new  []*kube.Resource
+		want []result
+	}{
+		"identical renders": {
+			old: []*kube.Resource{
+				newResource("v1", "ConfigMap", "default", "a", map[string]any{"k": "v"}),
+			},
+			new: []*kube.Resource{
+				newResource("v1", "ConfigMap", "default", "a", map[string]any{"k": "v"}),
+			},
+			want: []result{
+				{id: "v1/ConfigMap/default/a", diff: kube.DiffUnchanged},
+			},
+		},
+		"modified resource": {
+			old: []*kube.Resource{
+				newResource("v1", "ConfigMap", "default", "a", map[string]any{"k": "v1"}),
+			},
+			new: []*kube.Resource{
+				newResource("v1", "ConfigMap", "default", "a", map[string]any{"k": "v2"}),
+			},
+			want: []result{
+				{id: "v1/ConfigMap/default/a", diff: kube.DiffModified},
+			},
+		},
+		"added and removed resources": {
+			old: []*kube.Resource{
+				newResource("v1", "ConfigMap", "default", "a", nil),
+				newResource("v1", "Namespace", "", "old", nil),
+			},
+			new: []*kube.Resource{
+				newResource("v1", "Namespace", "", "new", nil),
+				newResource("v1", "ConfigMap", "default", "a", nil),
+			},
+			want: []result{
+				{id: "v1/Namespace/new", diff: kube.DiffAdded},
+				{id: "v1/ConfigMap/default/a", diff: kube.DiffUnchanged},
+				{id: "v1/Namespace/old", diff: kube.DiffRemoved},
+			},
+		},
+		"namespace change is a different resource": {
+			old: []*kube.Resource{
+				newResource("v1", "ConfigMap", "one", "a", nil),
+			},
+			new: []*kube.Resource{
+				newResource("v1", "ConfigMap", "two", "a", nil),
+			},
+			want: []result{
+				{id: "v1/ConfigMap/two/a", diff: kube.DiffAdded},
+				{id: "v1/ConfigMap/one/a", diff: kube.DiffRemoved},
+			},
+		},
+		"duplicate resources are matched in order": {
+			old: []*kube.Resource{
+				newResource("v1", "ConfigMap", "default", "a", map[string]any{"k": "1"}),
+				newResource("v1", "ConfigMap", "default", "a", map[string]any{"k": "2"}),
+			},
+			new: []*kube.Resource{
+				newResource("v1", "ConfigMap", "default", "a", map[string]any{"k": "1"}),
+			},
+			want: []result{
+				{id: "v1/ConfigMap/default/a", diff: kube.DiffUnchanged},
+				{id: "v1/ConfigMap/default/a", diff: kube.DiffRemoved},
+			},
+		},
+		"empty renders": {
+			want: []result{},
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			diffs := kube.DiffResources(tc.old, tc.new)
+
+			got := make([]result, 0, len(diffs))
+			for _, d := range diffs {
+				got = append(got, result{id: d.Metadata.String(), diff: d.Type})
+
+				switch d.Type {
+				case kube.DiffAdded:
+					assert.Nil(t, d.Old)
+					assert.NotNil(t, d.New)
+				case kube.DiffRemoved:
+					assert.NotNil(t, d.Old)
+					assert.Nil(t, d.New)
+				case kube.DiffModified, kube.DiffUnchanged:
+					assert.NotNil(t, d.Old)
+					assert.NotNil(t, d.New)
+				}
+			}
+
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
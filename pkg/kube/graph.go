@@ -0,0 +1,177 @@
+package kube
+
+import (
+	"fmt"
+)
+
+// ReferencesDiagnosticSource is the [Diagnostic] source used for dangling
+// references.
+const ReferencesDiagnosticSource = "references"
+
+// Edge is a [Reference] from one resource to another.
+type Edge struct {
+	// From is the referring resource.
+	From *Resource
+	// To is the referenced resource. It is nil if the reference is dangling,
+	// i.e. the referenced resource was not rendered.
+	To *Resource
+
+	Reference
+}
+
+// Dangling reports whether the referenced resource was not rendered.
+func (e Edge) Dangling() bool {
+	return e.To == nil
+}
+
+// Graph contains the references between rendered resources.
+type Graph struct {
+	references map[*Resource][]Edge
+	referrers  map[*Resource][]Edge
+	resources  []*Resource
+}
+
+// NewGraph finds the references between the given resources. References by
+// name are matched by kind, name and namespace. Since rendered resources often
+// do not set a namespace, resources without a namespace match any namespace.
+func NewGraph(resources []*Resource) *Graph {
+	g := &Graph{
+		references: make(map[*Resource][]Edge),
+		referrers:  make(map[*Resource][]Edge),
+		resources:  resources,
+	}
+
+	for _, r := range resources {
+		if r.Object == nil {
+			continue
+		}
+
+		for _, ref := range FindReferences(r.Object) {
+			targets := g.resolve(r, ref)
+			if len(targets) == 0 {
+				if ref.Selector == nil {
+					g.references[r] = append(g.references[r], Edge{From: r, Reference: ref})
+				}
+
+				continue
+			}
+
+			for _, t := range targets {
+				e := Edge{From: r, To: t, Reference: ref}
+				g.references[r] = append(g.references[r], e)
+				g.referrers[t] = append(g.referrers[t], e)
+			}
+		}
+	}
+
+	return g
+}
+
+// References returns the references from the resource, in the order they
+// appear in the resource. Dangling references are included.
+func (g *Graph) References(r *Resource) []Edge {
+	return g.references[r]
+}
+
+// Referrers returns the references to the resource from other resources.
+func (g *Graph) Referrers(r *Resource) []Edge {
+	return g.referrers[r]
+}
+
+// Find returns the resource with the same apiVersion, kind, namespace and
+// name as the object, or nil if there is none.
+func (g *Graph) Find(o *Object) *Resource {
+	if o == nil {
+		return nil
+	}
+
+	for _, r := range g.resources {
+		if r.Object != nil && ObjectEqual(r.Object, o) {
+			return r
+		}
+	}
+
+	return nil
+}
+
+// Dangling returns all references to resources that were not rendered,
+// excluding optional references.
+func (g *Graph) Dangling() []Edge {
+	var edges []Edge
+
+	for _, r := range g.resources {
+		for _, e := range g.references[r] {
+			if e.Dangling() && !e.Optional {
+				edges = append(edges, e)
+			}
+		}
+	}
+
+	return edges
+}
+
+// AddDiagnostics adds a warning [Diagnostic] to each resource with a dangling
+// reference.
+func (g *Graph) AddDiagnostics() {
+	for _, e := range g.Dangling() {
+		e.From.Diagnostics = append(e.From.Diagnostics, Diagnostic{
+			Severity: SeverityWarning,
+			Source:   ReferencesDiagnosticSource,
+			Path:     e.Path,
+			Message:  fmt.Sprintf("references %s, which is not rendered", e.Reference),
+		})
+	}
+}
+
+// resolve returns the resources matching the reference from r.
+func (g *Graph) resolve(r *Resource, ref Reference) []*Resource {
+	namespace := ref.Namespace
+	if namespace == "" && !clusterScopedKinds[ref.Kind] {
+		namespace = r.Object.GetNamespace()
+	}
+
+	var targets []*Resource
+
+	for _, t := range g.resources {
+		if t == r || t.Object == nil {
+			continue
+		}
+
+		if !clusterScopedKinds[ref.Kind] && !namespaceMatches(namespace, t.Object.GetNamespace()) {
+			continue
+		}
+
+		if ref.Selector != nil {
+			labels, ok := podLabels(*t.Object)
+			if ok && labelsMatch(ref.Selector, labels) {
+				targets = append(targets, t)
+			}
+
+			continue
+		}
+
+		if t.Object.GetKind() == ref.Kind && t.Object.GetName() == ref.Name {
+			targets = append(targets, t)
+		}
+	}
+
+	return targets
+}
+
+// namespaceMatches reports whether two namespaces may be the same. An empty
+// namespace matches any namespace.
+func namespaceMatches(a, b string) bool {
+	return a == "" || b == "" || a == b
+}
+
+// labelsMatch reports whether the labels contain all key/value pairs of the
+// selector.
+func labelsMatch(selector, labels map[string]string) bool {
+	for k, v := range selector {
+		if lv, ok := labels[k]; !ok || lv != v {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,137 @@
+package kube_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/macropower/kat/pkg/kube"
+)
+
+const graphYAML = `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: app-config
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+spec:
+  template:
+    metadata:
+      labels:
+        app: app
+    spec:
+      volumes:
+        - name: config
+          configMap:
+            name: app-config
+      containers:
+        - name: app
+          envFrom:
+            - configMapRef:
+                name: missing-config
+            - secretRef:
+                name: optional-secret
+                optional: true
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: app
+spec:
+  selector:
+    app: app
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: unmatched
+spec:
+  selector:
+    app: other
+---
+apiVersion: networking.k8s.io/v1
+kind: Ingress
+metadata:
+  name: app
+spec:
+  defaultBackend:
+    service:
+      name: app
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: app-config
+  namespace: other
+`
+
+func TestGraph(t *testing.T) {
+	t.Parallel()
+
+	resources, err := kube.SplitYAML([]byte(graphYAML))
+	require.NoError(t, err)
+	require.Len(t, resources, 6)
+
+	var (
+		configMap  = resources[0]
+		deployment = resources[1]
+		service    = resources[2]
+		unmatched  = resources[3]
+		ingress    = resources[4]
+		otherCM    = resources[5]
+	)
+
+	g := kube.NewGraph(resources)
+
+	targets := func(edges []kube.Edge) []*kube.Resource {
+		var rs []*kube.Resource
+		for _, e := range edges {
+			rs = append(rs, e.To)
+		}
+
+		return rs
+	}
+
+	sources := func(edges []kube.Edge) []*kube.Resource {
+		var rs []*kube.Resource
+		for _, e := range edges {
+			rs = append(rs, e.From)
+		}
+
+		return rs
+	}
+
+	// Resources without a namespace match any namespace.
+	assert.Equal(t, []*kube.Resource{configMap, otherCM, nil, nil}, targets(g.References(deployment)))
+	assert.Equal(t, []*kube.Resource{deployment}, targets(g.References(service)))
+	assert.Equal(t, []*kube.Resource{service}, targets(g.References(ingress)))
+	assert.Empty(t, g.References(unmatched))
+
+	assert.Equal(t, []*kube.Resource{deployment}, sources(g.Referrers(configMap)))
+	assert.Equal(t, []*kube.Resource{service}, sources(g.Referrers(deployment)))
+	assert.Equal(t, []*kube.Resource{ingress}, sources(g.Referrers(service)))
+
+	assert.Same(t, service, g.Find(service.Object))
+	assert.Nil(t, g.Find(&kube.Object{"kind": "Secret"}))
+
+	dangling := g.Dangling()
+	require.Len(t, dangling, 1)
+	assert.Same(t, deployment, dangling[0].From)
+	assert.Equal(t, "missing-config", dangling[0].Name)
+
+	g.AddDiagnostics()
+
+	assert.Equal(t, []kube.Diagnostic{
+		{
+			Severity: kube.SeverityWarning,
+			Source:   kube.ReferencesDiagnosticSource,
+			Path:     "$.spec.template.spec.containers[0].envFrom[0].configMapRef.name",
+			Message:  "references ConfigMap missing-config, which is not rendered",
+		},
+	}, deployment.Diagnostics)
+	assert.Empty(t, service.Diagnostics)
+}
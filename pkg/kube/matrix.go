@@ -0,0 +1,269 @@
+package kube
+
+import (
+	"fmt"
+	"maps"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+
+	"github.com/goccy/go-yaml"
+)
+
+// simpleFieldKey matches map keys that can be written in a [FieldDifference]
+// path without quoting.
+var simpleFieldKey = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// Matrix compares the resources of several renders of the same project, e.g.
+// one render per environment.
+type Matrix struct {
+	// Names contains the name of each render.
+	Names []string `json:"names"`
+	// Resources contains every resource found in any render, in the order
+	// they first appear.
+	Resources []MatrixResource `json:"resources"`
+}
+
+// MatrixResource describes a resource across the renders of a [Matrix].
+type MatrixResource struct {
+	Metadata ResourceMetadata `json:"metadata"`
+
+	// Resources contains the resource from each render, in the order of
+	// [Matrix.Names]. Entries are nil if the render does not contain the
+	// resource.
+	Resources []*Resource `json:"-"`
+
+	// Present reports whether each render contains the resource, in the
+	// order of [Matrix.Names].
+	Present []bool `json:"present"`
+
+	// Fields contains the fields whose values differ between the renders
+	// that contain the resource.
+	Fields []FieldDifference `json:"fields,omitempty"`
+}
+
+// FieldDifference describes a field whose value differs between renders.
+type FieldDifference struct {
+	// Path is the path of the field, e.g. `.spec.template.spec.containers[0].image`.
+	Path string `json:"path"`
+	// Values contains the value of the field in each render, in the order of
+	// [Matrix.Names]. Values are nil if the field or resource is absent.
+	Values []any `json:"values"`
+}
+
+// matrixKey identifies a resource within a render. Resources that share the
+// same metadata are matched in the order they appear.
+type matrixKey struct {
+	Metadata   ResourceMetadata
+	Occurrence int
+}
+
+// NewMatrix matches the resources of each render by their
+// [ResourceMetadata] and compares their fields. The renders must be in the
+// order of names.
+func NewMatrix(names []string, renders [][]*Resource) *Matrix {
+	m := &Matrix{Names: names}
+
+	index := map[matrixKey]int{}
+
+	for i, resources := range renders {
+		seen := map[ResourceMetadata]int{}
+
+		for _, r := range resources {
+			md := r.Object.GetMetadata()
+			key := matrixKey{Metadata: md, Occurrence: seen[md]}
+			seen[md]++
+
+			row, ok := index[key]
+			if !ok {
+				row = len(m.Resources)
+				index[key] = row
+				m.Resources = append(m.Resources, MatrixResource{
+					Metadata:  md,
+					Resources: make([]*Resource, len(names)),
+					Present:   make([]bool, len(names)),
+				})
+			}
+
+			m.Resources[row].Resources[i] = r
+			m.Resources[row].Present[i] = true
+		}
+	}
+
+	for i := range m.Resources {
+		m.Resources[i].Fields = compareFields(m.Resources[i].Resources)
+	}
+
+	return m
+}
+
+// Consistent reports whether every resource is present in every render with
+// identical fields.
+func (m *Matrix) Consistent() bool {
+	for _, r := range m.Resources {
+		if !r.Consistent() {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Consistent reports whether the resource is present in every render with
+// identical fields.
+func (r MatrixResource) Consistent() bool {
+	return len(r.Fields) == 0 && !slices.Contains(r.Present, false)
+}
+
+// YAML returns a YAML report of the matrix. Each resource lists the renders
+// that contain it, followed by the values of each differing field, keyed by
+// render name. A nil matrix is reported as empty.
+func (m *Matrix) YAML() ([]byte, error) {
+	if m == nil {
+		m = &Matrix{}
+	}
+
+	resources := make(yaml.MapSlice, 0, len(m.Resources))
+
+	for _, r := range m.Resources {
+		present := make(yaml.MapSlice, 0, len(m.Names))
+		for i, name := range m.Names {
+			present = append(present, yaml.MapItem{Key: name, Value: r.Present[i]})
+		}
+
+		item := yaml.MapSlice{{Key: "present", Value: present}}
+
+		if len(r.Fields) > 0 {
+			fields := make(yaml.MapSlice, 0, len(r.Fields))
+
+			for _, f := range r.Fields {
+				values := make(yaml.MapSlice, 0, len(m.Names))
+				for i, name := range m.Names {
+					if r.Present[i] {
+						values = append(values, yaml.MapItem{Key: name, Value: f.Values[i]})
+					}
+				}
+
+				fields = append(fields, yaml.MapItem{Key: f.Path, Value: values})
+			}
+
+			item = append(item, yaml.MapItem{Key: "fields", Value: fields})
+		}
+
+		resources = append(resources, yaml.MapItem{Key: r.Metadata.String(), Value: item})
+	}
+
+	b, err := yaml.Marshal(yaml.MapSlice{
+		{Key: "names", Value: m.Names},
+		{Key: "resources", Value: resources},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("marshal matrix: %w", err)
+	}
+
+	return b, nil
+}
+
+// compareFields returns the fields whose values differ between the non-nil
+// resources, sorted by path.
+func compareFields(resources []*Resource) []FieldDifference {
+	fields := make([]map[string]any, len(resources))
+	paths := map[string]struct{}{}
+	present := 0
+
+	for i, r := range resources {
+		if r == nil {
+			continue
+		}
+
+		present++
+		fields[i] = map[string]any{}
+		flattenFields(fields[i], "", map[string]any(*r.Object))
+
+		for path := range fields[i] {
+			paths[path] = struct{}{}
+		}
+	}
+
+	if present < 2 {
+		return nil
+	}
+
+	var diffs []FieldDifference
+
+	for _, path := range slices.Sorted(maps.Keys(paths)) {
+		var (
+			values  = make([]any, len(resources))
+			first   = -1
+			differs bool
+		)
+
+		for i, f := range fields {
+			if f == nil {
+				continue
+			}
+
+			v, ok := f[path]
+			values[i] = v
+
+			if first == -1 {
+				first = i
+
+				continue
+			}
+
+			// A missing field differs from a field that is explicitly null.
+			firstValue, firstOK := fields[first][path]
+			if ok != firstOK || !reflect.DeepEqual(firstValue, v) {
+				differs = true
+			}
+		}
+
+		if differs {
+			diffs = append(diffs, FieldDifference{Path: path, Values: values})
+		}
+	}
+
+	return diffs
+}
+
+// flattenFields adds the leaf values of v to fields, keyed by their paths.
+// Empty maps and lists are treated as leaf values.
+func flattenFields(fields map[string]any, path string, v any) {
+	switch val := v.(type) {
+	case map[string]any:
+		if len(val) == 0 {
+			fields[path] = val
+
+			return
+		}
+
+		for k, child := range val {
+			flattenFields(fields, fieldPath(path, k), child)
+		}
+
+	case []any:
+		if len(val) == 0 {
+			fields[path] = val
+
+			return
+		}
+
+		for i, child := range val {
+			flattenFields(fields, path+"["+strconv.Itoa(i)+"]", child)
+		}
+
+	default:
+		fields[path] = val
+	}
+}
+
+// fieldPath appends the map key to the path, quoting it if needed.
+func fieldPath(path, key string) string {
+	if simpleFieldKey.MatchString(key) {
+		return path + "." + key
+	}
+
+	return path + "[" + strconv.Quote(key) + "]"
+}
@@ -0,0 +1,160 @@
+package kube_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/macropower/kat/pkg/kube"
+)
+
+func TestNewMatrix(t *testing.T) {
+	t.Parallel()
+
+	type row struct {
+		id      string
+		present []bool
+		fields  []kube.FieldDifference
+	}
+
+	tcs := map[string]struct {
+		renders    [][]*kube.Resource
+		want       []row
+		consistent bool
+	}{
+		"identical renders": {
+			renders: [][]*kube.Resource{
+				{newResource("v1", "ConfigMap", "default", "a", map[string]any{"k": "v"})},
+				{newResource("v1", "ConfigMap", "default", "a", map[string]any{"k": "v"})},
+			},
+			want: []row{
+				{id: "v1/ConfigMap/default/a", present: []bool{true, true}},
+			},
+			consistent: true,
+		},
+		"differing fields": {
+			renders: [][]*kube.Resource{
+				{newResource("v1", "ConfigMap", "default", "a", map[string]any{"k": "v1", "same": "x"})},
+				{newResource("v1", "ConfigMap", "default", "a", map[string]any{"k": "v2", "same": "x", "extra": "y"})},
+			},
+			want: []row{
+				{
+					id:      "v1/ConfigMap/default/a",
+					present: []bool{true, true},
+					fields: []kube.FieldDifference{
+						{Path: ".data.extra", Values: []any{nil, "y"}},
+						{Path: ".data.k", Values: []any{"v1", "v2"}},
+					},
+				},
+			},
+		},
+		"quoted and indexed paths": {
+			renders: [][]*kube.Resource{
+				{newResource("v1", "ConfigMap", "", "a", map[string]any{"a.b": []any{"x"}})},
+				{newResource("v1", "ConfigMap", "", "a", map[string]any{"a.b": []any{"y"}})},
+			},
+			want: []row{
+				{
+					id:      "v1/ConfigMap/a",
+					present: []bool{true, true},
+					fields: []kube.FieldDifference{
+						{Path: `.data["a.b"][0]`, Values: []any{"x", "y"}},
+					},
+				},
+			},
+		},
+		"resource missing from one render": {
+			renders: [][]*kube.Resource{
+				{newResource("v1", "ConfigMap", "default", "a", nil)},
+				{
+					newResource("v1", "ConfigMap", "default", "a", nil),
+					newResource("v1", "Secret", "default", "b", nil),
+				},
+				{newResource("v1", "ConfigMap", "default", "a", nil)},
+			},
+			want: []row{
+				{id: "v1/ConfigMap/default/a", present: []bool{true, true, true}},
+				{id: "v1/Secret/default/b", present: []bool{false, true, false}},
+			},
+		},
+		"duplicate resources": {
+			renders: [][]*kube.Resource{
+				{
+					newResource("v1", "ConfigMap", "default", "a", map[string]any{"k": "1"}),
+					newResource("v1", "ConfigMap", "default", "a", map[string]any{"k": "2"}),
+				},
+				{newResource("v1", "ConfigMap", "default", "a", map[string]any{"k": "1"})},
+			},
+			want: []row{
+				{id: "v1/ConfigMap/default/a", present: []bool{true, true}},
+				{id: "v1/ConfigMap/default/a", present: []bool{true, false}},
+			},
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			names := make([]string, len(tc.renders))
+			for i := range names {
+				names[i] = string(rune('a' + i))
+			}
+
+			m := kube.NewMatrix(names, tc.renders)
+
+			got := make([]row, 0, len(m.Resources))
+			for _, r := range m.Resources {
+				got = append(got, row{id: r.Metadata.String(), present: r.Present, fields: r.Fields})
+			}
+
+			assert.Equal(t, tc.want, got)
+			assert.Equal(t, tc.consistent, m.Consistent())
+		})
+	}
+}
+
+func TestMatrix_YAML(t *testing.T) {
+	t.Parallel()
+
+	m := kube.NewMatrix([]string{"dev", "prod"}, [][]*kube.Resource{
+		{newResource("v1", "ConfigMap", "default", "a", map[string]any{"k": "v1"})},
+		{
+			newResource("v1", "ConfigMap", "default", "a", map[string]any{"k": "v2"}),
+			newResource("v1", "Secret", "default", "b", nil),
+		},
+	})
+
+	got, err := m.YAML()
+	require.NoError(t, err)
+
+	want := `names:
+- dev
+- prod
+resources:
+  v1/ConfigMap/default/a:
+    present:
+      dev: true
+      prod: true
+    fields:
+      .data.k:
+        dev: v1
+        prod: v2
+  v1/Secret/default/b:
+    present:
+      dev: false
+      prod: true
+`
+	assert.Equal(t, want, string(got))
+}
+
+func TestMatrix_YAML_Nil(t *testing.T) {
+	t.Parallel()
+
+	var m *kube.Matrix
+
+	got, err := m.YAML()
+	require.NoError(t, err)
+	assert.Equal(t, "names: []\nresources: {}\n", string(got))
+}
@@ -11,6 +11,18 @@ type ResourceMetadata struct {
 	Name       string `json:"name"`
 }
 
+// String returns a unique identifier for the resource in the format
+// `apiVersion/kind/namespace/name`. The namespace is omitted for
+// cluster-scoped resources.
+func (m ResourceMetadata) String() string {
+	id := m.APIVersion + "/" + m.Kind + "/"
+	if m.Namespace != "" {
+		id += m.Namespace + "/"
+	}
+
+	return id + m.Name
+}
+
 type Object map[string]any
 
 func (o Object) GetMetadata() ResourceMetadata {
@@ -99,6 +111,24 @@ func (o Object) GetName() string {
 	return "<empty>"
 }
 
+// GetLabels returns the labels of the object.
+// Labels with non-string values are ignored.
+func (o Object) GetLabels() map[string]string {
+	labels := map[string]string{}
+
+	if metadata, ok := o["metadata"].(map[string]any); ok {
+		if l, ok := metadata["labels"].(map[string]any); ok {
+			for k, v := range l {
+				if s, ok := v.(string); ok {
+					labels[k] = s
+				}
+			}
+		}
+	}
+
+	return labels
+}
+
 func (o Object) GetNamespacedName() string {
 	ns := o.GetNamespace()
 	name := o.GetName()
@@ -0,0 +1,62 @@
+package kube
+
+import (
+	"slices"
+)
+
+// applyOrder contains kinds in the order they should be applied, so that
+// resources are created before the resources that depend on them.
+//
+// It is based on Helm's install order, but applies CustomResourceDefinitions
+// directly after Namespaces, since custom resources may be used by any kind.
+var applyOrder = []string{
+	"Namespace",
+	"CustomResourceDefinition",
+	"NetworkPolicy",
+	"ResourceQuota",
+	"LimitRange",
+	"PodSecurityPolicy",
+	"PodDisruptionBudget",
+	"ServiceAccount",
+	"Secret",
+	"SecretList",
+	"ConfigMap",
+	"StorageClass",
+	"PersistentVolume",
+	"PersistentVolumeClaim",
+	"ClusterRole",
+	"ClusterRoleList",
+	"ClusterRoleBinding",
+	"ClusterRoleBindingList",
+	"Role",
+	"RoleList",
+	"RoleBinding",
+	"RoleBindingList",
+	"Service",
+	"DaemonSet",
+	"Pod",
+	"ReplicationController",
+	"ReplicaSet",
+	"Deployment",
+	"HorizontalPodAutoscaler",
+	"StatefulSet",
+	"Job",
+	"CronJob",
+	"IngressClass",
+	"Ingress",
+	"APIService",
+	"MutatingWebhookConfiguration",
+	"ValidatingWebhookConfiguration",
+}
+
+// ApplyOrder returns the position of the kind in the order that resources
+// should be applied. Kinds that are not built into Kubernetes, e.g. custom
+// resources, are applied last.
+func ApplyOrder(kind string) int {
+	i := slices.Index(applyOrder, kind)
+	if i < 0 {
+		return len(applyOrder)
+	}
+
+	return i
+}
@@ -0,0 +1,32 @@
+package kube_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/macropower/kat/pkg/kube"
+)
+
+func TestApplyOrder(t *testing.T) {
+	t.Parallel()
+
+	ordered := []string{
+		"Namespace",
+		"CustomResourceDefinition",
+		"ServiceAccount",
+		"ConfigMap",
+		"ClusterRole",
+		"Service",
+		"Deployment",
+		"Ingress",
+		"Certificate",
+	}
+
+	for i := 1; i < len(ordered); i++ {
+		assert.Less(t, kube.ApplyOrder(ordered[i-1]), kube.ApplyOrder(ordered[i]),
+			"%s should be applied before %s", ordered[i-1], ordered[i])
+	}
+
+	assert.Equal(t, kube.ApplyOrder("Certificate"), kube.ApplyOrder("Issuer"))
+}
@@ -0,0 +1,331 @@
+package kube
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Reference describes a reference from a resource to another resource, either
+// by name or by a label selector.
+type Reference struct {
+	// Selector selects resources by the labels of their pods. It is nil for
+	// references by name.
+	Selector map[string]string
+	// Path is the YAML path of the field that holds the reference, e.g.
+	// `$.spec.template.spec.volumes[0].configMap.name`.
+	Path string
+	// Kind is the kind of the referenced resource.
+	Kind string
+	// Namespace is the namespace of the referenced resource. It is empty for
+	// cluster-scoped resources, and for resources in the same namespace as
+	// the referrer.
+	Namespace string
+	// Name is the name of the referenced resource. It is empty for references
+	// by selector.
+	Name string
+	// Optional is true if the referenced resource does not need to be
+	// rendered, e.g. for optional ConfigMap volumes, or for resources that
+	// are usually provided by the cluster.
+	Optional bool
+}
+
+// String returns a human-readable description of the referenced resource.
+func (r Reference) String() string {
+	if r.Selector != nil {
+		return fmt.Sprintf("pods with labels %s", formatLabels(r.Selector))
+	}
+
+	if r.Namespace != "" {
+		return fmt.Sprintf("%s %s/%s", r.Kind, r.Namespace, r.Name)
+	}
+
+	return r.Kind + " " + r.Name
+}
+
+// clusterScopedKinds contains the kinds that may be referenced, and that are
+// not namespaced.
+var clusterScopedKinds = map[string]bool{
+	"ClusterRole":      true,
+	"PersistentVolume": true,
+}
+
+// defaultClusterRoles contains ClusterRoles that are provided by Kubernetes.
+var defaultClusterRoles = map[string]bool{
+	"cluster-admin": true,
+	"admin":         true,
+	"edit":          true,
+	"view":          true,
+}
+
+// FindReferences returns the references from the object to other resources.
+// It finds references of:
+//   - Pods and workloads to ConfigMaps, Secrets, PersistentVolumeClaims and
+//     ServiceAccounts, via volumes, environment variables and the pod spec.
+//   - Services to workloads, via their selector.
+//   - Ingresses to Services and TLS Secrets.
+//   - RoleBindings and ClusterRoleBindings to Roles, ClusterRoles and
+//     ServiceAccounts.
+//   - Any object to its owners, via ownerReferences.
+func FindReferences(o *Object) []Reference {
+	f := &referenceFinder{}
+
+	f.ownerReferences(*o)
+
+	if spec, path, ok := podSpec(*o); ok {
+		f.podSpec(spec, path)
+	}
+
+	switch o.GetKind() {
+	case "Service":
+		f.service(*o)
+	case "Ingress":
+		f.ingress(*o)
+	case "RoleBinding", "ClusterRoleBinding":
+		f.roleBinding(*o)
+	}
+
+	return f.refs
+}
+
+// referenceFinder collects references from an object.
+type referenceFinder struct {
+	refs []Reference
+}
+
+// add adds a reference by name, if the kind and name are set.
+func (f *referenceFinder) add(path, kind string, name any, optional bool) {
+	n, ok := name.(string)
+	if !ok || n == "" || kind == "" {
+		return
+	}
+
+	f.refs = append(f.refs, Reference{
+		Path:     path,
+		Kind:     kind,
+		Name:     n,
+		Optional: optional,
+	})
+}
+
+func (f *referenceFinder) ownerReferences(o Object) {
+	for i, owner := range getSlice(o, "metadata", "ownerReferences") {
+		kind, _ := getString(owner, "kind")
+		f.add(fmt.Sprintf("$.metadata.ownerReferences[%d].name", i), kind, get(owner, "name"), false)
+	}
+}
+
+func (f *referenceFinder) podSpec(spec any, path string) {
+	for i, vol := range getSlice(spec, "volumes") {
+		volPath := fmt.Sprintf("%s.volumes[%d]", path, i)
+
+		f.add(volPath+".configMap.name", "ConfigMap",
+			get(vol, "configMap", "name"), getBool(vol, "configMap", "optional"))
+		f.add(volPath+".secret.secretName", "Secret",
+			get(vol, "secret", "secretName"), getBool(vol, "secret", "optional"))
+		f.add(volPath+".persistentVolumeClaim.claimName", "PersistentVolumeClaim",
+			get(vol, "persistentVolumeClaim", "claimName"), false)
+
+		for j, src := range getSlice(vol, "projected", "sources") {
+			srcPath := fmt.Sprintf("%s.projected.sources[%d]", volPath, j)
+
+			f.add(srcPath+".configMap.name", "ConfigMap",
+				get(src, "configMap", "name"), getBool(src, "configMap", "optional"))
+			f.add(srcPath+".secret.name", "Secret",
+				get(src, "secret", "name"), getBool(src, "secret", "optional"))
+		}
+	}
+
+	for _, field := range []string{"initContainers", "containers"} {
+		for i, c := range getSlice(spec, field) {
+			cPath := fmt.Sprintf("%s.%s[%d]", path, field, i)
+
+			for j, envFrom := range getSlice(c, "envFrom") {
+				efPath := fmt.Sprintf("%s.envFrom[%d]", cPath, j)
+
+				f.add(efPath+".configMapRef.name", "ConfigMap",
+					get(envFrom, "configMapRef", "name"), getBool(envFrom, "configMapRef", "optional"))
+				f.add(efPath+".secretRef.name", "Secret",
+					get(envFrom, "secretRef", "name"), getBool(envFrom, "secretRef", "optional"))
+			}
+
+			for j, env := range getSlice(c, "env") {
+				envPath := fmt.Sprintf("%s.env[%d].valueFrom", cPath, j)
+
+				f.add(envPath+".configMapKeyRef.name", "ConfigMap",
+					get(env, "valueFrom", "configMapKeyRef", "name"),
+					getBool(env, "valueFrom", "configMapKeyRef", "optional"))
+				f.add(envPath+".secretKeyRef.name", "Secret",
+					get(env, "valueFrom", "secretKeyRef", "name"),
+					getBool(env, "valueFrom", "secretKeyRef", "optional"))
+			}
+		}
+	}
+
+	for i, ips := range getSlice(spec, "imagePullSecrets") {
+		f.add(fmt.Sprintf("%s.imagePullSecrets[%d].name", path, i), "Secret", get(ips, "name"), false)
+	}
+
+	sa, _ := getString(spec, "serviceAccountName")
+	// The default ServiceAccount is created by Kubernetes.
+	f.add(path+".serviceAccountName", "ServiceAccount", sa, sa == "default")
+}
+
+func (f *referenceFinder) service(o Object) {
+	selector := map[string]string{}
+
+	sel, _ := get(o, "spec", "selector").(map[string]any)
+	for k, v := range sel {
+		if s, ok := v.(string); ok {
+			selector[k] = s
+		}
+	}
+
+	if len(selector) == 0 {
+		return
+	}
+
+	f.refs = append(f.refs, Reference{
+		Path:     "$.spec.selector",
+		Selector: selector,
+		// Pods may be created by other tools.
+		Optional: true,
+	})
+}
+
+func (f *referenceFinder) ingress(o Object) {
+	f.add("$.spec.defaultBackend.service.name", "Service",
+		get(o, "spec", "defaultBackend", "service", "name"), false)
+	f.add("$.spec.backend.serviceName", "Service",
+		get(o, "spec", "backend", "serviceName"), false)
+
+	for i, rule := range getSlice(o, "spec", "rules") {
+		for j, p := range getSlice(rule, "http", "paths") {
+			pPath := fmt.Sprintf("$.spec.rules[%d].http.paths[%d].backend", i, j)
+
+			f.add(pPath+".service.name", "Service", get(p, "backend", "service", "name"), false)
+			f.add(pPath+".serviceName", "Service", get(p, "backend", "serviceName"), false)
+		}
+	}
+
+	for i, tls := range getSlice(o, "spec", "tls") {
+		f.add(fmt.Sprintf("$.spec.tls[%d].secretName", i), "Secret", get(tls, "secretName"), false)
+	}
+}
+
+func (f *referenceFinder) roleBinding(o Object) {
+	kind, _ := getString(o, "roleRef", "kind")
+	name, _ := getString(o, "roleRef", "name")
+
+	optional := kind == "ClusterRole" && (defaultClusterRoles[name] || strings.HasPrefix(name, "system:"))
+	f.add("$.roleRef.name", kind, name, optional)
+
+	for i, subject := range getSlice(o, "subjects") {
+		if k, _ := getString(subject, "kind"); k != "ServiceAccount" {
+			continue
+		}
+
+		name, _ := getString(subject, "name")
+		if name == "" {
+			continue
+		}
+
+		namespace, _ := getString(subject, "namespace")
+
+		f.refs = append(f.refs, Reference{
+			Path:      fmt.Sprintf("$.subjects[%d].name", i),
+			Kind:      "ServiceAccount",
+			Namespace: namespace,
+			Name:      name,
+		})
+	}
+}
+
+// podSpec returns the pod spec of a Pod or workload, and its YAML path.
+func podSpec(o Object) (any, string, bool) {
+	switch o.GetKind() {
+	case "Pod":
+		return get(o, "spec"), "$.spec", true
+	case "Deployment", "StatefulSet", "DaemonSet", "ReplicaSet", "ReplicationController", "Job":
+		return get(o, "spec", "template", "spec"), "$.spec.template.spec", true
+	case "CronJob":
+		return get(o, "spec", "jobTemplate", "spec", "template", "spec"), "$.spec.jobTemplate.spec.template.spec", true
+	}
+
+	return nil, "", false
+}
+
+// podLabels returns the labels of the pods of a Pod or workload.
+func podLabels(o Object) (map[string]string, bool) {
+	var labels any
+
+	switch o.GetKind() {
+	case "Pod":
+		labels = get(o, "metadata", "labels")
+	case "Deployment", "StatefulSet", "DaemonSet", "ReplicaSet", "ReplicationController", "Job":
+		labels = get(o, "spec", "template", "metadata", "labels")
+	case "CronJob":
+		labels = get(o, "spec", "jobTemplate", "spec", "template", "metadata", "labels")
+	default:
+		return nil, false
+	}
+
+	result := map[string]string{}
+
+	l, _ := labels.(map[string]any)
+	for k, v := range l {
+		if s, ok := v.(string); ok {
+			result[k] = s
+		}
+	}
+
+	return result, true
+}
+
+// get returns the value at the given path of map keys, or nil if it does not
+// exist.
+func get(v any, path ...string) any {
+	for _, key := range path {
+		switch m := v.(type) {
+		case map[string]any:
+			v = m[key]
+		case Object:
+			v = m[key]
+		default:
+			return nil
+		}
+	}
+
+	return v
+}
+
+func getString(v any, path ...string) (string, bool) {
+	s, ok := get(v, path...).(string)
+
+	return s, ok
+}
+
+func getBool(v any, path ...string) bool {
+	b, _ := get(v, path...).(bool)
+
+	return b
+}
+
+func getSlice(v any, path ...string) []any {
+	s, _ := get(v, path...).([]any)
+
+	return s
+}
+
+// formatLabels formats labels as a comma-separated list of `key=value`
+// pairs, sorted by key.
+func formatLabels(labels map[string]string) string {
+	pairs := make([]string, 0, len(labels))
+	for k, v := range labels {
+		pairs = append(pairs, k+"="+v)
+	}
+
+	slices.Sort(pairs)
+
+	return strings.Join(pairs, ",")
+}
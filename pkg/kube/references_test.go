@@ -0,0 +1,203 @@
+package kube_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/macropower/kat/pkg/kube"
+)
+
+func TestFindReferences(t *testing.T) {
+	t.Parallel()
+
+	tcs := map[string]struct {
+		input string
+		want  []kube.Reference
+	}{
+		"deployment": {
+			input: `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+spec:
+  template:
+    spec:
+      serviceAccountName: app
+      imagePullSecrets:
+        - name: registry
+      volumes:
+        - name: config
+          configMap:
+            name: app-config
+        - name: certs
+          secret:
+            secretName: app-certs
+            optional: true
+        - name: data
+          persistentVolumeClaim:
+            claimName: app-data
+      containers:
+        - name: app
+          envFrom:
+            - configMapRef:
+                name: app-env
+            - secretRef:
+                name: app-secret-env
+          env:
+            - name: PASSWORD
+              valueFrom:
+                secretKeyRef:
+                  name: app-password
+                  key: password
+`,
+			want: []kube.Reference{
+				{Path: "$.spec.template.spec.volumes[0].configMap.name", Kind: "ConfigMap", Name: "app-config"},
+				{Path: "$.spec.template.spec.volumes[1].secret.secretName", Kind: "Secret", Name: "app-certs", Optional: true},
+				{Path: "$.spec.template.spec.volumes[2].persistentVolumeClaim.claimName", Kind: "PersistentVolumeClaim", Name: "app-data"},
+				{Path: "$.spec.template.spec.containers[0].envFrom[0].configMapRef.name", Kind: "ConfigMap", Name: "app-env"},
+				{Path: "$.spec.template.spec.containers[0].envFrom[1].secretRef.name", Kind: "Secret", Name: "app-secret-env"},
+				{Path: "$.spec.template.spec.containers[0].env[0].valueFrom.secretKeyRef.name", Kind: "Secret", Name: "app-password"},
+				{Path: "$.spec.template.spec.imagePullSecrets[0].name", Kind: "Secret", Name: "registry"},
+				{Path: "$.spec.template.spec.serviceAccountName", Kind: "ServiceAccount", Name: "app"},
+			},
+		},
+		"cronjob with default service account": {
+			input: `apiVersion: batch/v1
+kind: CronJob
+metadata:
+  name: job
+spec:
+  jobTemplate:
+    spec:
+      template:
+        spec:
+          serviceAccountName: default
+`,
+			want: []kube.Reference{
+				{Path: "$.spec.jobTemplate.spec.template.spec.serviceAccountName", Kind: "ServiceAccount", Name: "default", Optional: true},
+			},
+		},
+		"service": {
+			input: `apiVersion: v1
+kind: Service
+metadata:
+  name: app
+spec:
+  selector:
+    app: app
+`,
+			want: []kube.Reference{
+				{Path: "$.spec.selector", Selector: map[string]string{"app": "app"}, Optional: true},
+			},
+		},
+		"service without selector": {
+			input: `apiVersion: v1
+kind: Service
+metadata:
+  name: external
+spec:
+  type: ExternalName
+`,
+		},
+		"ingress": {
+			input: `apiVersion: networking.k8s.io/v1
+kind: Ingress
+metadata:
+  name: app
+spec:
+  tls:
+    - secretName: app-tls
+  rules:
+    - http:
+        paths:
+          - path: /
+            backend:
+              service:
+                name: app
+`,
+			want: []kube.Reference{
+				{Path: "$.spec.rules[0].http.paths[0].backend.service.name", Kind: "Service", Name: "app"},
+				{Path: "$.spec.tls[0].secretName", Kind: "Secret", Name: "app-tls"},
+			},
+		},
+		"role binding": {
+			input: `apiVersion: rbac.authorization.k8s.io/v1
+kind: RoleBinding
+metadata:
+  name: app
+roleRef:
+  kind: ClusterRole
+  name: view
+subjects:
+  - kind: ServiceAccount
+    name: app
+    namespace: other
+  - kind: User
+    name: jane
+`,
+			want: []kube.Reference{
+				{Path: "$.roleRef.name", Kind: "ClusterRole", Name: "view", Optional: true},
+				{Path: "$.subjects[0].name", Kind: "ServiceAccount", Namespace: "other", Name: "app"},
+			},
+		},
+		"owner references": {
+			input: `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: app
+  ownerReferences:
+    - apiVersion: apps/v1
+      kind: Deployment
+      name: app
+`,
+			want: []kube.Reference{
+				{Path: "$.metadata.ownerReferences[0].name", Kind: "Deployment", Name: "app"},
+			},
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			resources, err := kube.SplitYAML([]byte(tc.input))
+			require.NoError(t, err)
+			require.Len(t, resources, 1)
+
+			got := kube.FindReferences(resources[0].Object)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestReference_String(t *testing.T) {
+	t.Parallel()
+
+	tcs := map[string]struct {
+		ref  kube.Reference
+		want string
+	}{
+		"by name": {
+			ref:  kube.Reference{Kind: "ConfigMap", Name: "app"},
+			want: "ConfigMap app",
+		},
+		"with namespace": {
+			ref:  kube.Reference{Kind: "ServiceAccount", Namespace: "other", Name: "app"},
+			want: "ServiceAccount other/app",
+		},
+		"by selector": {
+			ref:  kube.Reference{Selector: map[string]string{"b": "2", "a": "1"}},
+			want: "pods with labels a=1,b=2",
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tc.want, tc.ref.String())
+		})
+	}
+}
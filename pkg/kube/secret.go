@@ -0,0 +1,258 @@
+package kube
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/goccy/go-yaml/ast"
+	"github.com/goccy/go-yaml/parser"
+)
+
+// SecretMask replaces masked secret values.
+const SecretMask = "********"
+
+// SecretKind identifies a kind of resource that contains secret values.
+type SecretKind struct {
+	// Group is the API group of the kind, e.g. "bitnami.com", or "core" for
+	// the core group. If empty, the kind matches in any group.
+	Group string `json:"group,omitempty" jsonschema:"title=Group"`
+	// Kind is the kind of the resource, e.g. "SealedSecret".
+	Kind string `json:"kind" jsonschema:"title=Kind"`
+	// Fields contains the fields whose values are secret.
+	Fields []SecretField `json:"fields" jsonschema:"title=Fields"`
+}
+
+// SecretField is a map of secret values in a resource.
+type SecretField struct {
+	// Path is the dot-separated path of the map, e.g. "spec.encryptedData".
+	// Every value in the map is secret.
+	Path string `json:"path" jsonschema:"title=Path"`
+	// Base64 is true if the values are base64-encoded, and should be decoded
+	// when they are revealed.
+	Base64 bool `json:"base64,omitempty" jsonschema:"title=Base64 Encoded"`
+}
+
+// DefaultSecretKinds contains the kinds that are always treated as secret.
+var DefaultSecretKinds = []SecretKind{
+	{
+		Group: "core",
+		Kind:  "Secret",
+		Fields: []SecretField{
+			{Path: "data", Base64: true},
+			{Path: "stringData"},
+		},
+	},
+}
+
+// Matches reports whether the object is of this kind.
+func (k SecretKind) Matches(o *Object) bool {
+	if o == nil || o.GetKind() != k.Kind {
+		return false
+	}
+
+	return k.Group == "" || o.GetGroup() == k.Group
+}
+
+// SecretMasker hides the values of secret fields in YAML documents.
+type SecretMasker struct {
+	kinds []SecretKind
+}
+
+// NewSecretMasker creates a new [SecretMasker] for the given kinds, in
+// addition to [DefaultSecretKinds].
+func NewSecretMasker(kinds ...SecretKind) *SecretMasker {
+	all := make([]SecretKind, 0, len(DefaultSecretKinds)+len(kinds))
+	all = append(all, DefaultSecretKinds...)
+	all = append(all, kinds...)
+
+	return &SecretMasker{kinds: all}
+}
+
+// fields returns the secret fields of the object.
+func (s *SecretMasker) fields(o *Object) []SecretField {
+	var fields []SecretField
+
+	for _, k := range s.kinds {
+		if k.Matches(o) {
+			fields = append(fields, k.Fields...)
+		}
+	}
+
+	return fields
+}
+
+// IsSecret reports whether the object contains secret fields.
+func (s *SecretMasker) IsSecret(o *Object) bool {
+	return len(s.fields(o)) > 0
+}
+
+// Mask returns the YAML document of the object, with the value of every key
+// in its secret fields replaced by [SecretMask]. Keys remain visible.
+func (s *SecretMasker) Mask(o *Object, content string) (string, error) {
+	return s.replace(o, content, func(SecretField, *ast.MappingValueNode) (string, bool) {
+		return `"` + SecretMask + `"`, true
+	})
+}
+
+// Reveal returns the YAML document of the object, with base64-encoded
+// secret values decoded in place. Values that do not decode to text are
+// left encoded.
+func (s *SecretMasker) Reveal(o *Object, content string) (string, error) {
+	return s.replace(o, content, func(f SecretField, mv *ast.MappingValueNode) (string, bool) {
+		if !f.Base64 {
+			return "", false
+		}
+
+		encoded, ok := scalarValue(mv.Value)
+		if !ok {
+			return "", false
+		}
+
+		decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+		if err != nil || !utf8.Valid(decoded) {
+			return "", false
+		}
+
+		return formatScalar(string(decoded), mv.Key.GetToken().Position.Column), true
+	})
+}
+
+// replace replaces the values in the secret fields of the object's YAML
+// document. The replace function returns the YAML of the new value of each
+// key, or false to keep the current value.
+func (s *SecretMasker) replace(
+	o *Object,
+	content string,
+	replace func(f SecretField, mv *ast.MappingValueNode) (string, bool),
+) (string, error) {
+	fields := s.fields(o)
+	if len(fields) == 0 {
+		return content, nil
+	}
+
+	file, err := parser.ParseBytes([]byte(content), parser.ParseComments)
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrInvalidYAML, err)
+	}
+
+	if len(file.Docs) != 1 {
+		return "", fmt.Errorf("%w: expected one document, got %d", ErrInvalidYAML, len(file.Docs))
+	}
+
+	for _, f := range fields {
+		m, ok := mappingAt(file.Docs[0].Body, strings.Split(f.Path, "."))
+		if !ok {
+			continue
+		}
+
+		for _, mv := range m.Values {
+			value, ok := replace(f, mv)
+			if !ok {
+				continue
+			}
+
+			node, err := parseNode(value)
+			if err != nil {
+				return "", err
+			}
+
+			err = mv.Replace(node)
+			if err != nil {
+				return "", fmt.Errorf("replace %s.%s: %w", f.Path, mv.Key.GetToken().Value, err)
+			}
+		}
+	}
+
+	return file.String(), nil
+}
+
+// mappingAt returns the mapping at the path of keys, if there is one.
+func mappingAt(node ast.Node, path []string) (*ast.MappingNode, bool) {
+	for _, key := range path {
+		m, ok := node.(*ast.MappingNode)
+		if !ok {
+			return nil, false
+		}
+
+		node = nil
+
+		for _, mv := range m.Values {
+			if mv.Key.GetToken().Value == key {
+				node = mv.Value
+
+				break
+			}
+		}
+	}
+
+	m, ok := node.(*ast.MappingNode)
+
+	return m, ok
+}
+
+// scalarValue returns the string value of a scalar node.
+func scalarValue(node ast.Node) (string, bool) {
+	switch n := node.(type) {
+	case *ast.StringNode:
+		return n.Value, true
+	case *ast.LiteralNode:
+		return n.Value.Value, true
+	}
+
+	return "", false
+}
+
+// formatScalar formats a string as a YAML scalar, for a value whose key
+// starts at the given column. Multi-line strings are formatted as literal
+// blocks, indented below the key.
+func formatScalar(s string, column int) string {
+	// Literal blocks cannot start with spaces or keep multiple trailing
+	// newlines without indicators, so such strings are quoted instead.
+	if !strings.Contains(strings.TrimSuffix(s, "\n"), "\n") ||
+		strings.HasPrefix(s, " ") || strings.HasSuffix(s, "\n\n") {
+		b, _ := json.Marshal(s) //nolint:errchkjson // Strings are always valid JSON.
+
+		return string(b)
+	}
+
+	header := "|-"
+	if strings.HasSuffix(s, "\n") {
+		header = "|"
+	}
+
+	indent := strings.Repeat(" ", max(0, column-1)+2)
+
+	var b strings.Builder
+
+	b.WriteString(header)
+
+	for line := range strings.SplitSeq(strings.TrimSuffix(s, "\n"), "\n") {
+		b.WriteString("\n")
+
+		if line != "" {
+			b.WriteString(indent + line)
+		}
+	}
+
+	b.WriteString("\n")
+
+	return b.String()
+}
+
+// parseNode parses the YAML of a single value.
+func parseNode(value string) (ast.Node, error) {
+	file, err := parser.ParseBytes([]byte(value), 0)
+	if err != nil {
+		return nil, fmt.Errorf("parse value: %w", err)
+	}
+
+	if len(file.Docs) != 1 || file.Docs[0].Body == nil {
+		return nil, errors.New("parse value: expected one value")
+	}
+
+	return file.Docs[0].Body, nil
+}
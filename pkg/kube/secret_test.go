@@ -0,0 +1,123 @@
+package kube_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/macropower/kat/pkg/kube"
+)
+
+func TestSecretMasker(t *testing.T) {
+	t.Parallel()
+
+	const secret = `apiVersion: v1
+kind: Secret
+metadata:
+  name: test # comment
+data:
+  password: cGFzc3dvcmQ=
+  config: bGluZTEKbGluZTIK
+  binary: //79
+stringData:
+  token: abc
+type: Opaque
+`
+
+	const sealedSecret = `apiVersion: bitnami.com/v1alpha1
+kind: SealedSecret
+metadata:
+  name: test
+spec:
+  encryptedData:
+    password: AgBy3i4OJSWK+PiTySYZZA9rO43cGDEq
+`
+
+	sealedSecretKind := kube.SecretKind{
+		Group:  "bitnami.com",
+		Kind:   "SealedSecret",
+		Fields: []kube.SecretField{{Path: "spec.encryptedData"}},
+	}
+
+	tcs := map[string]struct {
+		content    string
+		kinds      []kube.SecretKind
+		wantMask   string
+		wantReveal string
+		wantSecret bool
+	}{
+		"secret": {
+			content:    secret,
+			wantSecret: true,
+			wantMask: `apiVersion: v1
+kind: Secret
+metadata:
+  name: test # comment
+data:
+  password: "********"
+  config: "********"
+  binary: "********"
+stringData:
+  token: "********"
+type: Opaque
+`,
+			wantReveal: `apiVersion: v1
+kind: Secret
+metadata:
+  name: test # comment
+data:
+  password: "password"
+  config: |
+    line1
+    line2
+  binary: //79
+stringData:
+  token: abc
+type: Opaque
+`,
+		},
+		"configured kind": {
+			content:    sealedSecret,
+			kinds:      []kube.SecretKind{sealedSecretKind},
+			wantSecret: true,
+			wantMask: `apiVersion: bitnami.com/v1alpha1
+kind: SealedSecret
+metadata:
+  name: test
+spec:
+  encryptedData:
+    password: "********"
+`,
+			wantReveal: sealedSecret,
+		},
+		"unconfigured kind": {
+			content:    sealedSecret,
+			wantMask:   sealedSecret,
+			wantReveal: sealedSecret,
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			resources, err := kube.SplitYAML([]byte(tc.content))
+			require.NoError(t, err)
+			require.Len(t, resources, 1)
+
+			obj := resources[0].Object
+			masker := kube.NewSecretMasker(tc.kinds...)
+
+			assert.Equal(t, tc.wantSecret, masker.IsSecret(obj))
+
+			masked, err := masker.Mask(obj, tc.content)
+			require.NoError(t, err)
+			assert.Equal(t, tc.wantMask, masked)
+
+			revealed, err := masker.Reveal(obj, tc.content)
+			require.NoError(t, err)
+			assert.Equal(t, tc.wantReveal, revealed)
+		})
+	}
+}
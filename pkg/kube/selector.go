@@ -0,0 +1,205 @@
+package kube
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// ErrInvalidSelector is returned when a label selector cannot be parsed.
+var ErrInvalidSelector = errors.New("invalid label selector")
+
+var (
+	setRequirementRe = regexp.MustCompile(`^(\S+)\s+(in|notin)\s*\((.*)\)$`)
+	labelKeyRe       = regexp.MustCompile(`^[A-Za-z0-9]([-A-Za-z0-9_./]*[A-Za-z0-9])?$`)
+)
+
+type selectorOperator int
+
+const (
+	opEquals selectorOperator = iota
+	opNotEquals
+	opIn
+	opNotIn
+	opExists
+	opDoesNotExist
+)
+
+type requirement struct {
+	key      string
+	values   []string
+	operator selectorOperator
+}
+
+func (r requirement) matches(labels map[string]string) bool {
+	v, ok := labels[r.key]
+
+	switch r.operator {
+	case opEquals:
+		return ok && v == r.values[0]
+	case opNotEquals:
+		return !ok || v != r.values[0]
+	case opIn:
+		return ok && slices.Contains(r.values, v)
+	case opNotIn:
+		return !ok || !slices.Contains(r.values, v)
+	case opExists:
+		return ok
+	case opDoesNotExist:
+		return !ok
+	}
+
+	return false
+}
+
+// Selector matches objects by their labels, using the same syntax as
+// `kubectl --selector`.
+//
+// Requirements are separated by commas, and all of them must match:
+//   - `key=value` or `key==value`: the label is set to value
+//   - `key!=value`: the label is not set to value, or is not set
+//   - `key in (a,b)`: the label is set to one of the values
+//   - `key notin (a,b)`: the label is not set to any of the values, or is not set
+//   - `key`: the label is set
+//   - `!key`: the label is not set
+//
+// The zero value matches everything.
+type Selector struct {
+	raw          string
+	requirements []requirement
+}
+
+// ParseSelector parses a label selector.
+func ParseSelector(s string) (Selector, error) {
+	sel := Selector{raw: strings.TrimSpace(s)}
+	if sel.raw == "" {
+		return sel, nil
+	}
+
+	for _, term := range splitSelector(sel.raw) {
+		req, err := parseRequirement(strings.TrimSpace(term))
+		if err != nil {
+			return Selector{}, fmt.Errorf("%w %q: %w", ErrInvalidSelector, s, err)
+		}
+
+		sel.requirements = append(sel.requirements, req)
+	}
+
+	return sel, nil
+}
+
+// Matches reports whether the labels satisfy all requirements.
+func (s Selector) Matches(labels map[string]string) bool {
+	for _, r := range s.requirements {
+		if !r.matches(labels) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// MatchesObject reports whether the labels of the object satisfy all
+// requirements.
+func (s Selector) MatchesObject(o *Object) bool {
+	if len(s.requirements) == 0 {
+		return true
+	}
+
+	return s.Matches(o.GetLabels())
+}
+
+// Empty reports whether the selector has no requirements.
+func (s Selector) Empty() bool {
+	return len(s.requirements) == 0
+}
+
+// String returns the selector as it was parsed.
+func (s Selector) String() string {
+	return s.raw
+}
+
+// splitSelector splits a selector on commas that are not inside a set.
+func splitSelector(s string) []string {
+	var (
+		terms []string
+		depth int
+		start int
+	)
+
+	for i, c := range s {
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				terms = append(terms, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+
+	return append(terms, s[start:])
+}
+
+func parseRequirement(term string) (requirement, error) {
+	if term == "" {
+		return requirement{}, errors.New("empty requirement")
+	}
+
+	if m := setRequirementRe.FindStringSubmatch(term); m != nil {
+		req := requirement{key: m[1], operator: opIn}
+		if m[2] == "notin" {
+			req.operator = opNotIn
+		}
+
+		for v := range strings.SplitSeq(m[3], ",") {
+			req.values = append(req.values, strings.TrimSpace(v))
+		}
+
+		return req, validateKey(req.key)
+	}
+
+	if key, ok := strings.CutPrefix(term, "!"); ok {
+		key = strings.TrimSpace(key)
+
+		return requirement{key: key, operator: opDoesNotExist}, validateKey(key)
+	}
+
+	for _, sep := range []struct {
+		token    string
+		operator selectorOperator
+	}{
+		{token: "!=", operator: opNotEquals},
+		{token: "==", operator: opEquals},
+		{token: "=", operator: opEquals},
+	} {
+		key, value, ok := strings.Cut(term, sep.token)
+		if !ok {
+			continue
+		}
+
+		key = strings.TrimSpace(key)
+		req := requirement{
+			key:      key,
+			operator: sep.operator,
+			values:   []string{strings.TrimSpace(value)},
+		}
+
+		return req, validateKey(key)
+	}
+
+	return requirement{key: term, operator: opExists}, validateKey(term)
+}
+
+func validateKey(key string) error {
+	if !labelKeyRe.MatchString(key) {
+		return fmt.Errorf("invalid label key %q", key)
+	}
+
+	return nil
+}
@@ -0,0 +1,110 @@
+package kube_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/macropower/kat/pkg/kube"
+)
+
+func TestParseSelector(t *testing.T) {
+	t.Parallel()
+
+	labels := map[string]string{
+		"app.kubernetes.io/name": "web",
+		"tier":                   "frontend",
+	}
+
+	tcs := map[string]struct {
+		selector string
+		want     bool
+		wantErr  bool
+	}{
+		"empty": {
+			selector: "",
+			want:     true,
+		},
+		"equals": {
+			selector: "tier=frontend",
+			want:     true,
+		},
+		"double equals": {
+			selector: "tier==backend",
+			want:     false,
+		},
+		"not equals": {
+			selector: "tier!=backend",
+			want:     true,
+		},
+		"not equals missing label": {
+			selector: "env!=prod",
+			want:     true,
+		},
+		"in": {
+			selector: "tier in (frontend, backend)",
+			want:     true,
+		},
+		"notin": {
+			selector: "tier notin (frontend)",
+			want:     false,
+		},
+		"exists": {
+			selector: "app.kubernetes.io/name",
+			want:     true,
+		},
+		"does not exist": {
+			selector: "!env",
+			want:     true,
+		},
+		"multiple requirements": {
+			selector: "tier in (frontend,backend), app.kubernetes.io/name=web, !env",
+			want:     true,
+		},
+		"one requirement fails": {
+			selector: "tier=frontend,env=prod",
+			want:     false,
+		},
+		"invalid key": {
+			selector: "tier=frontend,=prod",
+			wantErr:  true,
+		},
+		"trailing comma": {
+			selector: "tier=frontend,",
+			wantErr:  true,
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			sel, err := kube.ParseSelector(tc.selector)
+			if tc.wantErr {
+				require.ErrorIs(t, err, kube.ErrInvalidSelector)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, sel.Matches(labels))
+		})
+	}
+}
+
+func TestSelector_MatchesObject(t *testing.T) {
+	t.Parallel()
+
+	sel, err := kube.ParseSelector("app=web")
+	require.NoError(t, err)
+
+	assert.True(t, sel.MatchesObject(&kube.Object{
+		"metadata": map[string]any{
+			"labels": map[string]any{"app": "web"},
+		},
+	}))
+	assert.False(t, sel.MatchesObject(&kube.Object{
+		"metadata": map[string]any{"name": "test"},
+	}))
+}
@@ -21,6 +21,7 @@ package kube
 import (
 	"errors"
 	"fmt"
+	"strings"
 
 	"go.jacobcolvin.com/niceyaml"
 	"go.jacobcolvin.com/niceyaml/lexers"
@@ -34,6 +35,34 @@ var (
 type Resource struct {
 	Object *Object
 	Source *niceyaml.Source
+
+	// Diagnostics contains problems found in the resource after it was
+	// rendered, e.g. by schema validation.
+	Diagnostics []Diagnostic
+}
+
+// Content returns the YAML content of the resource, without any leading
+// document header or blank lines, so that the position of the resource in
+// the render does not affect comparisons.
+func (r *Resource) Content() string {
+	content := strings.TrimLeft(r.Source.Content(), "\n")
+	content = strings.TrimLeft(strings.TrimPrefix(content, "---\n"), "\n")
+	if content != "" && !strings.HasSuffix(content, "\n") {
+		content += "\n"
+	}
+
+	return content
+}
+
+// JoinResources joins the content of the resources into a single
+// multi-document YAML string.
+func JoinResources(resources []*Resource) string {
+	docs := make([]string, 0, len(resources))
+	for _, r := range resources {
+		docs = append(docs, r.Content())
+	}
+
+	return strings.Join(docs, "---\n")
 }
 
 // SplitYAML splits a YAML file into unstructured objects. Returns list of all unstructured objects
@@ -75,3 +75,42 @@ func TestSplitYAML_PartialResults(t *testing.T) {
 	require.ErrorIs(t, err, kube.ErrInvalidYAML)
 	assert.Len(t, objs, 1)
 }
+
+func TestJoinResources(t *testing.T) {
+	t.Parallel()
+
+	tcs := map[string]struct {
+		input string
+		want  string
+	}{
+		"single resource": {
+			input: "kind: A\n",
+			want:  "kind: A\n",
+		},
+		"multiple resources": {
+			input: "kind: A\n---\nkind: B\n---\nkind: C\n",
+			want:  "kind: A\n---\nkind: B\n---\nkind: C\n",
+		},
+		"leading document header": {
+			input: "---\nkind: A\n---\n\nkind: B",
+			want:  "kind: A\n---\nkind: B\n",
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			objs, err := kube.SplitYAML([]byte(tc.input))
+			require.NoError(t, err)
+
+			assert.Equal(t, tc.want, kube.JoinResources(objs))
+		})
+	}
+
+	t.Run("no resources", func(t *testing.T) {
+		t.Parallel()
+
+		assert.Empty(t, kube.JoinResources(nil))
+	})
+}
@@ -19,7 +19,21 @@ REQUIRED workflow:
 4. EXCEPTION: If you modify a single resource without changing its metadata, you are allowed to repeatedly call 'get_resource' to retrieve the updated YAML
 
 IMPORTANT: When making edits to a single resource, you MUST call 'get_resource' on the affected resource BOTH BEFORE AND AFTER you make changes.
+
+Additional tools:
+- Use 'list_resources' with 'kind', 'namespace', or 'labelSelector' to narrow down large renders, instead of reading every resource
+- Use 'get_render_output' to see the full stdout, stderr, and error of the last render, e.g. to find out why a render failed
+- Use 'diff_resources' after making changes to see which resources were added, removed, or modified by the last render
+- Use 'run_plugin' to run one of the plugins configured for the current profile
+
+Rendered resources are also available as MCP resources with URIs like 'kat://<path>/<group>/<kind>/<namespace>/<name>'. Subscribe to them to be notified when a render changes them.
+
+Resources may include 'diagnostics' describing problems found by schema validation or policy checks. Diagnostics with severity 'error' should be fixed.
 `
+
+	// defaultMaxLength is the default maximum length of large text fields
+	// returned by tools.
+	defaultMaxLength = 20000
 )
 
 func newResourceMetadataSchema() *jsonschema.Schema {
@@ -48,6 +62,74 @@ func newResourceMetadataSchema() *jsonschema.Schema {
 	}
 }
 
+func newResourceSummarySchema() *jsonschema.Schema {
+	schema := newResourceMetadataSchema()
+	schema.Description = "Metadata of a Kubernetes resource, and any problems found in it."
+	schema.Properties["diagnostics"] = newDiagnosticsSchema()
+
+	return schema
+}
+
+func newDiagnosticsSchema() *jsonschema.Schema {
+	return &jsonschema.Schema{
+		Type:        "array",
+		Description: "Problems found in the resource by schema validation or policy checks.",
+		Items: &jsonschema.Schema{
+			Type: "object",
+			Properties: map[string]*jsonschema.Schema{
+				"severity": {
+					Type:        "string",
+					Description: "How serious the problem is.",
+					Enum:        []any{"error", "warning"},
+				},
+				"source": {
+					Type:        "string",
+					Description: `What reported the problem, either "schema" or the name of a check.`,
+				},
+				"path": {
+					Type:        "string",
+					Description: "The YAML path of the offending field. Empty if the problem applies to the whole resource.",
+				},
+				"message": {
+					Type:        "string",
+					Description: "Description of the problem.",
+				},
+			},
+			Required: []string{"severity", "source", "message"},
+		},
+	}
+}
+
+func newWarningsSchema() *jsonschema.Schema {
+	return &jsonschema.Schema{
+		Type:        "array",
+		Description: "Warnings printed to stderr by the manifest generator, e.g. deprecations or skipped values. These are easy to miss, and often point to broken values.",
+		Items: &jsonschema.Schema{
+			Type: "object",
+			Properties: map[string]*jsonschema.Schema{
+				"location": {
+					Type:        "string",
+					Description: "The source location reported by the manifest generator, e.g. coalesce.go:298.",
+				},
+				"message": {
+					Type:        "string",
+					Description: "Description of the warning.",
+				},
+			},
+			Required: []string{"message"},
+		},
+	}
+}
+
+// maxLengthOrDefault returns maxLength, or [defaultMaxLength] if it is not positive.
+func maxLengthOrDefault(maxLength int) int {
+	if maxLength <= 0 {
+		return defaultMaxLength
+	}
+
+	return maxLength
+}
+
 // truncateString truncates a string to maxLen characters with ellipsis if needed.
 func truncateString(str string, maxLen int) string {
 	if str == "" {
@@ -0,0 +1,201 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"maps"
+	"net/url"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"github.com/macropower/kat/pkg/kube"
+	"github.com/macropower/kat/pkg/log"
+)
+
+const (
+	resourceScheme   = "kat"
+	resourceMIMEType = "application/yaml"
+
+	// coreGroup is used in resource URIs for resources in the core API group.
+	coreGroup = "core"
+	// clusterNamespace is used in resource URIs for cluster-scoped resources.
+	clusterNamespace = "_"
+)
+
+// resourceChanges describes how the published MCP resources changed after a
+// render.
+type resourceChanges struct {
+	set     []*mcp.Resource // Added or updated resources.
+	removed []string
+	updated []string
+}
+
+// resourceURI returns the MCP resource URI of a rendered resource, in the
+// format `kat://<path>/<group>/<kind>/<namespace>/<name>`.
+//
+// Resources in the core API group use the group "core", and cluster-scoped
+// resources use the namespace "_".
+func resourceURI(path string, md kube.ResourceMetadata) string {
+	group := coreGroup
+	if g, _, ok := strings.Cut(md.APIVersion, "/"); ok {
+		group = g
+	}
+
+	namespace := md.Namespace
+	if namespace == "" {
+		namespace = clusterNamespace
+	}
+
+	segments := []string{group, md.Kind, namespace, md.Name}
+	for i, seg := range segments {
+		segments[i] = url.PathEscape(seg)
+	}
+
+	return resourceScheme + "://" + filepath.ToSlash(filepath.Clean(path)) + "/" + strings.Join(segments, "/")
+}
+
+// newMCPResource creates the MCP resource description of a rendered resource.
+func newMCPResource(uri string, r *kube.Resource) *mcp.Resource {
+	md := r.Object.GetMetadata()
+
+	res := &mcp.Resource{
+		URI:      uri,
+		Name:     md.String(),
+		Title:    fmt.Sprintf("%s %s", md.Kind, r.Object.GetNamespacedName()),
+		MIMEType: resourceMIMEType,
+		Size:     int64(len(r.Content())),
+	}
+
+	errs := kube.CountDiagnostics([]*kube.Resource{r}, kube.SeverityError)
+	warns := kube.CountDiagnostics([]*kube.Resource{r}, kube.SeverityWarning)
+
+	if errs+warns > 0 {
+		res.Description = fmt.Sprintf("%d errors, %d warnings.", errs, warns)
+	}
+
+	return res
+}
+
+// updatePublished replaces the published resources with the given rendered
+// resources, and reports what changed. Renders without any resources are
+// ignored, so that the last rendered resources remain available after a
+// failed render.
+//
+// The caller must hold sess.mu.
+func (sess *session) updatePublished(resources []*kube.Resource) resourceChanges {
+	var changes resourceChanges
+
+	if len(resources) == 0 {
+		return changes
+	}
+
+	published := make(map[string]*kube.Resource, len(resources))
+
+	for _, r := range resources {
+		if r.Object == nil {
+			continue
+		}
+
+		uri := resourceURI(sess.path, r.Object.GetMetadata())
+		if _, ok := published[uri]; ok {
+			// The first resource wins if multiple resources share the same URI.
+			continue
+		}
+
+		published[uri] = r
+
+		old, ok := sess.published[uri]
+
+		switch {
+		case !ok:
+			changes.set = append(changes.set, newMCPResource(uri, r))
+		case old.Content() != r.Content():
+			// Replace the resource to update its size and description.
+			changes.set = append(changes.set, newMCPResource(uri, r))
+			changes.updated = append(changes.updated, uri)
+		}
+	}
+
+	for uri := range sess.published {
+		if _, ok := published[uri]; !ok {
+			changes.removed = append(changes.removed, uri)
+		}
+	}
+
+	sess.published = published
+
+	return changes
+}
+
+// publishChanges applies changes to the MCP server, which notifies clients
+// that the resource list changed, and notifies subscribers of each updated or
+// removed resource.
+func (sess *session) publishChanges(ctx context.Context, changes resourceChanges) {
+	if len(changes.removed) > 0 {
+		sess.server.RemoveResources(changes.removed...)
+	}
+
+	for _, r := range changes.set {
+		sess.server.AddResource(r, sess.handleReadResource)
+	}
+
+	for _, uri := range append(changes.updated, changes.removed...) {
+		err := sess.server.ResourceUpdated(ctx, &mcp.ResourceUpdatedNotificationParams{URI: uri})
+		if err != nil {
+			log.WithContext(ctx).WarnContext(ctx, "notify resource updated",
+				slog.String("uri", uri),
+				slog.Any("error", err),
+			)
+		}
+	}
+}
+
+// handleReadResource handles resources/read requests for rendered resources.
+func (sess *session) handleReadResource(_ context.Context, req *mcp.ReadResourceRequest) (*mcp.ReadResourceResult, error) {
+	sess.mu.RLock()
+	r, ok := sess.published[req.Params.URI]
+	sess.mu.RUnlock()
+
+	if !ok {
+		return nil, mcp.ResourceNotFoundError(req.Params.URI)
+	}
+
+	return &mcp.ReadResourceResult{
+		Contents: []*mcp.ResourceContents{
+			{
+				URI:      req.Params.URI,
+				MIMEType: resourceMIMEType,
+				Text:     r.Content(),
+			},
+		},
+	}, nil
+}
+
+// handleSubscribe handles resources/subscribe requests. Subscriptions are
+// tracked by the MCP server, so this only checks that the resource exists.
+func (s *Server) handleSubscribe(_ context.Context, req *mcp.SubscribeRequest) error {
+	s.sessionsMu.Lock()
+	sessions := append(slices.Collect(maps.Values(s.sessions)), s.shared)
+	s.sessionsMu.Unlock()
+
+	for _, sess := range sessions {
+		sess.mu.RLock()
+		_, ok := sess.published[req.Params.URI]
+		sess.mu.RUnlock()
+
+		if ok {
+			return nil
+		}
+	}
+
+	return mcp.ResourceNotFoundError(req.Params.URI)
+}
+
+// handleUnsubscribe handles resources/unsubscribe requests.
+func (s *Server) handleUnsubscribe(context.Context, *mcp.UnsubscribeRequest) error {
+	return nil
+}
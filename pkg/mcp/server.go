@@ -4,7 +4,10 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"maps"
 	"net/http"
+	"path/filepath"
+	"slices"
 	"sync"
 	"time"
 
@@ -16,65 +19,128 @@ import (
 	"github.com/macropower/kat/pkg/command"
 	"github.com/macropower/kat/pkg/kube"
 	"github.com/macropower/kat/pkg/log"
+	"github.com/macropower/kat/pkg/profile"
 )
 
 // ExecutionState tracks the current state of command execution.
 type ExecutionState struct {
 	Output command.Output
+
+	// Previous contains the resources from the last render that produced
+	// any, before the render that produced Output.
+	Previous []*kube.Resource
 }
 
 type CommandRunner interface {
 	Subscribe(ch chan<- command.Event)
 	ConfigureContext(ctx context.Context, opts ...command.RunnerOpt) error
 	RunContext(ctx context.Context) command.Output
+	RunPluginContext(ctx context.Context, name string, opts ...command.PluginRunOpt) command.Output
+	GetCurrentProfile() (string, *profile.Profile)
 	SendEvent(evt command.Event)
 }
 
+// PooledRunner is a [CommandRunner] created by a [RunnerFactory]. The
+// [Server] owns pooled runners: it starts watching them for changes, and
+// closes them once they expire.
+type PooledRunner interface {
+	CommandRunner
+	RunOnEvent()
+	Close()
+}
+
+// RunnerFactory creates a [PooledRunner] for the given path.
+type RunnerFactory func(path string) (PooledRunner, error)
+
+// DefaultIdleTimeout is the default duration after which pooled runners that
+// have not been used by any tool call are closed.
+const DefaultIdleTimeout = 15 * time.Minute
+
 // Server implements the MCP server for kat.
 type Server struct {
-	runner         CommandRunner
-	tracer         trace.Tracer
-	completionCond *sync.Cond
-	server         *mcp.Server
-	eventCh        chan command.Event
-	address        string
-	currentPath    string
-	state          ExecutionState
-	mu             sync.RWMutex
+	tracer      trace.Tracer
+	server      *mcp.Server
+	newRunner   RunnerFactory
+	shared      *session            // Session for the runner shared with the UI.
+	sessions    map[string]*session // Sessions by path, other than the shared session.
+	done        chan struct{}
+	address     string
+	sharedPath  string // Path of the shared session, guarded by sessionsMu.
+	idleTimeout time.Duration
+	sessionsMu  sync.Mutex
+}
+
+// ServerOpt is a functional option for configuring a [Server].
+type ServerOpt func(*Server)
+
+// WithRunnerFactory sets a factory used to create a separate runner for each
+// path that tool calls operate on, other than the initial path. This allows
+// tool calls for different paths to run concurrently, without reconfiguring
+// the shared runner.
+//
+// Without a factory, the shared runner is reconfigured whenever a tool call
+// operates on a different path.
+func WithRunnerFactory(f RunnerFactory) ServerOpt {
+	return func(s *Server) {
+		s.newRunner = f
+	}
+}
+
+// WithIdleTimeout sets the duration after which runners created by the
+// [RunnerFactory] are closed if no tool call has used them. A duration of
+// zero disables expiry. Defaults to [DefaultIdleTimeout].
+func WithIdleTimeout(d time.Duration) ServerOpt {
+	return func(s *Server) {
+		s.idleTimeout = d
+	}
 }
 
 // NewServer creates a new [Server].
-func NewServer(address string, runner CommandRunner, initialPath string) (*Server, error) {
+func NewServer(address string, runner CommandRunner, initialPath string, opts ...ServerOpt) (*Server, error) {
 	impl := &mcp.Implementation{
 		Name:    name,
 		Title:   name,
 		Version: version.GetVersion(),
 	}
 
-	opts := &mcp.ServerOptions{
-		Instructions: instructions,
-	}
-
 	s := &Server{
 		address:     address,
-		server:      mcp.NewServer(impl, opts),
-		runner:      runner,
-		eventCh:     make(chan command.Event, 100),
-		currentPath: initialPath,
-		state:       ExecutionState{},
+		sessions:    make(map[string]*session),
+		done:        make(chan struct{}),
+		sharedPath:  filepath.Clean(initialPath),
+		idleTimeout: DefaultIdleTimeout,
 		tracer:      otel.Tracer("mcp-server"),
 	}
+	for _, opt := range opts {
+		opt(s)
+	}
 
-	s.completionCond = sync.NewCond(&s.mu)
+	s.server = mcp.NewServer(impl, &mcp.ServerOptions{
+		Instructions:       instructions,
+		SubscribeHandler:   s.handleSubscribe,
+		UnsubscribeHandler: s.handleUnsubscribe,
+		Capabilities: &mcp.ServerCapabilities{
+			Logging: &mcp.LoggingCapabilities{},
+			// Advertise resources before the first render completes.
+			Resources: &mcp.ResourceCapabilities{ListChanged: true},
+		},
+	})
 
-	runner.Subscribe(s.eventCh)
+	// Start event processing.
+	s.shared = newSession(s.server, s.tracer, s.sharedPath)
+	s.shared.onPathChange = s.setSharedPath
+	s.shared.attach(runner)
 
 	// Register tools with the MCP server.
 	mcp.AddTool(s.server, newToolListResources(), withTracing(s.tracer, s.handleListResources))
 	mcp.AddTool(s.server, newToolGetResource(), withTracing(s.tracer, s.handleGetResource))
+	mcp.AddTool(s.server, newToolGetRenderOutput(), withTracing(s.tracer, s.handleGetRenderOutput))
+	mcp.AddTool(s.server, newToolDiffResources(), withTracing(s.tracer, s.handleDiffResources))
+	mcp.AddTool(s.server, newToolRunPlugin(), withTracing(s.tracer, s.handleRunPlugin))
 
-	// Start event processing.
-	go s.processEvents()
+	if s.newRunner != nil && s.idleTimeout > 0 {
+		go s.expireSessions()
+	}
 
 	return s, nil
 }
@@ -85,27 +151,33 @@ func (s *Server) handleListResources(
 	_ *mcp.CallToolRequest,
 	params ListResourcesParams,
 ) (*mcp.CallToolResult, ListResourcesResult, error) {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-
-	err := s.reload(ctx, params.Path)
+	sess, err := s.acquire(ctx, params.Path)
 	if err != nil {
-		return nil, ListResourcesResult{}, fmt.Errorf("reconfigure runner: %w", err)
+		return nil, ListResourcesResult{}, fmt.Errorf("prepare runner: %w", err)
 	}
+	defer sess.mu.Unlock()
 
 	result := ListResourcesResult{
-		Resources: []kube.ResourceMetadata{},
+		Resources: []ResourceSummary{},
+	}
+
+	filter, err := newResourceFilter(params)
+	if err != nil {
+		result.Error = fmt.Sprintf("INVALID INPUT ERROR: %v", err)
+		result.Message = result.Error
+
+		return createListResourcesResult(result), result, nil
 	}
 
-	if s.state.Output.Error != nil {
-		result.Error = s.state.Output.Error.Error()
+	if sess.state.Output.Error != nil {
+		result.Error = sess.state.Output.Error.Error()
 	}
 
-	populateResultFromOutput(&result, s.state.Output)
+	populateResultFromOutput(&result, sess.state.Output, filter)
 
-	s.runner.SendEvent(command.NewEventListResources(ctx))
+	sess.runner.SendEvent(command.NewEventListResources(ctx))
 
-	result.Message = fmt.Sprintf("Found %d Kubernetes resources.", result.ResourceCount)
+	result.Message = formatListResourcesMessage(result, len(sess.state.Output.Resources), filter)
 
 	return createListResourcesResult(result), result, nil
 }
@@ -116,33 +188,32 @@ func (s *Server) handleGetResource(
 	_ *mcp.CallToolRequest,
 	params GetResourceParams,
 ) (*mcp.CallToolResult, GetResourceResult, error) {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-
-	err := s.reload(ctx, params.Path)
+	sess, err := s.acquire(ctx, params.Path)
 	if err != nil {
-		return nil, GetResourceResult{}, fmt.Errorf("reconfigure runner: %w", err)
+		return nil, GetResourceResult{}, fmt.Errorf("prepare runner: %w", err)
 	}
+	defer sess.mu.Unlock()
 
 	result := GetResourceResult{
 		Found: false,
 	}
 
-	if s.state.Output.Error != nil {
-		result.Error = s.state.Output.Error.Error()
+	if sess.state.Output.Error != nil {
+		result.Error = sess.state.Output.Error.Error()
 	}
 
 	// Search for the requested resource.
-	resource := findResource(s.state.Output.Resources, params)
+	resource := findResource(sess.state.Output.Resources, params)
 	if resource != nil {
 		result.Found = true
 		result.Resource = &ResourceDetails{
-			Metadata: resource.Object.GetMetadata(),
-			YAML:     resource.Source.Content(),
+			Metadata:    resource.Object.GetMetadata(),
+			YAML:        resource.Source.Content(),
+			Diagnostics: resource.Diagnostics,
 		}
 
 		// Send event to open the resource in the pager.
-		s.runner.SendEvent(command.NewEventOpenResource(ctx, *resource))
+		sess.runner.SendEvent(command.NewEventOpenResource(ctx, *resource))
 	}
 
 	result.Message = formatResourceMessage(result, params)
@@ -150,12 +221,103 @@ func (s *Server) handleGetResource(
 	return createGetResourceResult(result), result, nil
 }
 
+// handleGetRenderOutput handles the get_render_output tool call.
+func (s *Server) handleGetRenderOutput(
+	ctx context.Context,
+	_ *mcp.CallToolRequest,
+	params GetRenderOutputParams,
+) (*mcp.CallToolResult, GetRenderOutputResult, error) {
+	sess, err := s.acquire(ctx, params.Path)
+	if err != nil {
+		return nil, GetRenderOutputResult{}, fmt.Errorf("prepare runner: %w", err)
+	}
+	defer sess.mu.Unlock()
+
+	result := newGetRenderOutputResult(sess.state.Output, params.MaxLength)
+
+	return createGetRenderOutputResult(result), result, nil
+}
+
+// handleDiffResources handles the diff_resources tool call.
+func (s *Server) handleDiffResources(
+	ctx context.Context,
+	_ *mcp.CallToolRequest,
+	params DiffResourcesParams,
+) (*mcp.CallToolResult, DiffResourcesResult, error) {
+	sess, err := s.acquire(ctx, params.Path)
+	if err != nil {
+		return nil, DiffResourcesResult{}, fmt.Errorf("prepare runner: %w", err)
+	}
+	defer sess.mu.Unlock()
+
+	result := DiffResourcesResult{
+		Changes: []ResourceChange{},
+	}
+
+	switch {
+	case sess.state.Output.Error != nil:
+		result.Error = sess.state.Output.Error.Error()
+		result.Message = "The last render failed, use 'get_render_output' for details: " + result.Error
+	case sess.state.Previous == nil:
+		result.Message = "There is no previous render to compare against. Make a change to the manifest sources, then call 'diff_resources' again."
+	default:
+		populateDiffResult(&result, sess.state.Previous, sess.state.Output.Resources, params)
+	}
+
+	return createDiffResourcesResult(result), result, nil
+}
+
+// handleRunPlugin handles the run_plugin tool call.
+func (s *Server) handleRunPlugin(
+	ctx context.Context,
+	_ *mcp.CallToolRequest,
+	params RunPluginParams,
+) (*mcp.CallToolResult, RunPluginResult, error) {
+	sess, err := s.acquire(ctx, params.Path)
+	if err != nil {
+		return nil, RunPluginResult{}, fmt.Errorf("prepare runner: %w", err)
+	}
+
+	// Unlock before running the plugin, since its events are processed
+	// while it runs.
+	sess.mu.Unlock()
+
+	profileName, p := sess.runner.GetCurrentProfile()
+
+	result := RunPluginResult{
+		Plugins: pluginNames(p),
+	}
+
+	if msg := checkPlugin(profileName, p, params.Name); msg != "" {
+		result.Error = msg
+		result.Message = msg
+
+		return createRunPluginResult(result), result, nil
+	}
+
+	output := sess.runner.RunPluginContext(ctx, params.Name)
+	populateRunPluginResult(&result, output, params)
+
+	return createRunPluginResult(result), result, nil
+}
+
 func (s *Server) Server() *mcp.Server {
 	return s.server
 }
 
+// Close stops the server and closes all runners created by the
+// [RunnerFactory].
 func (s *Server) Close() {
-	close(s.eventCh)
+	close(s.done)
+
+	s.sessionsMu.Lock()
+	sessions := append(slices.Collect(maps.Values(s.sessions)), s.shared)
+	clear(s.sessions)
+	s.sessionsMu.Unlock()
+
+	for _, sess := range sessions {
+		sess.close()
+	}
 }
 
 // Serve starts the MCP server.
@@ -208,106 +370,165 @@ func (s *Server) serveStdio(ctx context.Context) error {
 	return nil
 }
 
-// processEvents processes command events in a separate goroutine.
-func (s *Server) processEvents() {
-	for event := range s.eventCh {
-		switch e := event.(type) {
-		case command.EventEnd:
-			s.updateState(e.Output)
+// acquire returns the session for the given path, locked and ready to use.
+// If path is empty, the path of the shared runner is used, i.e. the path that
+// is shown in the UI. The caller must unlock the session.
+func (s *Server) acquire(ctx context.Context, path string) (*session, error) {
+	ctx, span := s.tracer.Start(ctx, "reload")
+	defer span.End()
 
-			// Broadcast to all waiters.
-			s.completionCond.Broadcast()
-		}
+	sess, path := s.lookupSession(path)
+
+	sess.mu.Lock()
+
+	// The shared runner may have been configured for another path from the
+	// UI since the lookup. With a factory, it is never reconfigured by tool
+	// calls, so a separate runner is used for the path instead.
+	if sess == s.shared && s.newRunner != nil && sess.path != path {
+		sess.mu.Unlock()
+
+		sess = s.pooledSession(path)
+
+		sess.mu.Lock()
 	}
-}
 
-func (s *Server) updateState(output command.Output) {
-	s.mu.Lock()
-	defer s.mu.Unlock()
+	err := s.prepareSession(ctx, sess, path)
+	if err != nil {
+		sess.mu.Unlock()
+
+		return nil, err
+	}
 
-	s.state.Output = output
+	return sess, nil
 }
 
-func (s *Server) pathChanged(newPath string) bool {
-	// If no path provided, use current path (no-op).
-	if newPath == "" {
-		return false
+// lookupSession returns the session for the given path, creating it if
+// needed, along with the resolved path. The shared session is used for its
+// current path, and for every path if there is no [RunnerFactory].
+func (s *Server) lookupSession(path string) (*session, string) {
+	s.sessionsMu.Lock()
+
+	// If no path provided, use the path of the shared runner, rather than
+	// the path of another client's last tool call.
+	if path == "" {
+		path = s.sharedPath
+	} else {
+		path = filepath.Clean(path)
 	}
 
-	// If path hasn't changed, this is a no-op.
-	if s.currentPath == newPath {
-		return false
+	if s.newRunner != nil && path != s.sharedPath {
+		s.sessionsMu.Unlock()
+
+		return s.pooledSession(path), path
 	}
 
-	return true
+	s.sessionsMu.Unlock()
+
+	return s.shared, path
 }
 
-func (s *Server) reload(ctx context.Context, path string) error {
-	ctx, span := s.tracer.Start(ctx, "reload")
-	defer span.End()
+// pooledSession returns the session with a separate runner for the given
+// path, creating it if needed.
+func (s *Server) pooledSession(path string) *session {
+	s.sessionsMu.Lock()
+	defer s.sessionsMu.Unlock()
 
-	if !s.pathChanged(path) {
-		return nil // No path change, nothing to do.
+	sess, ok := s.sessions[path]
+	if !ok {
+		sess = newSession(s.server, s.tracer, path)
+		s.sessions[path] = sess
 	}
 
-	logger := log.WithContext(ctx)
-	logger.DebugContext(ctx, "reloading with new path", slog.String("path", path))
+	sess.lastUsed = time.Now()
 
-	// Reconfigure the runner with the new path.
-	err := s.runner.ConfigureContext(ctx,
-		command.WithAutoProfile(),
-		command.WithPath(path),
-	)
-	if err != nil {
-		return fmt.Errorf("reconfigure runner with path %q: %w", path, err)
-	}
+	return sess
+}
 
-	s.currentPath = path
+// setSharedPath records the path that the shared runner is configured for.
+// The runner can be reconfigured by tool calls, or from the UI.
+func (s *Server) setSharedPath(path string) {
+	s.sessionsMu.Lock()
+	defer s.sessionsMu.Unlock()
 
-	t := time.Now()
+	s.sharedPath = path
+}
 
-	// Start the command with the new path.
-	go s.runner.RunContext(ctx)
+// prepareSession ensures that the session has a runner for the given path,
+// and that the path has been rendered.
+//
+// The caller must hold sess.mu.
+func (s *Server) prepareSession(ctx context.Context, sess *session, path string) error {
+	switch {
+	case sess.runner == nil:
+		runner, err := s.newRunner(path)
+		if err != nil {
+			s.removeSession(sess)
 
-	// Wait for any completion that occurs after our request was made.
-	err = s.waitForCompletion(ctx, t)
-	if err != nil {
-		return fmt.Errorf("wait for completion: %w", err)
-	}
+			return fmt.Errorf("create runner for path %q: %w", path, err)
+		}
+
+		sess.closeRunner = runner.Close
+		sess.attach(runner)
 
-	logger.DebugContext(ctx, "reload completed successfully")
+		go runner.RunOnEvent()
+
+		return sess.render(ctx)
+
+	case sess.path != path:
+		return sess.reconfigure(ctx, path)
+	}
 
 	return nil
 }
 
-// waitForCompletion blocks until any command execution completes or the context is canceled.
-func (s *Server) waitForCompletion(ctx context.Context, reloadTime time.Time) error {
-	ctx, span := s.tracer.Start(ctx, "wait")
-	defer span.End()
+// removeSession removes the session from the pool, if it is still present.
+func (s *Server) removeSession(sess *session) {
+	s.sessionsMu.Lock()
+	defer s.sessionsMu.Unlock()
 
-	if reloadTime.IsZero() {
-		return nil // No reload happened.
+	if s.sessions[sess.path] == sess {
+		delete(s.sessions, sess.path)
 	}
+}
+
+// expireSessions periodically closes sessions that have been idle for
+// longer than the idle timeout.
+func (s *Server) expireSessions() {
+	ticker := time.NewTicker(max(s.idleTimeout/4, 10*time.Millisecond))
+	defer ticker.Stop()
 
 	for {
-		// Check if the last completion should be allowed.
-		if s.state.Output.Timestamp.After(reloadTime) {
-			return nil
+		select {
+		case <-s.done:
+			return
+		case now := <-ticker.C:
+			s.closeIdleSessions(now)
 		}
+	}
+}
 
-		// Check if context was canceled.
-		select {
-		case <-ctx.Done():
-			return fmt.Errorf("wait for completion canceled: %w", ctx.Err())
-		default:
+// closeIdleSessions closes sessions, other than the shared session, that have
+// not been used since before the idle timeout.
+func (s *Server) closeIdleSessions(now time.Time) {
+	var idle []*session
+
+	s.sessionsMu.Lock()
+
+	for path, sess := range s.sessions {
+		if now.Sub(sess.lastUsed) < s.idleTimeout {
+			continue
 		}
 
-		// Wait for the next completion.
-		s.completionCond.Wait()
+		delete(s.sessions, path)
+
+		idle = append(idle, sess)
+	}
+
+	s.sessionsMu.Unlock()
 
-		// The condition variable's Wait() method atomically releases the mutex and waits.
-		// This allows updateState() in another goroutine to acquire the lock.
-		// After updateState() broadcasts and releases the lock, Wait() re-acquires it before returning.
+	for _, sess := range idle {
+		slog.Debug("closing idle runner", slog.String("path", sess.path))
+		sess.close()
 	}
 }
 
@@ -3,6 +3,8 @@ package mcp_test
 import (
 	"context"
 	"errors"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -15,10 +17,13 @@ import (
 	"github.com/macropower/kat/pkg/command"
 	"github.com/macropower/kat/pkg/kube"
 	"github.com/macropower/kat/pkg/mcp"
+	"github.com/macropower/kat/pkg/profile"
 )
 
 // mockCommandRunner implements the CommandRunner interface for testing.
 type mockCommandRunner struct {
+	profile        *profile.Profile
+	closed         atomic.Bool
 	channels       []chan<- command.Event
 	outputs        []command.Output
 	configureCount int
@@ -65,6 +70,27 @@ func (m *mockCommandRunner) RunContext(ctx context.Context) command.Output {
 	return output
 }
 
+func (m *mockCommandRunner) RunPluginContext(ctx context.Context, name string, _ ...command.PluginRunOpt) command.Output {
+	m.SendEvent(command.NewEventStart(ctx, command.TypePlugin))
+
+	output := command.NewOutput(command.TypePlugin)
+	output.Stdout = "ran " + name
+
+	m.SendEvent(command.NewEventEnd(ctx, output))
+
+	return output
+}
+
+func (m *mockCommandRunner) RunOnEvent() {}
+
+func (m *mockCommandRunner) Close() {
+	m.closed.Store(true)
+}
+
+func (m *mockCommandRunner) GetCurrentProfile() (string, *profile.Profile) {
+	return "test", m.profile
+}
+
 func (m *mockCommandRunner) SendEvent(evt command.Event) {
 	// Set timestamp for EventEnd events if not already set
 	if endEvent, ok := evt.(command.EventEnd); ok {
@@ -141,6 +167,18 @@ func TestServer_GetResourceSendsOpenEvent(t *testing.T) {
 	assert.True(t, openResourceEventFound, "EventOpenResource should have been sent")
 }
 
+// closeSessions closes the client session, and waits for the server session
+// to end. Resource list notifications are sent shortly after each render, so
+// it first allows any pending notifications to be sent.
+func closeSessions(t *testing.T, clientSession *sdk.ClientSession, serverSession *sdk.ServerSession) {
+	t.Helper()
+
+	time.Sleep(50 * time.Millisecond)
+
+	require.NoError(t, clientSession.Close())
+	require.NoError(t, serverSession.Wait())
+}
+
 func (m *mockCommandRunner) addOutput(output command.Output) {
 	m.outputs = append(m.outputs, output)
 }
@@ -406,6 +444,47 @@ func TestServer_Integration(t *testing.T) {
 				},
 			},
 		},
+		"list_resources_filtered": {
+			params: &sdk.CallToolParams{
+				Name: "list_resources",
+				Arguments: map[string]any{
+					"path":      "/test/path",
+					"kind":      "deployment",
+					"namespace": "kube-system",
+				},
+			},
+			want: map[string]any{
+				"stdoutPreview": "command output",
+				"stderrPreview": "",
+				"message":       "Found 1 of 2 Kubernetes resources matching kind=deployment, namespace=kube-system.",
+				"resourceCount": float64(1),
+				"resources": []any{
+					map[string]any{
+						"apiVersion": "apps/v1",
+						"kind":       "Deployment",
+						"name":       "test-deployment",
+						"namespace":  "kube-system",
+					},
+				},
+			},
+		},
+		"list_resources_invalid_selector": {
+			params: &sdk.CallToolParams{
+				Name: "list_resources",
+				Arguments: map[string]any{
+					"path":          "/test/path",
+					"labelSelector": "app in (web",
+				},
+			},
+			want: map[string]any{
+				"stdoutPreview": "",
+				"stderrPreview": "",
+				"error":         `INVALID INPUT ERROR: labelSelector: invalid label selector "app in (web": invalid label key "app in (web"`,
+				"message":       `INVALID INPUT ERROR: labelSelector: invalid label selector "app in (web": invalid label key "app in (web"`,
+				"resourceCount": float64(0),
+				"resources":     []any{},
+			},
+		},
 		"get_resource_found": {
 			params: &sdk.CallToolParams{
 				Name: "get_resource",
@@ -486,8 +565,7 @@ func TestServer_Integration(t *testing.T) {
 		})
 	}
 
-	require.NoError(t, clientSession.Close())
-	require.NoError(t, serverSession.Wait())
+	closeSessions(t, clientSession, serverSession)
 	testServer.Close()
 }
 
@@ -579,8 +657,7 @@ func TestServer_PathReconfiguration(t *testing.T) {
 
 	_ = initialConfigureCount // Use the variable to avoid unused variable warning
 
-	require.NoError(t, clientSession.Close())
-	require.NoError(t, serverSession.Wait())
+	closeSessions(t, clientSession, serverSession)
 	testServer.Close()
 }
 
@@ -691,8 +768,457 @@ func TestServer_LatestResultsAfterReload(t *testing.T) {
 	assert.Equal(t, "first-pod", podName1)
 	assert.Equal(t, "second-pod", podName2)
 
-	require.NoError(t, clientSession.Close())
-	require.NoError(t, serverSession.Wait())
+	closeSessions(t, clientSession, serverSession)
+
+	testServer.Close()
+}
+
+//nolint:paralleltest,tparallel // Shares a clientSession.
+func TestServer_Tools(t *testing.T) {
+	t.Parallel()
+
+	newResource := func(yaml string) *kube.Resource {
+		resources, err := kube.SplitYAML([]byte(yaml))
+		require.NoError(t, err)
+		require.Len(t, resources, 1)
+
+		return resources[0]
+	}
+
+	pod := newResource("apiVersion: v1\nkind: Pod\nmetadata:\n  name: web\n  labels:\n    app: web\n")
+	podModified := newResource("apiVersion: v1\nkind: Pod\nmetadata:\n  name: web\n  labels:\n    app: api\n")
+	configMap := newResource("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: config\n")
+	configMap.Diagnostics = []kube.Diagnostic{{
+		Severity: kube.SeverityError,
+		Source:   "schema",
+		Path:     "$.data",
+		Message:  "missing property 'data'",
+	}}
+
+	testRunner := &mockCommandRunner{
+		profile: profile.MustNew("helm",
+			profile.WithPlugins(map[string]*profile.Plugin{
+				"lint": profile.MustNewPlugin("helm", "lint"),
+			}),
+		),
+	}
+	testRunner.addOutput(command.Output{
+		Type:      command.TypeRun,
+		Stdout:    "first",
+		Resources: []*kube.Resource{pod, configMap},
+	})
+	testRunner.addOutput(command.Output{
+		Type:      command.TypeRun,
+		Stdout:    "second",
+		Stderr:    "warning: deprecated value",
+		Warnings:  []command.Warning{{Message: "deprecated value"}},
+		Resources: []*kube.Resource{podModified},
+	})
+
+	clientTransport, serverTransport := sdk.NewInMemoryTransports()
+
+	testServer, err := mcp.NewServer("", testRunner, "/initial/path")
+	require.NoError(t, err)
+
+	ctx := t.Context()
+
+	serverSession, err := testServer.Server().Connect(ctx, serverTransport, nil)
+	require.NoError(t, err)
+
+	client := sdk.NewClient(&sdk.Implementation{Name: "client"}, nil)
+	clientSession, err := client.Connect(ctx, clientTransport, nil)
+	require.NoError(t, err)
+
+	callTool := func(t *testing.T, name string, args map[string]any) map[string]any {
+		t.Helper()
+
+		r, err := clientSession.CallTool(ctx, &sdk.CallToolParams{Name: name, Arguments: args})
+		require.NoError(t, err)
+
+		content, ok := r.StructuredContent.(map[string]any)
+		require.True(t, ok, "StructuredContent should be a map[string]any")
+
+		return content
+	}
+
+	t.Run("list_resources with label selector", func(t *testing.T) {
+		got := callTool(t, "list_resources", map[string]any{
+			"path":          "/test/path",
+			"labelSelector": "app in (web,api)",
+		})
+		assert.Equal(t, []any{
+			map[string]any{
+				"apiVersion": "v1",
+				"kind":       "Pod",
+				"name":       "web",
+				"namespace":  "",
+			},
+		}, got["resources"])
+	})
+
+	t.Run("list_resources includes diagnostics", func(t *testing.T) {
+		got := callTool(t, "list_resources", map[string]any{"kind": "ConfigMap"})
+		assert.Equal(t, []any{
+			map[string]any{
+				"apiVersion": "v1",
+				"kind":       "ConfigMap",
+				"name":       "config",
+				"namespace":  "",
+				"diagnostics": []any{
+					map[string]any{
+						"severity": "error",
+						"source":   "schema",
+						"path":     "$.data",
+						"message":  "missing property 'data'",
+					},
+				},
+			},
+		}, got["resources"])
+	})
+
+	t.Run("diff_resources without previous render", func(t *testing.T) {
+		got := callTool(t, "diff_resources", map[string]any{})
+		assert.Equal(t, []any{}, got["changes"])
+		assert.Contains(t, got["message"], "no previous render")
+	})
+
+	// Simulate a re-render triggered by a file change.
+	testRunner.RunContext(ctx)
+	time.Sleep(50 * time.Millisecond)
+
+	t.Run("get_render_output", func(t *testing.T) {
+		got := callTool(t, "get_render_output", map[string]any{"maxLength": 7})
+		assert.Equal(t, "second", got["stdout"])
+		assert.Equal(t, "warning\n[OUTPUT TRUNCATED]", got["stderr"])
+		assert.InDelta(t, float64(1), got["resourceCount"], 0)
+		assert.Equal(t,
+			"Render succeeded with 1 resources, 0 errors, and 0 warnings. The manifest generator printed 1 warnings.",
+			got["message"],
+		)
+		assert.Equal(t, []any{map[string]any{"message": "deprecated value"}}, got["warnings"])
+	})
+
+	t.Run("diff_resources", func(t *testing.T) {
+		got := callTool(t, "diff_resources", map[string]any{})
+		assert.Equal(t, "0 added, 1 removed, 1 modified, 0 unchanged.", got["message"])
+		assert.Equal(t, []any{
+			map[string]any{
+				"metadata": map[string]any{
+					"apiVersion": "v1",
+					"kind":       "Pod",
+					"name":       "web",
+					"namespace":  "",
+				},
+				"type": "modified",
+				"diff": "--- a/v1/Pod/web\n+++ b/v1/Pod/web\n@@ -3,4 +3,4 @@\n metadata:\n   name: web\n   labels:\n-    app: web\n+    app: api\n",
+			},
+			map[string]any{
+				"metadata": map[string]any{
+					"apiVersion": "v1",
+					"kind":       "ConfigMap",
+					"name":       "config",
+					"namespace":  "",
+				},
+				"type": "removed",
+				"diff": "--- a/v1/ConfigMap/config\n+++ /dev/null\n@@ -1,4 +0,0 @@\n-apiVersion: v1\n-kind: ConfigMap\n-metadata:\n-  name: config\n",
+			},
+		}, got["changes"])
+	})
+
+	t.Run("diff_resources summary only", func(t *testing.T) {
+		got := callTool(t, "diff_resources", map[string]any{"summaryOnly": true})
+		changes, ok := got["changes"].([]any)
+		require.True(t, ok)
+		require.Len(t, changes, 2)
+		assert.NotContains(t, changes[0], "diff")
+	})
+
+	t.Run("run_plugin", func(t *testing.T) {
+		got := callTool(t, "run_plugin", map[string]any{"name": "lint"})
+		assert.Equal(t, "ran lint", got["stdout"])
+		assert.Equal(t, `Plugin "lint" completed successfully.`, got["message"])
+		assert.Equal(t, []any{"lint"}, got["plugins"])
+	})
+
+	t.Run("run_plugin not found", func(t *testing.T) {
+		got := callTool(t, "run_plugin", map[string]any{"name": "deploy"})
+		assert.Equal(t,
+			`INVALID INPUT ERROR: Plugin "deploy" not found in profile "test". Available plugins: lint.`,
+			got["error"],
+		)
+	})
+
+	t.Run("plugin output does not replace render", func(t *testing.T) {
+		got := callTool(t, "get_render_output", map[string]any{})
+		assert.Equal(t, "second", got["stdout"])
+	})
+
+	closeSessions(t, clientSession, serverSession)
+	testServer.Close()
+}
+
+func TestServer_Resources(t *testing.T) {
+	t.Parallel()
+
+	newResource := func(yaml string) *kube.Resource {
+		resources, err := kube.SplitYAML([]byte(yaml))
+		require.NoError(t, err)
+		require.Len(t, resources, 1)
+
+		return resources[0]
+	}
+
+	deployment := newResource("apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: web\n  namespace: default\nspec:\n  replicas: 1\n")
+	deploymentModified := newResource("apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: web\n  namespace: default\nspec:\n  replicas: 2\n")
+	namespace := newResource("apiVersion: v1\nkind: Namespace\nmetadata:\n  name: default\n")
+
+	const (
+		deploymentURI = "kat:///initial/path/apps/Deployment/default/web"
+		namespaceURI  = "kat:///initial/path/core/Namespace/_/default"
+	)
+
+	testRunner := &mockCommandRunner{}
+	testRunner.addOutput(command.Output{
+		Type:      command.TypeRun,
+		Resources: []*kube.Resource{deployment, namespace},
+	})
+	testRunner.addOutput(command.Output{
+		Type:      command.TypeRun,
+		Resources: []*kube.Resource{deploymentModified},
+	})
+
+	clientTransport, serverTransport := sdk.NewInMemoryTransports()
+
+	testServer, err := mcp.NewServer("", testRunner, "/initial/path")
+	require.NoError(t, err)
+
+	ctx := t.Context()
+
+	serverSession, err := testServer.Server().Connect(ctx, serverTransport, nil)
+	require.NoError(t, err)
+
+	listChanged := make(chan struct{}, 10)
+	updated := make(chan string, 10)
+
+	client := sdk.NewClient(&sdk.Implementation{Name: "client"}, &sdk.ClientOptions{
+		ResourceListChangedHandler: func(context.Context, *sdk.ResourceListChangedRequest) {
+			listChanged <- struct{}{}
+		},
+		ResourceUpdatedHandler: func(_ context.Context, req *sdk.ResourceUpdatedNotificationRequest) {
+			updated <- req.Params.URI
+		},
+	})
+	clientSession, err := client.Connect(ctx, clientTransport, nil)
+	require.NoError(t, err)
+
+	waitFor := func(t *testing.T, ch <-chan struct{}) {
+		t.Helper()
+
+		select {
+		case <-ch:
+		case <-time.After(time.Second):
+			require.Fail(t, "timed out waiting for notification")
+		}
+	}
+
+	listURIs := func(t *testing.T) []string {
+		t.Helper()
+
+		res, err := clientSession.ListResources(ctx, nil)
+		require.NoError(t, err)
+
+		var uris []string
+		for _, r := range res.Resources {
+			uris = append(uris, r.URI)
+		}
+
+		return uris
+	}
+
+	// Initial render.
+	testRunner.RunContext(ctx)
+	waitFor(t, listChanged)
+
+	assert.ElementsMatch(t, []string{deploymentURI, namespaceURI}, listURIs(t))
+
+	read, err := clientSession.ReadResource(ctx, &sdk.ReadResourceParams{URI: deploymentURI})
+	require.NoError(t, err)
+	require.Len(t, read.Contents, 1)
+	assert.Equal(t, "application/yaml", read.Contents[0].MIMEType)
+	assert.Equal(t, deployment.Content(), read.Contents[0].Text)
+
+	require.NoError(t, clientSession.Subscribe(ctx, &sdk.SubscribeParams{URI: deploymentURI}))
+	require.NoError(t, clientSession.Subscribe(ctx, &sdk.SubscribeParams{URI: namespaceURI}))
+	require.Error(t, clientSession.Subscribe(ctx, &sdk.SubscribeParams{URI: "kat:///initial/path/core/Pod/_/missing"}))
+
+	// Render triggered by a file change.
+	testRunner.RunContext(ctx)
+	waitFor(t, listChanged)
+
+	var gotUpdated []string
+	for range 2 {
+		select {
+		case uri := <-updated:
+			gotUpdated = append(gotUpdated, uri)
+		case <-time.After(time.Second):
+			require.Fail(t, "timed out waiting for resources/updated")
+		}
+	}
+
+	assert.ElementsMatch(t, []string{deploymentURI, namespaceURI}, gotUpdated)
+	assert.Equal(t, []string{deploymentURI}, listURIs(t))
+
+	read, err = clientSession.ReadResource(ctx, &sdk.ReadResourceParams{URI: deploymentURI})
+	require.NoError(t, err)
+	assert.Equal(t, deploymentModified.Content(), read.Contents[0].Text)
+
+	_, err = clientSession.ReadResource(ctx, &sdk.ReadResourceParams{URI: namespaceURI})
+	require.Error(t, err)
+
+	closeSessions(t, clientSession, serverSession)
+	testServer.Close()
+}
+
+func TestServer_RunnerPool(t *testing.T) {
+	t.Parallel()
+
+	sharedRunner := &mockCommandRunner{}
+
+	var (
+		mu      sync.Mutex
+		runners = map[string][]*mockCommandRunner{}
+	)
+
+	factory := func(path string) (mcp.PooledRunner, error) {
+		if path == "/invalid" {
+			return nil, errors.New("no command for path")
+		}
+
+		r := &mockCommandRunner{}
+		r.addOutput(command.Output{
+			Type:   command.TypeRun,
+			Stdout: "render of " + path,
+		})
+
+		mu.Lock()
+		runners[path] = append(runners[path], r)
+		mu.Unlock()
+
+		return r, nil
+	}
+
+	clientTransport, serverTransport := sdk.NewInMemoryTransports()
+
+	testServer, err := mcp.NewServer("", sharedRunner, "/initial/path",
+		mcp.WithRunnerFactory(factory),
+		mcp.WithIdleTimeout(200*time.Millisecond),
+	)
+	require.NoError(t, err)
+
+	ctx := t.Context()
+
+	serverSession, err := testServer.Server().Connect(ctx, serverTransport, nil)
+	require.NoError(t, err)
+
+	client := sdk.NewClient(&sdk.Implementation{Name: "client"}, nil)
+	clientSession, err := client.Connect(ctx, clientTransport, nil)
+	require.NoError(t, err)
+
+	stdoutFor := func(path string) string {
+		r, err := clientSession.CallTool(ctx, &sdk.CallToolParams{
+			Name:      "get_render_output",
+			Arguments: map[string]any{"path": path},
+		})
+		if err != nil {
+			return err.Error()
+		}
+
+		content, ok := r.StructuredContent.(map[string]any)
+		if !ok {
+			return ""
+		}
+
+		stdout, _ := content["stdout"].(string) //nolint:revive // Empty if unset.
+
+		return stdout
+	}
+
+	// Tool calls for different paths run concurrently, each with its own runner.
+	var wg sync.WaitGroup
+
+	got := make([]string, 2)
+	for i, path := range []string{"/a", "/b"} {
+		wg.Go(func() {
+			got[i] = stdoutFor(path)
+		})
+	}
+
+	wg.Wait()
+
+	assert.Equal(t, []string{"render of /a", "render of /b"}, got)
+
+	// Runners are reused for the same path.
+	assert.Equal(t, "render of /a", stdoutFor("/a"))
+
+	mu.Lock()
+	assert.Len(t, runners["/a"], 1)
+	assert.Len(t, runners["/b"], 1)
+	mu.Unlock()
+
+	// Calls without a path use the shared runner's path, rather than the
+	// path of the last call.
+	sharedRunner.addOutput(command.Output{
+		Type:   command.TypeRun,
+		Stdout: "render of /initial/path",
+	})
+	sharedRunner.RunContext(ctx)
+
+	assert.Eventually(t, func() bool {
+		return stdoutFor("") == "render of /initial/path"
+	}, time.Second, 10*time.Millisecond)
+
+	// The shared session follows the shared runner when its path is changed
+	// from the UI.
+	sharedRunner.addOutput(command.Output{
+		Type:   command.TypeRun,
+		Stdout: "shared render of /b",
+	})
+	sharedRunner.SendEvent(command.NewEventConfigure(ctx, "/b"))
+	sharedRunner.RunContext(ctx)
+
+	assert.Eventually(t, func() bool {
+		return stdoutFor("") == "shared render of /b"
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(t, "shared render of /b", stdoutFor("/b"))
+
+	// The previous path of the shared runner uses a separate runner.
+	assert.Equal(t, "render of /initial/path", stdoutFor("/initial/path"))
+
+	// The shared runner is never reconfigured.
+	assert.Equal(t, 0, sharedRunner.configureCount)
+
+	// Errors from the factory are returned.
+	r, err := clientSession.CallTool(ctx, &sdk.CallToolParams{
+		Name:      "list_resources",
+		Arguments: map[string]any{"path": "/invalid"},
+	})
+	require.NoError(t, err)
+	assert.True(t, r.IsError)
+
+	// Idle runners are closed, and recreated when used again.
+	mu.Lock()
+	first := runners["/a"][0]
+	mu.Unlock()
+
+	assert.Eventually(t, first.closed.Load, 2*time.Second, 10*time.Millisecond)
+	assert.False(t, sharedRunner.closed.Load())
+
+	assert.Equal(t, "render of /a", stdoutFor("/a"))
+
+	mu.Lock()
+	assert.Len(t, runners["/a"], 2)
+	mu.Unlock()
 
+	closeSessions(t, clientSession, serverSession)
 	testServer.Close()
 }
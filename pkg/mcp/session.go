@@ -0,0 +1,230 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/macropower/kat/pkg/command"
+	"github.com/macropower/kat/pkg/kube"
+	"github.com/macropower/kat/pkg/log"
+)
+
+// session tracks the renders of a single path, using its own [CommandRunner].
+type session struct {
+	runner         CommandRunner
+	server         *mcp.Server
+	tracer         trace.Tracer
+	completionCond *sync.Cond
+	eventCh        chan command.Event
+	done           chan struct{}
+	closeRunner    func()                    // Closes the runner, if it is owned by the session.
+	onPathChange   func(path string)         // Called when the runner is configured for another path.
+	published      map[string]*kube.Resource // Rendered resources by MCP resource URI.
+	lastUsed       time.Time                 // Guarded by [Server.sessionsMu].
+	path           string
+	state          ExecutionState
+	mu             sync.RWMutex
+	closeOnce      sync.Once
+}
+
+// newSession creates a new [session] for the given path. The session does
+// not have a runner until [session.attach] is called.
+func newSession(server *mcp.Server, tracer trace.Tracer, path string) *session {
+	sess := &session{
+		server:  server,
+		tracer:  tracer,
+		eventCh: make(chan command.Event, 100),
+		done:    make(chan struct{}),
+		path:    path,
+	}
+
+	sess.completionCond = sync.NewCond(&sess.mu)
+
+	return sess
+}
+
+// attach subscribes the session to events from the runner.
+func (sess *session) attach(runner CommandRunner) {
+	sess.runner = runner
+
+	runner.Subscribe(sess.eventCh)
+
+	go sess.processEvents()
+}
+
+// close stops processing events, closes the runner if it is owned by the
+// session, and removes its resources from the MCP server.
+func (sess *session) close() {
+	sess.closeOnce.Do(func() {
+		close(sess.done)
+
+		if sess.closeRunner != nil {
+			sess.closeRunner()
+		}
+
+		sess.mu.RLock()
+		uris := slices.Collect(maps.Keys(sess.published))
+		sess.mu.RUnlock()
+
+		if len(uris) > 0 {
+			sess.server.RemoveResources(uris...)
+		}
+	})
+}
+
+// processEvents processes command events in a separate goroutine.
+func (sess *session) processEvents() {
+	for {
+		select {
+		case <-sess.done:
+			return
+
+		case event := <-sess.eventCh:
+			if e, ok := event.(command.EventConfigure); ok {
+				sess.updatePath(e.Path)
+
+				continue
+			}
+
+			e, ok := event.(command.EventEnd)
+			if !ok {
+				continue
+			}
+
+			// Plugin output is returned by the run_plugin tool directly, and
+			// must not replace the last render.
+			if e.Output.Type != command.TypeRun {
+				continue
+			}
+
+			changes := sess.updateState(e.Output)
+
+			// Broadcast to all waiters.
+			sess.completionCond.Broadcast()
+
+			sess.publishChanges(e.GetContext(), changes)
+		}
+	}
+}
+
+func (sess *session) updateState(output command.Output) resourceChanges {
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+
+	if len(sess.state.Output.Resources) > 0 {
+		sess.state.Previous = sess.state.Output.Resources
+	}
+
+	sess.state.Output = output
+
+	return sess.updatePublished(output.Resources)
+}
+
+// updatePath follows the runner when it is configured for another path,
+// e.g. from the UI.
+func (sess *session) updatePath(path string) {
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+
+	if path != "" && path != sess.path {
+		sess.setPath(path)
+	}
+}
+
+// setPath sets the session's path.
+//
+// The caller must hold sess.mu.
+func (sess *session) setPath(path string) {
+	sess.path = path
+
+	// Renders of the old path must not be compared with the new path.
+	sess.state = ExecutionState{}
+
+	if sess.onPathChange != nil {
+		sess.onPathChange(path)
+	}
+}
+
+// reconfigure points the session's runner at a new path, and renders it.
+//
+// The caller must hold sess.mu.
+func (sess *session) reconfigure(ctx context.Context, path string) error {
+	logger := log.WithContext(ctx)
+	logger.DebugContext(ctx, "reloading with new path", slog.String("path", path))
+
+	// Reconfigure the runner with the new path.
+	err := sess.runner.ConfigureContext(ctx,
+		command.WithAutoProfile(),
+		command.WithPath(path),
+	)
+	if err != nil {
+		return fmt.Errorf("reconfigure runner with path %q: %w", path, err)
+	}
+
+	sess.setPath(path)
+
+	return sess.render(ctx)
+}
+
+// render runs the command and waits for it to complete.
+//
+// The caller must hold sess.mu.
+func (sess *session) render(ctx context.Context) error {
+	t := time.Now()
+
+	// Start the command.
+	go sess.runner.RunContext(ctx)
+
+	// Wait for any completion that occurs after our request was made.
+	err := sess.waitForCompletion(ctx, t)
+	if err != nil {
+		return fmt.Errorf("wait for completion: %w", err)
+	}
+
+	log.WithContext(ctx).DebugContext(ctx, "render completed successfully",
+		slog.String("path", sess.path),
+	)
+
+	return nil
+}
+
+// waitForCompletion blocks until any command execution completes or the context is canceled.
+//
+// The caller must hold sess.mu.
+func (sess *session) waitForCompletion(ctx context.Context, reloadTime time.Time) error {
+	ctx, span := sess.tracer.Start(ctx, "wait")
+	defer span.End()
+
+	if reloadTime.IsZero() {
+		return nil // No reload happened.
+	}
+
+	for {
+		// Check if the last completion should be allowed.
+		if sess.state.Output.Timestamp.After(reloadTime) {
+			return nil
+		}
+
+		// Check if context was canceled.
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("wait for completion canceled: %w", ctx.Err())
+		default:
+		}
+
+		// Wait for the next completion.
+		sess.completionCond.Wait()
+
+		// The condition variable's Wait() method atomically releases the mutex and waits.
+		// This allows updateState() in another goroutine to acquire the lock.
+		// After updateState() broadcasts and releases the lock, Wait() re-acquires it before returning.
+	}
+}
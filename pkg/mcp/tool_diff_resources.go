@@ -0,0 +1,187 @@
+package mcp
+
+import (
+	"fmt"
+
+	"github.com/aymanbagabas/go-udiff"
+	"github.com/google/jsonschema-go/jsonschema"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"github.com/macropower/kat/pkg/kube"
+)
+
+func newToolDiffResources() *mcp.Tool {
+	return &mcp.Tool{
+		Name:  "diff_resources",
+		Title: "Diff Resources",
+		Description: `Compares the last render at the specified path with the render before it, and reports which resources were added, removed, or modified.
+
+Use this tool after changing values, templates, or other manifest sources to observe the effect of your change on ALL rendered resources.
+
+Modified resources include a unified diff of their YAML, unless 'summaryOnly' is true.`,
+		InputSchema: &jsonschema.Schema{
+			Type: "object",
+			Properties: map[string]*jsonschema.Schema{
+				"path": {
+					Type:        "string",
+					Description: "The directory path to operate on, relative to the project root.",
+				},
+				"summaryOnly": {
+					Type:        "boolean",
+					Description: "Only report which resources changed, without their diffs.",
+				},
+				"maxLength": {
+					Type:        "integer",
+					Description: "Maximum number of characters to return for each resource diff. Defaults to 20000.",
+				},
+			},
+			Required: []string{},
+		},
+		OutputSchema: &jsonschema.Schema{
+			Type: "object",
+			Properties: map[string]*jsonschema.Schema{
+				"error": {
+					Type:        "string",
+					Description: "Error message if the operation failed.",
+				},
+				"message": {
+					Type:        "string",
+					Description: "Human-readable message about the operation result.",
+				},
+				"changes": {
+					Type:        "array",
+					Description: "Resources that were added, removed, or modified.",
+					Items: &jsonschema.Schema{
+						Type: "object",
+						Properties: map[string]*jsonschema.Schema{
+							"metadata": newResourceMetadataSchema(),
+							"type": {
+								Type:        "string",
+								Description: "How the resource changed.",
+								Enum:        []any{"added", "removed", "modified"},
+							},
+							"diff": {
+								Type:        "string",
+								Description: "Unified diff of the resource YAML.",
+							},
+						},
+						Required: []string{"metadata", "type"},
+					},
+				},
+				"added": {
+					Type:        "integer",
+					Description: "Number of added resources.",
+				},
+				"removed": {
+					Type:        "integer",
+					Description: "Number of removed resources.",
+				},
+				"modified": {
+					Type:        "integer",
+					Description: "Number of modified resources.",
+				},
+				"unchanged": {
+					Type:        "integer",
+					Description: "Number of unchanged resources.",
+				},
+			},
+			Required: []string{"message", "changes", "added", "removed", "modified", "unchanged"},
+		},
+		Annotations: &mcp.ToolAnnotations{
+			ReadOnlyHint: true,
+		},
+	}
+}
+
+// DiffResourcesParams defines parameters for the diff_resources tool.
+type DiffResourcesParams struct {
+	Path        string `json:"path"`
+	SummaryOnly bool   `json:"summaryOnly,omitempty"`
+	MaxLength   int    `json:"maxLength,omitempty"`
+}
+
+// DiffResourcesResult contains the differences between the last two renders.
+type DiffResourcesResult struct {
+	Error     string           `json:"error,omitempty"`
+	Message   string           `json:"message"`
+	Changes   []ResourceChange `json:"changes"`
+	Added     int              `json:"added"`
+	Removed   int              `json:"removed"`
+	Modified  int              `json:"modified"`
+	Unchanged int              `json:"unchanged"`
+}
+
+// ResourceChange describes how a single resource changed between renders.
+type ResourceChange struct {
+	Metadata kube.ResourceMetadata `json:"metadata"`
+	Type     string                `json:"type"`
+	Diff     string                `json:"diff,omitempty"`
+}
+
+// createDiffResourcesResult creates the MCP tool result from DiffResourcesResult.
+func createDiffResourcesResult(result DiffResourcesResult) *mcp.CallToolResult {
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: result.Message},
+		},
+		IsError: result.Error != "",
+	}
+}
+
+// populateDiffResult populates the result with the differences between the
+// old and new resources.
+func populateDiffResult(result *DiffResourcesResult, oldResources, newResources []*kube.Resource, params DiffResourcesParams) {
+	maxLength := maxLengthOrDefault(params.MaxLength)
+
+	for _, d := range kube.DiffResources(oldResources, newResources) {
+		switch d.Type {
+		case kube.DiffUnchanged:
+			result.Unchanged++
+
+			continue
+		case kube.DiffAdded:
+			result.Added++
+		case kube.DiffRemoved:
+			result.Removed++
+		case kube.DiffModified:
+			result.Modified++
+		}
+
+		change := ResourceChange{
+			Metadata: d.Metadata,
+			Type:     d.Type.String(),
+		}
+
+		if !params.SummaryOnly {
+			change.Diff = truncateString(diffResource(d), maxLength)
+		}
+
+		result.Changes = append(result.Changes, change)
+	}
+
+	result.Message = fmt.Sprintf("%d added, %d removed, %d modified, %d unchanged.",
+		result.Added, result.Removed, result.Modified, result.Unchanged)
+}
+
+// diffResource returns a unified diff of the resource YAML.
+func diffResource(d kube.ResourceDiff) string {
+	var (
+		id                     = d.Metadata.String()
+		oldLabel, newLabel     = "a/" + id, "b/" + id
+		oldContent, newContent string
+	)
+
+	if d.Old != nil {
+		oldContent = d.Old.Content()
+	} else {
+		oldLabel = "/dev/null"
+	}
+
+	if d.New != nil {
+		newContent = d.New.Content()
+	} else {
+		newLabel = "/dev/null"
+	}
+
+	return udiff.Unified(oldLabel, newLabel, oldContent, newContent)
+}
@@ -0,0 +1,144 @@
+package mcp
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/jsonschema-go/jsonschema"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"github.com/macropower/kat/pkg/command"
+	"github.com/macropower/kat/pkg/kube"
+)
+
+func newToolGetRenderOutput() *mcp.Tool {
+	return &mcp.Tool{
+		Name:  "get_render_output",
+		Title: "Get Render Output",
+		Description: `Gets the raw stdout, stderr, and error of the last render at the specified path.
+
+Use this tool to find out why a render failed, or to see warnings printed by the manifest generator.`,
+		InputSchema: &jsonschema.Schema{
+			Type: "object",
+			Properties: map[string]*jsonschema.Schema{
+				"path": {
+					Type:        "string",
+					Description: "The directory path to operate on, relative to the project root.",
+				},
+				"maxLength": {
+					Type:        "integer",
+					Description: "Maximum number of characters to return for stdout and stderr. Defaults to 20000.",
+				},
+			},
+			Required: []string{},
+		},
+		OutputSchema: &jsonschema.Schema{
+			Type: "object",
+			Properties: map[string]*jsonschema.Schema{
+				"error": {
+					Type:        "string",
+					Description: "Error message if the render failed.",
+				},
+				"stdout": {
+					Type:        "string",
+					Description: "Stdout of the render command.",
+				},
+				"stderr": {
+					Type:        "string",
+					Description: "Stderr of the render command.",
+				},
+				"timestamp": {
+					Type:        "string",
+					Description: "Time the render completed, in RFC 3339 format.",
+				},
+				"message": {
+					Type:        "string",
+					Description: "Human-readable message about the operation result.",
+				},
+				"resourceCount": {
+					Type:        "integer",
+					Description: "Number of resources rendered.",
+				},
+				"errorCount": {
+					Type:        "integer",
+					Description: "Number of diagnostics with severity 'error' across all resources.",
+				},
+				"warningCount": {
+					Type:        "integer",
+					Description: "Number of diagnostics with severity 'warning' across all resources.",
+				},
+				"warnings": newWarningsSchema(),
+			},
+			Required: []string{"message", "stdout", "stderr", "resourceCount"},
+		},
+		Annotations: &mcp.ToolAnnotations{
+			ReadOnlyHint: true,
+		},
+	}
+}
+
+// GetRenderOutputParams defines parameters for the get_render_output tool.
+type GetRenderOutputParams struct {
+	Path      string `json:"path"`
+	MaxLength int    `json:"maxLength,omitempty"`
+}
+
+// GetRenderOutputResult contains the raw output of the last render.
+type GetRenderOutputResult struct {
+	Error         string            `json:"error,omitempty"`
+	Stdout        string            `json:"stdout"`
+	Stderr        string            `json:"stderr"`
+	Timestamp     string            `json:"timestamp,omitempty"`
+	Message       string            `json:"message"`
+	Warnings      []command.Warning `json:"warnings,omitempty"`
+	ResourceCount int               `json:"resourceCount"`
+	ErrorCount    int               `json:"errorCount,omitempty"`
+	WarningCount  int               `json:"warningCount,omitempty"`
+}
+
+// createGetRenderOutputResult creates the MCP tool result from GetRenderOutputResult.
+func createGetRenderOutputResult(result GetRenderOutputResult) *mcp.CallToolResult {
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: result.Message},
+		},
+		IsError: result.Error != "",
+	}
+}
+
+// newGetRenderOutputResult creates a [GetRenderOutputResult] from command output.
+func newGetRenderOutputResult(output command.Output, maxLength int) GetRenderOutputResult {
+	maxLength = maxLengthOrDefault(maxLength)
+
+	result := GetRenderOutputResult{
+		Stdout:        truncateString(output.Stdout, maxLength),
+		Stderr:        truncateString(output.Stderr, maxLength),
+		Warnings:      output.Warnings,
+		ResourceCount: len(output.Resources),
+		ErrorCount:    kube.CountDiagnostics(output.Resources, kube.SeverityError),
+		WarningCount:  kube.CountDiagnostics(output.Resources, kube.SeverityWarning),
+	}
+
+	if !output.Timestamp.IsZero() {
+		result.Timestamp = output.Timestamp.Format(time.RFC3339)
+	}
+
+	switch {
+	case output.Error != nil:
+		result.Error = output.Error.Error()
+		result.Message = "Render failed: " + result.Error
+	case output.Timestamp.IsZero():
+		result.Message = "No render has completed yet."
+	default:
+		result.Message = fmt.Sprintf(
+			"Render succeeded with %d resources, %d errors, and %d warnings.",
+			result.ResourceCount, result.ErrorCount, result.WarningCount,
+		)
+
+		if len(result.Warnings) > 0 {
+			result.Message += fmt.Sprintf(" The manifest generator printed %d warnings.", len(result.Warnings))
+		}
+	}
+
+	return result
+}
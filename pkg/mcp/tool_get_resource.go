@@ -60,6 +60,7 @@ IMPORTANT: You MUST first use 'list_resources' to get available resources, then
 							Type:        "string",
 							Description: "The YAML representation of the resource.",
 						},
+						"diagnostics": newDiagnosticsSchema(),
 					},
 				},
 				"error": {
@@ -102,8 +103,9 @@ type GetResourceResult struct {
 
 // ResourceDetails contains detailed information about a Kubernetes resource.
 type ResourceDetails struct {
-	Metadata kube.ResourceMetadata `json:"metadata"`
-	YAML     string                `json:"yaml"`
+	Metadata    kube.ResourceMetadata `json:"metadata"`
+	YAML        string                `json:"yaml"`
+	Diagnostics []kube.Diagnostic     `json:"diagnostics,omitempty"`
 }
 
 // createGetResourceResult creates the MCP tool result from GetResourceResult.
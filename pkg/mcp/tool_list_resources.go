@@ -1,6 +1,9 @@
 package mcp
 
 import (
+	"fmt"
+	"strings"
+
 	"github.com/google/jsonschema-go/jsonschema"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 
@@ -14,6 +17,8 @@ func newToolListResources() *mcp.Tool {
 		Title: "List Resources",
 		Description: `Lists all Kubernetes resources that would be rendered by a manifest generator (Helm, Kustomize, etc.) at the specified path.
 
+Use the 'kind', 'namespace' and 'labelSelector' parameters to narrow down large renders.
+
 IMPORTANT: Use this tool first before attempting to inspect any specific Kubernetes resources.`,
 		InputSchema: &jsonschema.Schema{
 			Type: "object",
@@ -22,6 +27,18 @@ IMPORTANT: Use this tool first before attempting to inspect any specific Kuberne
 					Type:        "string",
 					Description: "The directory path to operate on, relative to the project root.",
 				},
+				"kind": {
+					Type:        "string",
+					Description: "Only list resources of this kind (e.g. Deployment). Case-insensitive.",
+				},
+				"namespace": {
+					Type:        "string",
+					Description: "Only list resources in this namespace.",
+				},
+				"labelSelector": {
+					Type:        "string",
+					Description: "Only list resources matching this label selector, using kubectl syntax (e.g. app=web,tier in (frontend,backend),!canary).",
+				},
 			},
 			Required: []string{},
 		},
@@ -47,12 +64,13 @@ IMPORTANT: Use this tool first before attempting to inspect any specific Kuberne
 				"resources": {
 					Type:        "array",
 					Description: "List of Kubernetes resource metadata.",
-					Items:       newResourceMetadataSchema(),
+					Items:       newResourceSummarySchema(),
 				},
 				"resourceCount": {
 					Type:        "integer",
-					Description: "Total number of resources found.",
+					Description: "Number of resources found that match the filters.",
 				},
+				"warnings": newWarningsSchema(),
 			},
 			Required: []string{"message", "resources", "resourceCount"},
 		},
@@ -64,17 +82,29 @@ IMPORTANT: Use this tool first before attempting to inspect any specific Kuberne
 
 // ListResourcesParams defines parameters for the list_resources tool.
 type ListResourcesParams struct {
-	Path string `json:"path"`
+	Path          string `json:"path"`
+	Kind          string `json:"kind,omitempty"`
+	Namespace     string `json:"namespace,omitempty"`
+	LabelSelector string `json:"labelSelector,omitempty"`
 }
 
 // ListResourcesResult contains the result of listing resources.
 type ListResourcesResult struct {
-	Error         string                  `json:"error,omitempty"`
-	StdoutPreview string                  `json:"stdoutPreview"`
-	StderrPreview string                  `json:"stderrPreview"`
-	Message       string                  `json:"message"`
-	Resources     []kube.ResourceMetadata `json:"resources"`
-	ResourceCount int                     `json:"resourceCount"`
+	Error         string            `json:"error,omitempty"`
+	StdoutPreview string            `json:"stdoutPreview"`
+	StderrPreview string            `json:"stderrPreview"`
+	Message       string            `json:"message"`
+	Resources     []ResourceSummary `json:"resources"`
+	Warnings      []command.Warning `json:"warnings,omitempty"`
+	ResourceCount int               `json:"resourceCount"`
+}
+
+// ResourceSummary contains the metadata of a Kubernetes resource, along with
+// any problems found in it by validation or checks.
+type ResourceSummary struct {
+	kube.ResourceMetadata
+
+	Diagnostics []kube.Diagnostic `json:"diagnostics,omitempty"`
 }
 
 // createListResourcesResult creates the MCP tool result from ListResourcesResult.
@@ -89,19 +119,101 @@ func createListResourcesResult(result ListResourcesResult) *mcp.CallToolResult {
 	}
 }
 
-// populateResultFromOutput populates the result with data from command output.
-func populateResultFromOutput(result *ListResourcesResult, output command.Output) {
+// resourceFilter selects resources to include in the list_resources result.
+type resourceFilter struct {
+	kind      string
+	namespace string
+	selector  kube.Selector
+}
+
+// newResourceFilter creates a [resourceFilter] from the tool parameters.
+func newResourceFilter(params ListResourcesParams) (resourceFilter, error) {
+	selector, err := kube.ParseSelector(params.LabelSelector)
+	if err != nil {
+		return resourceFilter{}, fmt.Errorf("labelSelector: %w", err)
+	}
+
+	return resourceFilter{
+		kind:      params.Kind,
+		namespace: params.Namespace,
+		selector:  selector,
+	}, nil
+}
+
+// empty reports whether the filter matches every resource.
+func (f resourceFilter) empty() bool {
+	return f.kind == "" && f.namespace == "" && f.selector.Empty()
+}
+
+// matches reports whether the object passes all filters.
+func (f resourceFilter) matches(obj *kube.Object) bool {
+	if f.kind != "" && !strings.EqualFold(obj.GetKind(), f.kind) {
+		return false
+	}
+
+	if f.namespace != "" && obj.GetNamespace() != f.namespace {
+		return false
+	}
+
+	return f.selector.MatchesObject(obj)
+}
+
+// String returns a description of the filter, e.g. `kind=Deployment, namespace=default`.
+func (f resourceFilter) String() string {
+	var parts []string
+	if f.kind != "" {
+		parts = append(parts, "kind="+f.kind)
+	}
+
+	if f.namespace != "" {
+		parts = append(parts, "namespace="+f.namespace)
+	}
+
+	if !f.selector.Empty() {
+		parts = append(parts, fmt.Sprintf("labelSelector=%q", f.selector))
+	}
+
+	return strings.Join(parts, ", ")
+}
+
+// populateResultFromOutput populates the result with data from command
+// output, including only the resources that match the filter.
+func populateResultFromOutput(result *ListResourcesResult, output command.Output, filter resourceFilter) {
 	// Add stdout/stderr previews (truncated for readability).
 	result.StdoutPreview = truncateString(output.Stdout, 200)
 	result.StderrPreview = truncateString(output.Stderr, 200)
+	result.Warnings = output.Warnings
 
 	// Process resources.
-	if output.Resources != nil {
-		result.ResourceCount = len(output.Resources)
-		for _, resource := range output.Resources {
-			if resource.Object != nil {
-				result.Resources = append(result.Resources, resource.Object.GetMetadata())
-			}
+	for _, resource := range output.Resources {
+		if resource.Object == nil {
+			continue
 		}
+
+		if !filter.matches(resource.Object) {
+			continue
+		}
+
+		result.Resources = append(result.Resources, ResourceSummary{
+			ResourceMetadata: resource.Object.GetMetadata(),
+			Diagnostics:      resource.Diagnostics,
+		})
+	}
+
+	result.ResourceCount = len(result.Resources)
+}
+
+// formatListResourcesMessage formats the message for the list_resources tool result.
+func formatListResourcesMessage(result ListResourcesResult, total int, filter resourceFilter) string {
+	msg := fmt.Sprintf("Found %d Kubernetes resources.", result.ResourceCount)
+	if !filter.empty() {
+		msg = fmt.Sprintf("Found %d of %d Kubernetes resources matching %s.",
+			result.ResourceCount, total, filter)
 	}
+
+	if len(result.Warnings) > 0 {
+		msg += fmt.Sprintf(" The manifest generator printed %d warnings, see get_render_output.", len(result.Warnings))
+	}
+
+	return msg
 }
@@ -0,0 +1,145 @@
+package mcp
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/google/jsonschema-go/jsonschema"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"github.com/macropower/kat/pkg/command"
+	"github.com/macropower/kat/pkg/profile"
+)
+
+func newToolRunPlugin() *mcp.Tool {
+	return &mcp.Tool{
+		Name:  "run_plugin",
+		Title: "Run Plugin",
+		Description: `Runs a plugin configured for the current profile at the specified path, and returns its output.
+
+Plugins are user-defined commands, e.g. for running linters, policy engines, or dry-run applies against the rendered resources.
+
+IMPORTANT: Plugins may have side effects. Only run plugins that the user has asked for, or that are clearly safe based on their description.`,
+		InputSchema: &jsonschema.Schema{
+			Type: "object",
+			Properties: map[string]*jsonschema.Schema{
+				"name": {
+					Type:        "string",
+					Description: "The name of the plugin to run.",
+				},
+				"path": {
+					Type:        "string",
+					Description: "The directory path to operate on, relative to the project root.",
+				},
+				"maxLength": {
+					Type:        "integer",
+					Description: "Maximum number of characters to return for stdout and stderr. Defaults to 20000.",
+				},
+			},
+			Required: []string{"name"},
+		},
+		OutputSchema: &jsonschema.Schema{
+			Type: "object",
+			Properties: map[string]*jsonschema.Schema{
+				"error": {
+					Type:        "string",
+					Description: "Error message if the plugin failed.",
+				},
+				"stdout": {
+					Type:        "string",
+					Description: "Stdout of the plugin.",
+				},
+				"stderr": {
+					Type:        "string",
+					Description: "Stderr of the plugin.",
+				},
+				"message": {
+					Type:        "string",
+					Description: "Human-readable message about the operation result.",
+				},
+				"plugins": {
+					Type:        "array",
+					Description: "Names of the plugins available for the current profile.",
+					Items:       &jsonschema.Schema{Type: "string"},
+				},
+			},
+			Required: []string{"message", "stdout", "stderr"},
+		},
+	}
+}
+
+// RunPluginParams defines parameters for the run_plugin tool.
+type RunPluginParams struct {
+	Name      string `json:"name"`
+	Path      string `json:"path"`
+	MaxLength int    `json:"maxLength,omitempty"`
+}
+
+// RunPluginResult contains the output of a plugin.
+type RunPluginResult struct {
+	Error   string   `json:"error,omitempty"`
+	Stdout  string   `json:"stdout"`
+	Stderr  string   `json:"stderr"`
+	Message string   `json:"message"`
+	Plugins []string `json:"plugins,omitempty"`
+}
+
+// createRunPluginResult creates the MCP tool result from RunPluginResult.
+func createRunPluginResult(result RunPluginResult) *mcp.CallToolResult {
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: result.Message},
+		},
+		IsError: result.Error != "",
+	}
+}
+
+// pluginNames returns the sorted names of the plugins in the profile.
+func pluginNames(p *profile.Profile) []string {
+	if p == nil {
+		return nil
+	}
+
+	return slices.Sorted(maps.Keys(p.Plugins))
+}
+
+// checkPlugin returns an error message if the plugin is not available in the
+// profile, or an empty string otherwise.
+func checkPlugin(profileName string, p *profile.Profile, name string) string {
+	if p == nil {
+		return "No profile is configured for the current path."
+	}
+
+	if p.GetPlugin(name) != nil {
+		return ""
+	}
+
+	names := pluginNames(p)
+	if len(names) == 0 {
+		return fmt.Sprintf("INVALID INPUT ERROR: Profile %q has no plugins.", profileName)
+	}
+
+	return fmt.Sprintf(
+		"INVALID INPUT ERROR: Plugin %q not found in profile %q. Available plugins: %s.",
+		name, profileName, strings.Join(names, ", "),
+	)
+}
+
+// populateRunPluginResult populates the result with the plugin output.
+func populateRunPluginResult(result *RunPluginResult, output command.Output, params RunPluginParams) {
+	maxLength := maxLengthOrDefault(params.MaxLength)
+
+	result.Stdout = truncateString(output.Stdout, maxLength)
+	result.Stderr = truncateString(output.Stderr, maxLength)
+
+	if output.Error != nil {
+		result.Error = output.Error.Error()
+		result.Message = fmt.Sprintf("Plugin %q failed: %s", params.Name, result.Error)
+
+		return
+	}
+
+	result.Message = fmt.Sprintf("Plugin %q completed successfully.", params.Name)
+}
@@ -26,6 +26,9 @@ const (
 	TrustModeAllow
 	// TrustModeSkip skips runtime configs without prompting (--no-trust).
 	TrustModeSkip
+	// TrustModeAllowOnce trusts runtime configs without prompting, and without
+	// adding them to the trust list, e.g. for temporary checkouts.
+	TrustModeAllowOnce
 )
 
 const ( //nolint:grouper // Separate iota sequences require separate const blocks.
@@ -140,6 +143,9 @@ func (m *TrustManager) ensureTrusted(
 
 		return true, nil
 
+	case TrustModeAllowOnce:
+		return true, nil
+
 	case TrustModePrompt:
 		// Check if already trusted in policy.
 		if m.policy.IsTrusted(projectDir) {
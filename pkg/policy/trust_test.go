@@ -93,6 +93,7 @@ func TestTrustManager_LoadTrustedRuntimeConfig(t *testing.T) {
 		wantNil        bool
 		wantErr        bool
 		checkTrustList bool
+		checkUntrusted bool
 	}{
 		"no runtime config found": {
 			setupFunc: func(t *testing.T) (string, string, *policies.Policy) {
@@ -152,6 +153,21 @@ func TestTrustManager_LoadTrustedRuntimeConfig(t *testing.T) {
 			wantErr:        false,
 			checkTrustList: true,
 		},
+		"TrustModeAllowOnce returns config without saving to policy": {
+			setupFunc: func(t *testing.T) (string, string, *policies.Policy) {
+				t.Helper()
+
+				dir := setupProjectDir(t, validRuntimeConfig())
+				policyDir := t.TempDir()
+				policyPath := setupPolicyFile(t, policyDir)
+
+				return dir, policyPath, policies.New()
+			},
+			mode:           policy.TrustModeAllowOnce,
+			wantNil:        false,
+			wantErr:        false,
+			checkUntrusted: true,
+		},
 		"TrustModePrompt with already trusted returns config": {
 			setupFunc: func(t *testing.T) (string, string, *policies.Policy) {
 				t.Helper()
@@ -263,6 +279,10 @@ func TestTrustManager_LoadTrustedRuntimeConfig(t *testing.T) {
 			if tc.checkTrustList {
 				assert.True(t, pol.IsTrusted(targetPath))
 			}
+
+			if tc.checkUntrusted {
+				assert.False(t, pol.IsTrusted(targetPath))
+			}
 		})
 	}
 }
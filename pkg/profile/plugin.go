@@ -2,6 +2,7 @@ package profile
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 
@@ -9,6 +10,35 @@ import (
 	"github.com/macropower/kat/pkg/keys"
 )
 
+// Environment variables that describe the UI state to a plugin.
+const (
+	// PluginEnvProfile is the name of the current profile.
+	PluginEnvProfile = "KAT_PROFILE"
+	// PluginEnvPath is the path of the project that is rendered.
+	PluginEnvPath = "KAT_PATH"
+	// PluginEnvKind is the kind of the current resource.
+	PluginEnvKind = "KAT_KIND"
+	// PluginEnvName is the name of the current resource.
+	PluginEnvName = "KAT_NAME"
+	// PluginEnvNamespace is the namespace of the current resource.
+	PluginEnvNamespace = "KAT_NAMESPACE"
+)
+
+// PluginScope is a view in which a plugin can be run.
+type PluginScope string
+
+const (
+	// PluginScopeAll allows the plugin to run in every view.
+	PluginScopeAll PluginScope = "all"
+	// PluginScopeList allows the plugin to run in the resource list.
+	PluginScopeList PluginScope = "list"
+	// PluginScopePager allows the plugin to run in the pager.
+	PluginScopePager PluginScope = "pager"
+)
+
+// ErrInvalidPluginScope is returned for unknown plugin scopes.
+var ErrInvalidPluginScope = errors.New("invalid plugin scope")
+
 // Plugin represents a command plugin that can be executed on demand with keybinds.
 type Plugin struct {
 	executor Executor
@@ -19,6 +49,9 @@ type Plugin struct {
 	Description string `json:"description" jsonschema:"title=Description"`
 	// Keys defines the key bindings that trigger this plugin.
 	Keys []keys.Key `json:"keys,omitempty" jsonschema:"title=Keys"`
+	// Scope is the view in which the plugin can be run, either "list",
+	// "pager" or "all". Defaults to "all".
+	Scope PluginScope `json:"scope,omitempty" jsonschema:"title=Scope,enum=all,enum=list,enum=pager"`
 }
 
 // NewPlugin creates a new plugin with the given command and options.
@@ -82,6 +115,13 @@ func WithPluginEnvFrom(envFrom []execs.EnvFromSource) PluginOpt {
 	}
 }
 
+// WithPluginScope sets the view in which the plugin can be run.
+func WithPluginScope(scope PluginScope) PluginOpt {
+	return func(p *Plugin) {
+		p.Scope = scope
+	}
+}
+
 // WithPluginExecutor sets the [Executor] for the plugin.
 func WithPluginExecutor(executor Executor) PluginOpt {
 	return func(p *Plugin) {
@@ -90,6 +130,12 @@ func WithPluginExecutor(executor Executor) PluginOpt {
 }
 
 func (p *Plugin) Build() error {
+	switch p.Scope {
+	case "", PluginScopeAll, PluginScopeList, PluginScopePager:
+	default:
+		return fmt.Errorf("%w: %q", ErrInvalidPluginScope, p.Scope)
+	}
+
 	p.Command.SetBaseEnv(os.Environ())
 
 	err := p.Command.CompilePatterns()
@@ -104,6 +150,11 @@ func (p *Plugin) Build() error {
 	return nil
 }
 
+// InScope reports whether the plugin can be run in the view.
+func (p *Plugin) InScope(scope PluginScope) bool {
+	return p.Scope == "" || p.Scope == PluginScopeAll || p.Scope == scope
+}
+
 // Exec executes the plugin command in the specified directory.
 func (p *Plugin) Exec(ctx context.Context, dir string) (*execs.Result, error) {
 	result, err := p.executor.Exec(ctx, dir)
@@ -114,6 +165,24 @@ func (p *Plugin) Exec(ctx context.Context, dir string) (*execs.Result, error) {
 	return result, nil
 }
 
+// ExecWithStdin executes the plugin command in the specified directory,
+// writing stdin to the command's standard input.
+func (p *Plugin) ExecWithStdin(ctx context.Context, dir string, stdin []byte) (*execs.Result, error) {
+	return p.ExecWithOptions(ctx, dir, execs.ExecOptions{Stdin: stdin})
+}
+
+// ExecWithOptions executes the plugin command in the specified directory,
+// with the standard input and additional environment variables in opts, e.g.
+// the [PluginEnvKind] of the current resource.
+func (p *Plugin) ExecWithOptions(ctx context.Context, dir string, opts execs.ExecOptions) (*execs.Result, error) {
+	result, err := p.executor.ExecWithOptions(ctx, dir, opts)
+	if err != nil {
+		return result, fmt.Errorf("%w: %w", ErrPluginExecution, err)
+	}
+
+	return result, nil
+}
+
 // MatchKeys checks if any of the plugin's keys match the given key code.
 func (p *Plugin) MatchKeys(keyCode string) bool {
 	for _, key := range p.Keys {
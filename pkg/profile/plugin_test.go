@@ -42,6 +42,61 @@ func TestPlugin_Exec(t *testing.T) {
 		require.ErrorIs(t, err, execs.ErrCommandExecution)
 	})
 
+	t.Run("plugin execution with stdin", func(t *testing.T) {
+		t.Parallel()
+
+		plugin, err := profile.NewPlugin("cat", "stdin plugin")
+		require.NoError(t, err)
+
+		result, err := plugin.ExecWithStdin(t.Context(), "/tmp", []byte("kind: Pod\n"))
+
+		require.NoError(t, err)
+		require.NotNil(t, result)
+		assert.Equal(t, "kind: Pod\n", result.Stdout)
+	})
+
+	t.Run("plugin execution with env", func(t *testing.T) {
+		t.Parallel()
+
+		plugin, err := profile.NewPlugin("sh", "env plugin",
+			profile.WithPluginArgs("-c", "echo $KAT_KIND"))
+		require.NoError(t, err)
+
+		result, err := plugin.ExecWithOptions(t.Context(), "/tmp", execs.ExecOptions{
+			Env: []execs.EnvVar{{Name: profile.PluginEnvKind, Value: "Deployment"}},
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, "Deployment\n", result.Stdout)
+
+		// The environment is only used for a single execution.
+		result, err = plugin.Exec(t.Context(), "/tmp")
+
+		require.NoError(t, err)
+		assert.Equal(t, "\n", result.Stdout)
+	})
+
+	t.Run("plugin execution with custom executor", func(t *testing.T) {
+		t.Parallel()
+
+		executor := &recordingExecutor{result: &execs.Result{Stdout: "ok"}}
+
+		plugin, err := profile.NewPlugin("echo", "custom plugin",
+			profile.WithPluginExecutor(executor))
+		require.NoError(t, err)
+
+		opts := execs.ExecOptions{
+			Stdin: []byte("kind: Pod\n"),
+			Env:   []execs.EnvVar{{Name: profile.PluginEnvKind, Value: "Pod"}},
+		}
+
+		result, err := plugin.ExecWithOptions(t.Context(), "/tmp", opts)
+
+		require.NoError(t, err)
+		assert.Equal(t, "ok", result.Stdout)
+		assert.Equal(t, []execs.ExecOptions{opts}, executor.calls)
+	})
+
 	t.Run("empty command", func(t *testing.T) {
 		t.Parallel()
 
@@ -103,6 +158,55 @@ func TestPlugin_MatchKeys(t *testing.T) {
 	}
 }
 
+func TestPlugin_InScope(t *testing.T) {
+	t.Parallel()
+
+	tcs := map[string]struct {
+		scope profile.PluginScope
+		list  bool
+		pager bool
+	}{
+		"default": {
+			list:  true,
+			pager: true,
+		},
+		"all": {
+			scope: profile.PluginScopeAll,
+			list:  true,
+			pager: true,
+		},
+		"list": {
+			scope: profile.PluginScopeList,
+			list:  true,
+		},
+		"pager": {
+			scope: profile.PluginScopePager,
+			pager: true,
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			plugin, err := profile.NewPlugin("echo", "test plugin",
+				profile.WithPluginScope(tc.scope))
+			require.NoError(t, err)
+
+			assert.Equal(t, tc.list, plugin.InScope(profile.PluginScopeList))
+			assert.Equal(t, tc.pager, plugin.InScope(profile.PluginScopePager))
+		})
+	}
+
+	t.Run("invalid scope", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := profile.NewPlugin("echo", "test plugin",
+			profile.WithPluginScope("editor"))
+		require.ErrorIs(t, err, profile.ErrInvalidPluginScope)
+	})
+}
+
 func TestProfile_GetPlugin(t *testing.T) {
 	t.Parallel()
 
@@ -146,6 +250,9 @@ func TestProfile_GetPluginNameByKey(t *testing.T) {
 			profile.WithPluginKeys(keys.New("H"))),
 		"lint": profile.MustNewPlugin("helm", "helm lint",
 			profile.WithPluginKeys(keys.New("L"))),
+		"explain": profile.MustNewPlugin("kubectl", "kubectl explain",
+			profile.WithPluginKeys(keys.New("E")),
+			profile.WithPluginScope(profile.PluginScopePager)),
 	}
 
 	p := profile.MustNew("helm",
@@ -154,21 +261,35 @@ func TestProfile_GetPluginNameByKey(t *testing.T) {
 	t.Run("get plugin name by matching key", func(t *testing.T) {
 		t.Parallel()
 
-		name := p.GetPluginNameByKey("H")
+		name := p.GetPluginNameByKey("H", profile.PluginScopeList)
 		assert.Equal(t, "dry-run", name)
 	})
 
 	t.Run("get plugin name by different key", func(t *testing.T) {
 		t.Parallel()
 
-		name := p.GetPluginNameByKey("L")
+		name := p.GetPluginNameByKey("L", profile.PluginScopeList)
 		assert.Equal(t, "lint", name)
 	})
 
+	t.Run("plugin in scope", func(t *testing.T) {
+		t.Parallel()
+
+		name := p.GetPluginNameByKey("E", profile.PluginScopePager)
+		assert.Equal(t, "explain", name)
+	})
+
+	t.Run("plugin out of scope", func(t *testing.T) {
+		t.Parallel()
+
+		name := p.GetPluginNameByKey("E", profile.PluginScopeList)
+		assert.Empty(t, name)
+	})
+
 	t.Run("no plugin matches key", func(t *testing.T) {
 		t.Parallel()
 
-		name := p.GetPluginNameByKey("X")
+		name := p.GetPluginNameByKey("X", profile.PluginScopeList)
 		assert.Empty(t, name)
 	})
 
@@ -176,7 +297,7 @@ func TestProfile_GetPluginNameByKey(t *testing.T) {
 		t.Parallel()
 
 		p := profile.MustNew("kubectl")
-		name := p.GetPluginNameByKey("H")
+		name := p.GetPluginNameByKey("H", profile.PluginScopeList)
 		assert.Empty(t, name)
 	})
 }
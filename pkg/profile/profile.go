@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"log/slog"
 	"os"
+	"path/filepath"
 
 	"github.com/fsnotify/fsnotify"
 	"github.com/google/cel-go/cel"
@@ -13,6 +14,7 @@ import (
 
 	"github.com/macropower/kat/pkg/execs"
 	"github.com/macropower/kat/pkg/expr"
+	"github.com/macropower/kat/pkg/ignore"
 	"github.com/macropower/kat/pkg/keys"
 )
 
@@ -28,6 +30,7 @@ var (
 type Executor interface {
 	Exec(ctx context.Context, dir string) (*execs.Result, error)
 	ExecWithStdin(ctx context.Context, dir string, stdin []byte) (*execs.Result, error)
+	ExecWithOptions(ctx context.Context, dir string, opts execs.ExecOptions) (*execs.Result, error)
 	String() string
 }
 
@@ -72,6 +75,12 @@ type Profile struct {
 	// If no Source expression is provided, the profile will match all files by default.
 	Source string `json:"source,omitempty" jsonschema:"title=Source"`
 
+	// Ignore contains gitignore-style patterns of files and directories that
+	// are skipped before the Source expression is evaluated, e.g.
+	// `charts/*.tgz`. Ignored directories are not traversed. Patterns are
+	// added to the global ignore patterns.
+	Ignore []string `json:"ignore,omitempty" jsonschema:"title=Ignore"`
+
 	// Reload contains a CEL expression that is evaluated on automated reload
 	// events (e.g. from watch). If the expression returns true, the reload will proceed.
 	// If it returns false, the reload will be skipped. The expression has access to:
@@ -96,6 +105,16 @@ type Profile struct {
 	// They are appended to the Args of the Command.
 	ExtraArgs []string `json:"extraArgs,omitempty" jsonschema:"title=Optional Arguments" yaml:"extraArgs,flow,omitempty"`
 
+	// Variants contains a map of variant names to Variant configurations.
+	// Variants add arguments and environment variables to the Command, and
+	// can be switched from the menu without restarting.
+	Variants map[string]*Variant `json:"variants,omitempty" jsonschema:"title=Variants"`
+
+	// variant is the variant that is applied to the Command, if any.
+	variant *Variant
+	// variantName is the name of the applied variant.
+	variantName string
+
 	customExecutor bool // TODO: Prevent duplicate builds and remove this.
 }
 
@@ -144,6 +163,13 @@ func WithExtraArgs(args ...string) ProfileOpt {
 	}
 }
 
+// WithVariants sets the variants for the profile.
+func WithVariants(variants map[string]*Variant) ProfileOpt {
+	return func(p *Profile) {
+		p.Variants = variants
+	}
+}
+
 // WithHooks sets the hooks for the profile.
 func WithHooks(hooks *Hooks) ProfileOpt {
 	return func(p *Profile) {
@@ -158,6 +184,13 @@ func WithSource(source string) ProfileOpt {
 	}
 }
 
+// WithIgnore sets the ignore patterns for the profile.
+func WithIgnore(patterns ...string) ProfileOpt {
+	return func(p *Profile) {
+		p.Ignore = patterns
+	}
+}
+
 // WithReload sets the reload expression for the profile.
 func WithReload(reload string) ProfileOpt {
 	return func(p *Profile) {
@@ -225,6 +258,21 @@ func (p *Profile) Build() error {
 		}
 	}
 
+	for name, v := range p.Variants {
+		err := v.Build()
+		if err != nil {
+			return fmt.Errorf("build variant %q: %w", name, err)
+		}
+	}
+
+	// Discovered variants are only built once they are applied.
+	if p.variant != nil {
+		err := p.variant.Build()
+		if err != nil {
+			return fmt.Errorf("build variant %q: %w", p.variantName, err)
+		}
+	}
+
 	err := p.CompileSource()
 	if err != nil {
 		return fmt.Errorf("compile source: %w", err)
@@ -235,13 +283,23 @@ func (p *Profile) Build() error {
 		return fmt.Errorf("compile reload: %w", err)
 	}
 
+	_, err = ignore.New(p.Ignore...)
+	if err != nil {
+		return fmt.Errorf("compile ignore: %w", err)
+	}
+
 	err = p.Command.CompilePatterns()
 	if err != nil {
 		return fmt.Errorf("compile patterns: %w", err)
 	}
 
 	if p.executor == nil || !p.customExecutor {
-		p.executor = execs.NewExecutor(p.Command, p.ExtraArgs...)
+		cmd := p.Command
+		if p.variant != nil {
+			cmd = p.variant.Apply(cmd)
+		}
+
+		p.executor = execs.NewExecutor(cmd, p.ExtraArgs...)
 	}
 
 	return nil
@@ -392,8 +450,20 @@ func (p *Profile) MatchFileEvent(filePath string, fsOp fsnotify.Op) (bool, error
 // Exec runs the profile in the specified directory.
 // Returns ExecResult with the command output and any post-render hooks.
 func (p *Profile) Exec(ctx context.Context, dir string) (*execs.Result, error) {
-	// Execute preRender hooks, if any.
+	hr, err := p.ExecPreRender(ctx, dir)
+	if err != nil {
+		return hr, err
+	}
+
+	return p.ExecRender(ctx, dir)
+}
+
+// ExecPreRender runs the profile's preRender hooks in the specified directory.
+// The output of a hook is only returned if it fails.
+func (p *Profile) ExecPreRender(ctx context.Context, dir string) (*execs.Result, error) {
 	if p.Hooks != nil {
+		dir = p.VariantDir(dir)
+
 		p.status.SetStage(StagePreRender)
 
 		for _, hook := range p.Hooks.PreRender {
@@ -406,6 +476,14 @@ func (p *Profile) Exec(ctx context.Context, dir string) (*execs.Result, error) {
 		}
 	}
 
+	return nil, nil //nolint:nilnil // No hook failed.
+}
+
+// ExecRender runs the profile's command and postRender hooks in the specified
+// directory, without running the preRender hooks.
+func (p *Profile) ExecRender(ctx context.Context, dir string) (*execs.Result, error) {
+	dir = p.VariantDir(dir)
+
 	p.status.SetStage(StageRender)
 
 	result, err := p.executor.Exec(ctx, dir)
@@ -434,6 +512,16 @@ func (p *Profile) Exec(ctx context.Context, dir string) (*execs.Result, error) {
 	return result, nil
 }
 
+// VariantDir returns the directory in which the profile's commands run for
+// the specified directory, i.e. the applied variant's path within it, if any.
+func (p *Profile) VariantDir(dir string) string {
+	if p.variant != nil && p.variant.Path != "" {
+		return filepath.Join(dir, p.variant.Path)
+	}
+
+	return dir
+}
+
 // GetPlugin returns the plugin with the given name, or nil if not found.
 func (p *Profile) GetPlugin(name string) *Plugin {
 	if p.Plugins == nil {
@@ -443,14 +531,15 @@ func (p *Profile) GetPlugin(name string) *Plugin {
 	return p.Plugins[name]
 }
 
-// GetPluginNameByKey returns the name of the first plugin that matches the given key code.
-func (p *Profile) GetPluginNameByKey(keyCode string) string {
+// GetPluginNameByKey returns the name of the first plugin in the scope that
+// matches the given key code.
+func (p *Profile) GetPluginNameByKey(keyCode string, scope PluginScope) string {
 	if p.Plugins == nil {
 		return ""
 	}
 
 	for name, plugin := range p.Plugins {
-		if plugin.MatchKeys(keyCode) {
+		if plugin.InScope(scope) && plugin.MatchKeys(keyCode) {
 			slog.Debug("matched plugin",
 				slog.String("name", name),
 				slog.Any("keys", plugin.Keys),
@@ -463,7 +552,8 @@ func (p *Profile) GetPluginNameByKey(keyCode string) string {
 	return ""
 }
 
-func (p *Profile) GetPluginKeyBinds() []keys.KeyBind {
+// GetPluginKeyBinds returns the key bindings of the plugins in the scope.
+func (p *Profile) GetPluginKeyBinds(scope PluginScope) []keys.KeyBind {
 	binds := []keys.KeyBind{}
 
 	if p.Plugins == nil {
@@ -471,6 +561,10 @@ func (p *Profile) GetPluginKeyBinds() []keys.KeyBind {
 	}
 
 	for name, plugin := range p.Plugins {
+		if !plugin.InScope(scope) {
+			continue
+		}
+
 		desc := plugin.Description
 		if desc == "" {
 			desc = fmt.Sprintf("plugin %q", name)
@@ -23,7 +23,11 @@ func (m *mockExecutor) Exec(ctx context.Context, dir string) (*execs.Result, err
 	return m.ExecWithStdin(ctx, dir, nil)
 }
 
-func (m *mockExecutor) ExecWithStdin(_ context.Context, _ string, _ []byte) (*execs.Result, error) {
+func (m *mockExecutor) ExecWithStdin(ctx context.Context, dir string, stdin []byte) (*execs.Result, error) {
+	return m.ExecWithOptions(ctx, dir, execs.ExecOptions{Stdin: stdin})
+}
+
+func (m *mockExecutor) ExecWithOptions(_ context.Context, _ string, _ execs.ExecOptions) (*execs.Result, error) {
 	return m.result, m.err
 }
 
@@ -31,6 +35,35 @@ func (m *mockExecutor) String() string {
 	return "mock executor"
 }
 
+// recordingExecutor is a test implementation of the Executor interface that
+// records the options of each execution.
+type recordingExecutor struct {
+	result *execs.Result
+	calls  []execs.ExecOptions
+}
+
+func (r *recordingExecutor) Exec(ctx context.Context, dir string) (*execs.Result, error) {
+	return r.ExecWithOptions(ctx, dir, execs.ExecOptions{})
+}
+
+func (r *recordingExecutor) ExecWithStdin(ctx context.Context, dir string, stdin []byte) (*execs.Result, error) {
+	return r.ExecWithOptions(ctx, dir, execs.ExecOptions{Stdin: stdin})
+}
+
+func (r *recordingExecutor) ExecWithOptions(
+	_ context.Context,
+	_ string,
+	opts execs.ExecOptions,
+) (*execs.Result, error) {
+	r.calls = append(r.calls, opts)
+
+	return r.result, nil
+}
+
+func (r *recordingExecutor) String() string {
+	return "recording executor"
+}
+
 // newMockExecutor creates a mock executor that returns the specified result and error.
 func newMockExecutor(stdout, stderr string, err error) *mockExecutor {
 	var result *execs.Result
@@ -125,6 +158,20 @@ func TestProfile_New(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		"profile with ignore patterns": {
+			command: "echo",
+			opts: []profile.ProfileOpt{
+				profile.WithIgnore("charts/", "*.tmp"),
+			},
+			wantErr: false,
+		},
+		"profile with invalid ignore pattern": {
+			command: "echo",
+			opts: []profile.ProfileOpt{
+				profile.WithIgnore("[z-a]"),
+			},
+			wantErr: true,
+		},
 	}
 
 	for name, tc := range tcs {
@@ -851,3 +898,215 @@ func TestProfile_MatchFileEvent(t *testing.T) {
 		})
 	}
 }
+
+func TestProfile_FindVariants(t *testing.T) {
+	t.Parallel()
+
+	files := []string{
+		"app/Chart.yaml",
+		"app/values.yaml",
+		"app/values-dev.yaml",
+		"app/values-prod.yaml",
+		"app/overlays/prod/kustomization.yaml",
+	}
+
+	tcs := map[string]struct {
+		variants map[string]*profile.Variant
+		want     map[string]*profile.Variant
+		wantErr  bool
+	}{
+		"no variants": {
+			want: map[string]*profile.Variant{},
+		},
+		"static variants": {
+			variants: map[string]*profile.Variant{
+				"debug": {Args: []string{"--set", "debug=true"}},
+			},
+			want: map[string]*profile.Variant{
+				"debug": {Args: []string{"--set", "debug=true"}},
+			},
+		},
+		"discovered files": {
+			variants: map[string]*profile.Variant{
+				"values": {
+					Source: `files.filter(f, pathBase(f).matches("^values-.+[.]yaml$"))`,
+					Args:   []string{"-f", "$(file)"},
+					Env:    []execs.EnvVar{{Name: "VALUES", Value: "$(file)"}},
+				},
+			},
+			want: map[string]*profile.Variant{
+				"values-dev.yaml": {
+					Args: []string{"-f", "values-dev.yaml"},
+					Env:  []execs.EnvVar{{Name: "VALUES", Value: "values-dev.yaml"}},
+				},
+				"values-prod.yaml": {
+					Args: []string{"-f", "values-prod.yaml"},
+					Env:  []execs.EnvVar{{Name: "VALUES", Value: "values-prod.yaml"}},
+				},
+			},
+		},
+		"discovered directories": {
+			variants: map[string]*profile.Variant{
+				"overlays": {
+					Source: `files.filter(f, pathBase(f) == "kustomization.yaml").map(f, pathDir(f))`,
+					Path:   "$(file)",
+				},
+			},
+			want: map[string]*profile.Variant{
+				"overlays/prod": {Path: "overlays/prod", Args: []string{}, Env: []execs.EnvVar{}},
+			},
+		},
+		"static variants take precedence": {
+			variants: map[string]*profile.Variant{
+				"values-prod.yaml": {Args: []string{"--set", "prod=true"}},
+				"values": {
+					Source: `files.filter(f, pathBase(f) == "values-prod.yaml")`,
+					Args:   []string{"-f", "$(file)"},
+				},
+			},
+			want: map[string]*profile.Variant{
+				"values-prod.yaml": {Args: []string{"--set", "prod=true"}},
+			},
+		},
+		"source returning a path outside of dir": {
+			variants: map[string]*profile.Variant{
+				"values": {Source: `["values.yaml", "app/values.yaml"]`},
+			},
+			wantErr: true,
+		},
+		"source returning the parent of dir": {
+			variants: map[string]*profile.Variant{
+				"values": {Source: `[pathDir(dir)]`},
+			},
+			wantErr: true,
+		},
+		"source not returning a list": {
+			variants: map[string]*profile.Variant{
+				"values": {Source: `dir`},
+			},
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			p, err := profile.New("helm", profile.WithVariants(tc.variants))
+			require.NoError(t, err)
+
+			got, err := p.FindVariants("app", files)
+			if tc.wantErr {
+				require.Error(t, err)
+
+				return
+			}
+
+			require.NoError(t, err)
+			require.Len(t, got, len(tc.want))
+
+			for name, want := range tc.want {
+				require.Contains(t, got, name)
+				assert.Equal(t, want.Path, got[name].Path)
+				assert.Equal(t, want.Args, got[name].Args)
+				assert.Equal(t, want.Env, got[name].Env)
+			}
+		})
+	}
+}
+
+func TestVariant_Build(t *testing.T) {
+	t.Parallel()
+
+	tcs := map[string]struct {
+		path    string
+		wantErr bool
+	}{
+		"no path": {},
+		"local path": {
+			path: "overlays/prod",
+		},
+		"discovered path": {
+			path: "$(file)",
+		},
+		"parent path": {
+			path:    "../..",
+			wantErr: true,
+		},
+		"path climbing out of project": {
+			path:    "overlays/../../prod",
+			wantErr: true,
+		},
+		"absolute path": {
+			path:    "/etc",
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			v := &profile.Variant{Path: tc.path}
+
+			err := v.Build()
+			if tc.wantErr {
+				require.ErrorContains(t, err, "not local")
+			} else {
+				require.NoError(t, err)
+			}
+
+			// Variants are validated both when the profile is built, and when
+			// they are applied.
+			_, err = profile.New("echo", profile.WithVariants(map[string]*profile.Variant{"v": v}))
+			if tc.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+
+			p, err := profile.New("echo")
+			require.NoError(t, err)
+
+			_, err = p.ApplyVariant("v", &profile.Variant{Path: tc.path})
+			if tc.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestProfile_ApplyVariant(t *testing.T) {
+	t.Parallel()
+
+	p, err := profile.New("echo",
+		profile.WithArgs("template"),
+		profile.WithExtraArgs("-g"),
+	)
+	require.NoError(t, err)
+
+	pv, err := p.ApplyVariant("prod", &profile.Variant{Args: []string{"-f", "values-prod.yaml"}})
+	require.NoError(t, err)
+
+	result, err := pv.Exec(t.Context(), ".")
+	require.NoError(t, err)
+	assert.Equal(t, "template -f values-prod.yaml -g\n", result.Stdout)
+	assert.Equal(t, "prod", pv.GetVariantName())
+
+	// The original profile is not modified.
+	result, err = p.Exec(t.Context(), ".")
+	require.NoError(t, err)
+	assert.Equal(t, "template -g\n", result.Stdout)
+	assert.Nil(t, p.GetVariant())
+	assert.Empty(t, p.GetVariantName())
+
+	// Removing the variant restores the original command.
+	pn, err := pv.ApplyVariant("", nil)
+	require.NoError(t, err)
+
+	result, err = pn.Exec(t.Context(), ".")
+	require.NoError(t, err)
+	assert.Equal(t, "template -g\n", result.Stdout)
+}
@@ -0,0 +1,233 @@
+package profile
+
+import (
+	"fmt"
+	"maps"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/common/types/ref"
+
+	"github.com/macropower/kat/pkg/execs"
+	"github.com/macropower/kat/pkg/expr"
+)
+
+// VariantFile is replaced with the path of each file discovered by a
+// [Variant.Source], in the variant's Path, Args and Env values.
+const VariantFile = "$(file)"
+
+// Variant is a named set of extra arguments and environment variables for a
+// profile, e.g. to select a Helm values file or a Kustomize overlay.
+type Variant struct {
+	sourceProgram *expr.LazyProgram
+
+	// Source is a CEL expression that discovers variants from files. The
+	// expression has access to:
+	//   - `files` (list<string>): All file paths in directory
+	//   - `dir` (string): The directory path being processed
+	//
+	// Source CEL expressions must return a list of paths. One variant is created
+	// for each path, named after the path relative to `dir`, with `$(file)`
+	// replaced by that relative path:
+	//   - `files.filter(f, pathBase(f).matches("^values-.+[.]ya?ml$"))` - returns Helm values files
+	//   - `files.filter(f, pathBase(f) in ["kustomization.yaml", "kustomization.yml"] && pathDir(f).contains("overlays")).map(f, pathDir(f))` - returns Kustomize overlays
+	//
+	// If no Source expression is provided, the variant is used as-is.
+	Source string `json:"source,omitempty" jsonschema:"title=Source"`
+
+	// Path is the directory, relative to the project path, in which the
+	// profile's commands are executed, e.g. a Kustomize overlay. It must be
+	// within the project path.
+	Path string `json:"path,omitempty" jsonschema:"title=Path"`
+
+	// Args contains arguments that are appended to the profile's arguments.
+	Args []string `json:"args,omitempty" jsonschema:"title=Arguments" yaml:"args,flow,omitempty"`
+
+	// Env contains additional environment variable definitions.
+	Env []execs.EnvVar `json:"env,omitempty" jsonschema:"title=Environment Variables"`
+}
+
+// Build validates the variant's path, and compiles its source expression into
+// a CEL program.
+func (v *Variant) Build() error {
+	// The path must not escape the project path, e.g. `../..`.
+	if v.Path != "" && !filepath.IsLocal(filepath.FromSlash(v.Path)) {
+		return fmt.Errorf("path %q is not local to the project path", v.Path)
+	}
+
+	if v.Source == "" {
+		return nil
+	}
+
+	if v.sourceProgram == nil {
+		env, err := expr.NewEnvironment(
+			cel.Variable("files", cel.ListType(cel.StringType)),
+			cel.Variable("dir", cel.StringType),
+		)
+		if err != nil {
+			return fmt.Errorf("environment: %w", err)
+		}
+
+		v.sourceProgram = expr.NewLazyProgram(v.Source, env)
+	}
+
+	_, err := v.sourceProgram.Get()
+	if err != nil {
+		return fmt.Errorf("source expression: %w", err)
+	}
+
+	return nil
+}
+
+// discover returns the variants discovered by the source expression, keyed
+// by their paths relative to dirPath.
+func (v *Variant) discover(dirPath string, files []string) (map[string]*Variant, error) {
+	program, err := v.sourceProgram.Get()
+	if err != nil {
+		return nil, fmt.Errorf("source expression: %w", err)
+	}
+
+	result, _, err := program.Eval(map[string]any{
+		"files": files,
+		"dir":   dirPath,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("evaluate source expression: %w", err)
+	}
+
+	listVal, ok := result.Value().([]ref.Val)
+	if !ok {
+		return nil, fmt.Errorf("source expression returned %T, expected a list", result.Value())
+	}
+
+	variants := make(map[string]*Variant, len(listVal))
+
+	for _, item := range listVal {
+		path, ok := item.Value().(string)
+		if !ok {
+			return nil, fmt.Errorf("source expression returned %T, expected a string", item.Value())
+		}
+
+		rel, err := filepath.Rel(dirPath, path)
+		if err != nil {
+			return nil, fmt.Errorf("source expression returned path %q outside of %q: %w", path, dirPath, err)
+		}
+
+		// Rel does not fail for paths outside of dirPath, it climbs out of it.
+		if rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return nil, fmt.Errorf("source expression returned path %q outside of %q", path, dirPath)
+		}
+
+		variants[rel] = v.withFile(rel)
+	}
+
+	return variants, nil
+}
+
+// withFile returns a copy of the variant without a source, with [VariantFile]
+// replaced by the file path.
+func (v *Variant) withFile(file string) *Variant {
+	replace := func(s string) string {
+		return strings.ReplaceAll(s, VariantFile, file)
+	}
+
+	nv := &Variant{
+		Path: replace(v.Path),
+		Args: make([]string, 0, len(v.Args)),
+		Env:  make([]execs.EnvVar, 0, len(v.Env)),
+	}
+
+	for _, arg := range v.Args {
+		nv.Args = append(nv.Args, replace(arg))
+	}
+
+	for _, env := range v.Env {
+		env.Value = replace(env.Value)
+		nv.Env = append(nv.Env, env)
+	}
+
+	return nv
+}
+
+// Apply returns a copy of the command with the variant's arguments and
+// environment variables added.
+func (v *Variant) Apply(cmd execs.Command) execs.Command {
+	cmd.Args = slices.Concat(cmd.Args, v.Args)
+	cmd.Env = slices.Concat(cmd.Env, v.Env)
+
+	return cmd
+}
+
+// FindVariants returns the profile's variants for the directory and its
+// files, keyed by name. Variants with a Source are replaced by the variants
+// they discover. If names collide, variants without a Source take precedence.
+func (p *Profile) FindVariants(dirPath string, files []string) (map[string]*Variant, error) {
+	variants := map[string]*Variant{}
+
+	for name, v := range p.Variants {
+		if v.Source != "" {
+			continue
+		}
+
+		variants[name] = v
+	}
+
+	// Sort names so that collisions between sources are deterministic.
+	for _, name := range slices.Sorted(maps.Keys(p.Variants)) {
+		v := p.Variants[name]
+		if v.Source == "" {
+			continue
+		}
+
+		discovered, err := v.discover(dirPath, files)
+		if err != nil {
+			return nil, fmt.Errorf("variant %q: %w", name, err)
+		}
+
+		for dname, dv := range discovered {
+			_, exists := variants[dname]
+			if !exists {
+				variants[dname] = dv
+			}
+		}
+	}
+
+	return variants, nil
+}
+
+// ApplyVariant returns a copy of the profile that applies the named variant
+// to its command. Passing a nil variant returns a copy without a variant.
+// Any options are applied to the copy, e.g. to give it its own status.
+func (p *Profile) ApplyVariant(name string, v *Variant, opts ...ProfileOpt) (*Profile, error) {
+	pc := *p
+	pc.variant = v
+	pc.variantName = name
+
+	if v == nil {
+		pc.variantName = ""
+	}
+
+	for _, opt := range opts {
+		opt(&pc)
+	}
+
+	err := pc.Build()
+	if err != nil {
+		return nil, fmt.Errorf("rebuild profile with variant: %w", err)
+	}
+
+	return &pc, nil
+}
+
+// GetVariant returns the variant that is applied to the profile, or nil.
+func (p *Profile) GetVariant() *Variant {
+	return p.variant
+}
+
+// GetVariantName returns the name of the variant that is applied to the
+// profile, or an empty string.
+func (p *Profile) GetVariantName() string {
+	return p.variantName
+}
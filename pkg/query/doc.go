@@ -0,0 +1,6 @@
+// Package query matches Kubernetes objects using field selectors, label
+// selectors, or CEL (Common Expression Language) expressions.
+//
+// Queries are used to precisely filter rendered resources, e.g. in the
+// resource list, where fuzzy matching on names is too broad.
+package query
@@ -0,0 +1,249 @@
+package query
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/google/cel-go/cel"
+
+	"github.com/macropower/kat/pkg/expr"
+	"github.com/macropower/kat/pkg/kube"
+)
+
+// ErrInvalidQuery is returned when a query cannot be parsed.
+var ErrInvalidQuery = errors.New("invalid query")
+
+// fields contains the field names that can be used in selectors, mapped to
+// functions that return the field value of an object.
+var fields = map[string]func(o *kube.Object) string{
+	"apiVersion": (*kube.Object).GetAPIVersion,
+	"group":      (*kube.Object).GetGroup,
+	"kind":       (*kube.Object).GetKind,
+	"name":       (*kube.Object).GetName,
+	"namespace":  (*kube.Object).GetNamespace,
+	"ns":         (*kube.Object).GetNamespace,
+}
+
+// celEnvironment is shared by all queries, since creating an environment is
+// relatively expensive and queries are parsed on every keystroke.
+var celEnvironment = sync.OnceValues(func() (*expr.Environment, error) {
+	return expr.NewEnvironment(
+		cel.Variable("object", cel.MapType(cel.StringType, cel.DynType)),
+	)
+})
+
+// Query matches Kubernetes objects.
+//
+// A query is either a list of selectors, or a CEL expression. Selectors are
+// separated by spaces or commas, and all of them must match. They use the
+// same syntax as [kube.ParseSelector], and match either a field or a label:
+//   - `kind=Deployment`, `kind in (Deployment,StatefulSet)`
+//   - `ns=prod`, `namespace!=kube-system`, `!namespace` (cluster-scoped)
+//   - `name=api`, `group=apps`, `apiVersion=v1`
+//   - `app.kubernetes.io/name in (api,web)`, `!app.kubernetes.io/part-of`
+//
+// The field names `apiVersion`, `group`, `kind`, `name`, `namespace` and
+// `ns` take precedence over labels with the same key.
+//
+// Queries that are not valid selectors are evaluated as CEL expressions,
+// which have access to the `object` (map) variable, and must return a
+// boolean value:
+//   - object.kind == "Deployment" && object.spec.replicas > 1
+//   - has(object.spec.template.spec.securityContext)
+//
+// Expressions that fail to evaluate, e.g. because a field does not exist,
+// do not match.
+type Query struct {
+	program *expr.LazyProgram
+	fields  kube.Selector
+	labels  kube.Selector
+	raw     string
+}
+
+// Parse parses a query. An empty query matches every object.
+func Parse(s string) (*Query, error) {
+	q := &Query{raw: strings.TrimSpace(s)}
+	if q.raw == "" {
+		return q, nil
+	}
+
+	selErr := q.parseSelectors()
+	if selErr == nil {
+		return q, nil
+	}
+
+	env, err := celEnvironment()
+	if err != nil {
+		return nil, fmt.Errorf("create CEL environment: %w", err)
+	}
+
+	program := expr.NewLazyProgram(q.raw, env)
+
+	_, celErr := program.Get()
+	if celErr == nil {
+		q.program = program
+
+		return q, nil
+	}
+
+	// Report the error of the syntax that the query most likely uses.
+	if strings.Contains(q.raw, "object") {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidQuery, celErr)
+	}
+
+	return nil, fmt.Errorf("%w: %w", ErrInvalidQuery, selErr)
+}
+
+// MustParse parses a query and panics if there's an error.
+func MustParse(s string) *Query {
+	q, err := Parse(s)
+	if err != nil {
+		panic(err)
+	}
+
+	return q
+}
+
+// parseSelectors parses the query as a list of field and label selectors.
+func (q *Query) parseSelectors() error {
+	var fieldTerms, labelTerms []string
+
+	for _, term := range splitTerms(q.raw) {
+		if _, ok := fields[termKey(term)]; ok {
+			fieldTerms = append(fieldTerms, term)
+		} else {
+			labelTerms = append(labelTerms, term)
+		}
+	}
+
+	var err error
+
+	q.fields, err = kube.ParseSelector(strings.Join(fieldTerms, ","))
+	if err != nil {
+		return fmt.Errorf("field selector: %w", err)
+	}
+
+	q.labels, err = kube.ParseSelector(strings.Join(labelTerms, ","))
+	if err != nil {
+		return fmt.Errorf("label selector: %w", err)
+	}
+
+	return nil
+}
+
+// Matches reports whether the object matches the query.
+func (q *Query) Matches(o *kube.Object) bool {
+	if q.program != nil {
+		return q.matchesExpression(o)
+	}
+
+	if !q.fields.Empty() {
+		values := map[string]string{}
+
+		for name, get := range fields {
+			// Fields without a value are treated as unset labels, so that
+			// e.g. `!namespace` matches cluster-scoped objects.
+			if v := get(o); v != "" {
+				values[name] = v
+			}
+		}
+
+		if !q.fields.Matches(values) {
+			return false
+		}
+	}
+
+	return q.labels.MatchesObject(o)
+}
+
+func (q *Query) matchesExpression(o *kube.Object) bool {
+	program, err := q.program.Get()
+	if err != nil {
+		return false
+	}
+
+	result, _, err := program.Eval(map[string]any{
+		"object": expr.ConvertToCELValue(map[string]any(*o)),
+	})
+	if err != nil {
+		return false
+	}
+
+	matches, ok := result.Value().(bool)
+
+	return ok && matches
+}
+
+// IsExpression reports whether the query is a CEL expression.
+func (q *Query) IsExpression() bool {
+	return q.program != nil
+}
+
+// String returns the query as it was parsed.
+func (q *Query) String() string {
+	return q.raw
+}
+
+// splitTerms splits a query on spaces and commas that are not inside a set.
+// Set requirements such as `key in (a, b)` are kept as a single term.
+func splitTerms(s string) []string {
+	var (
+		tokens []string
+		token  strings.Builder
+		depth  int
+	)
+
+	flush := func() {
+		if token.Len() > 0 {
+			tokens = append(tokens, token.String())
+			token.Reset()
+		}
+	}
+
+	for _, c := range s {
+		switch {
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+		case depth == 0 && (c == ',' || c == ' ' || c == '\t'):
+			flush()
+
+			continue
+		}
+
+		token.WriteRune(c)
+	}
+
+	flush()
+
+	// Join the parts of set requirements, e.g. ["key", "in", "(a,b)"].
+	var terms []string
+
+	for i := 0; i < len(tokens); i++ {
+		if i+2 < len(tokens) && (tokens[i+1] == "in" || tokens[i+1] == "notin") &&
+			strings.HasPrefix(tokens[i+2], "(") {
+			terms = append(terms, strings.Join(tokens[i:i+3], " "))
+			i += 2
+
+			continue
+		}
+
+		terms = append(terms, tokens[i])
+	}
+
+	return terms
+}
+
+// termKey returns the key of a selector term.
+func termKey(term string) string {
+	term = strings.TrimPrefix(term, "!")
+
+	if i := strings.IndexAny(term, "=! "); i >= 0 {
+		return term[:i]
+	}
+
+	return term
+}
@@ -0,0 +1,127 @@
+package query_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/macropower/kat/pkg/kube"
+	"github.com/macropower/kat/pkg/query"
+)
+
+func TestQuery_Matches(t *testing.T) {
+	t.Parallel()
+
+	deployment := &kube.Object{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"metadata": map[string]any{
+			"name":      "api",
+			"namespace": "prod",
+			"labels": map[string]any{
+				"app.kubernetes.io/name": "api",
+			},
+		},
+		"spec": map[string]any{
+			"replicas": 3,
+		},
+	}
+
+	namespace := &kube.Object{
+		"apiVersion": "v1",
+		"kind":       "Namespace",
+		"metadata": map[string]any{
+			"name": "prod",
+		},
+	}
+
+	tcs := map[string]struct {
+		query          string
+		wantMatches    []bool // Deployment, Namespace.
+		wantErr        bool
+		wantExpression bool
+	}{
+		"empty": {
+			query:       "",
+			wantMatches: []bool{true, true},
+		},
+		"kind": {
+			query:       "kind=Deployment",
+			wantMatches: []bool{true, false},
+		},
+		"namespace alias": {
+			query:       "ns=prod",
+			wantMatches: []bool{true, false},
+		},
+		"cluster-scoped": {
+			query:       "!namespace",
+			wantMatches: []bool{false, true},
+		},
+		"group": {
+			query:       "group=apps",
+			wantMatches: []bool{true, false},
+		},
+		"fields and labels separated by spaces": {
+			query:       "kind=Deployment ns=prod app.kubernetes.io/name in (api,web)",
+			wantMatches: []bool{true, false},
+		},
+		"fields and labels separated by commas": {
+			query:       "kind in (Deployment, Namespace),app.kubernetes.io/name notin (web)",
+			wantMatches: []bool{true, true},
+		},
+		"label does not match": {
+			query:       "app.kubernetes.io/name=web",
+			wantMatches: []bool{false, false},
+		},
+		"name": {
+			query:       "name=prod",
+			wantMatches: []bool{false, true},
+		},
+		"expression": {
+			query:          `object.kind == "Deployment" && object.spec.replicas > 1`,
+			wantMatches:    []bool{true, false},
+			wantExpression: true,
+		},
+		"expression with has": {
+			query:          "has(object.spec)",
+			wantMatches:    []bool{true, false},
+			wantExpression: true,
+		},
+		"expression that fails to evaluate": {
+			query:          "object.spec.replicas > 1",
+			wantMatches:    []bool{true, false},
+			wantExpression: true,
+		},
+		"expression not returning a boolean": {
+			query:          `object["kind"]`,
+			wantMatches:    []bool{false, false},
+			wantExpression: true,
+		},
+		"invalid expression": {
+			query:   "object.kind ==",
+			wantErr: true,
+		},
+		"invalid selector": {
+			query:   "kind in (Deployment",
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			q, err := query.Parse(tc.query)
+			if tc.wantErr {
+				require.ErrorIs(t, err, query.ErrInvalidQuery)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.wantExpression, q.IsExpression())
+			assert.Equal(t, tc.wantMatches, []bool{q.Matches(deployment), q.Matches(namespace)})
+		})
+	}
+}
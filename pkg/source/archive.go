@@ -0,0 +1,139 @@
+package source
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+)
+
+// ErrArchiveTooLarge is returned when an archive exceeds the maximum size.
+var ErrArchiveTooLarge = errors.New("archive too large")
+
+// fetchArchive downloads a gzipped tarball over HTTP(S) and extracts it into
+// dir, returning the path of the project within dir.
+func (f *Fetcher) fetchArchive(ctx context.Context, ref, dir string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, ref, http.NoBody)
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrInvalidRef, err)
+	}
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("download %s: %w", ref, err)
+	}
+	defer resp.Body.Close() //nolint:errcheck // Ignore errors.
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("download %s: unexpected status %s", ref, resp.Status)
+	}
+
+	return f.extract(resp.Body, dir)
+}
+
+// extract extracts a gzipped tarball into dir. Only regular files and
+// directories are extracted. If the archive contains a single top-level
+// directory, as chart archives do, its path is returned; otherwise ".".
+func (f *Fetcher) extract(r io.Reader, dir string) (string, error) {
+	root, err := os.OpenRoot(dir)
+	if err != nil {
+		return "", fmt.Errorf("open directory %q: %w", dir, err)
+	}
+	defer root.Close() //nolint:errcheck // Ignore errors.
+
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return "", fmt.Errorf("read archive: %w", err)
+	}
+	defer gz.Close() //nolint:errcheck // Ignore errors.
+
+	var (
+		tr        = tar.NewReader(gz)
+		remaining = f.maxSize
+		topLevel  = map[string]bool{}
+	)
+
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return "", fmt.Errorf("read archive: %w", err)
+		}
+
+		name := path.Clean(strings.TrimPrefix(hdr.Name, "./"))
+		if name == "." {
+			continue
+		}
+
+		if !fs.ValidPath(name) {
+			return "", fmt.Errorf("read archive: invalid path %q", hdr.Name)
+		}
+
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			err = root.MkdirAll(name, 0o755)
+			if err != nil {
+				return "", fmt.Errorf("create directory %q: %w", name, err)
+			}
+
+		case tar.TypeReg:
+			remaining -= hdr.Size
+			if remaining < 0 {
+				return "", fmt.Errorf("%w: exceeds %d bytes", ErrArchiveTooLarge, f.maxSize)
+			}
+
+			err = writeFile(root, name, tr, hdr.Size)
+			if err != nil {
+				return "", err
+			}
+
+		default:
+			// Links and special files are skipped.
+			continue
+		}
+
+		top, rest, _ := strings.Cut(name, "/")
+		topLevel[top] = topLevel[top] || rest != "" || hdr.Typeflag == tar.TypeDir
+	}
+
+	if len(topLevel) == 1 {
+		for top, isDir := range topLevel {
+			if isDir {
+				return top, nil
+			}
+		}
+	}
+
+	return ".", nil
+}
+
+// writeFile writes size bytes from r to the named file in root, creating its
+// parent directories.
+func writeFile(root *os.Root, name string, r io.Reader, size int64) error {
+	err := root.MkdirAll(path.Dir(name), 0o755)
+	if err != nil {
+		return fmt.Errorf("create directory %q: %w", path.Dir(name), err)
+	}
+
+	file, err := root.OpenFile(name, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if err != nil {
+		return fmt.Errorf("create file %q: %w", name, err)
+	}
+	defer file.Close() //nolint:errcheck // Ignore errors.
+
+	_, err = io.CopyN(file, r, size)
+	if err != nil {
+		return fmt.Errorf("write file %q: %w", name, err)
+	}
+
+	return nil
+}
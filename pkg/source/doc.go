@@ -0,0 +1,7 @@
+// Package source fetches remote sources, such as Helm charts in OCI
+// registries, chart archives, and Git repositories, into temporary local
+// directories.
+//
+// Fetched sources can be rendered like any local project, e.g. to review an
+// upstream chart version without downloading it by hand first.
+package source
@@ -0,0 +1,111 @@
+package source
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os/exec"
+	"path"
+	"strings"
+)
+
+// gitRef is a reference to a path in a Git repository.
+type gitRef struct {
+	repo string
+	// path is the path of the project in the repository.
+	path string
+	// ref is a branch, tag or commit. If empty, the default branch is used.
+	ref string
+}
+
+// parseGitRef parses a ref like "git::https://example.com/repo//path?ref=v1".
+// As with Terraform modules, a double slash separates the repository URL from
+// the path within it, and the ref query parameter selects a branch, tag or
+// commit.
+func parseGitRef(ref string) (*gitRef, error) {
+	rest := strings.TrimPrefix(ref, prefixGit)
+
+	rest, query, _ := strings.Cut(rest, "?")
+
+	values, err := url.ParseQuery(query)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %q: %w", ErrInvalidRef, ref, err)
+	}
+
+	r := &gitRef{repo: rest, path: ".", ref: values.Get("ref")}
+
+	// Skip past the scheme's slashes, if there is a scheme.
+	offset := 0
+
+	scheme := strings.Index(rest, "://")
+	if scheme != -1 {
+		offset = scheme + len("://")
+	}
+
+	i := strings.Index(rest[offset:], "//")
+	if i != -1 {
+		r.repo = rest[:offset+i]
+		r.path = path.Clean(rest[offset+i+2:])
+	}
+
+	if r.repo == "" || !fs.ValidPath(r.path) {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidRef, ref)
+	}
+
+	return r, nil
+}
+
+// fetchGit shallow clones a Git repository into dir, returning the path of
+// the project within dir. If a ref is given, only that ref is fetched and
+// checked out, so that it may be a branch, a tag or a commit SHA.
+func (f *Fetcher) fetchGit(ctx context.Context, ref, dir string) (string, error) {
+	r, err := parseGitRef(ref)
+	if err != nil {
+		return "", err
+	}
+
+	if r.ref == "" {
+		err = f.runGit(ctx, "", "clone", "--quiet", "--depth", "1", "--", r.repo, dir)
+		if err != nil {
+			return "", fmt.Errorf("git clone %s: %w", r.repo, err)
+		}
+
+		return r.path, nil
+	}
+
+	err = f.runGit(ctx, dir, "init", "--quiet")
+	if err != nil {
+		return "", fmt.Errorf("git init: %w", err)
+	}
+
+	err = f.runGit(ctx, dir, "fetch", "--quiet", "--depth", "1", "--", r.repo, r.ref)
+	if err != nil {
+		return "", fmt.Errorf("git fetch %s %s: %w", r.repo, r.ref, err)
+	}
+
+	err = f.runGit(ctx, dir, "checkout", "--quiet", "FETCH_HEAD")
+	if err != nil {
+		return "", fmt.Errorf("git checkout %s: %w", r.ref, err)
+	}
+
+	return r.path, nil
+}
+
+// runGit runs git with the arguments in dir, or in the current working
+// directory if dir is empty. Its stderr is included in any error.
+func (f *Fetcher) runGit(ctx context.Context, dir string, args ...string) error {
+	var stderr bytes.Buffer
+
+	cmd := exec.CommandContext(ctx, f.git, args...)
+	cmd.Dir = dir
+	cmd.Stderr = &stderr
+
+	err := cmd.Run()
+	if err != nil {
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	return nil
+}
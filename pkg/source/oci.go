@@ -0,0 +1,295 @@
+package source
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const (
+	mediaTypeOCIManifest    = "application/vnd.oci.image.manifest.v1+json"
+	mediaTypeDockerManifest = "application/vnd.docker.distribution.manifest.v2+json"
+	mediaTypeHelmChart      = "application/vnd.cncf.helm.chart.content.v1.tar+gzip"
+
+	defaultTag = "latest"
+)
+
+// ociRef is a reference to an artifact in an OCI registry.
+type ociRef struct {
+	// scheme is the scheme of the registry API, i.e. "https" or "http".
+	scheme     string
+	registry   string
+	repository string
+	// reference is a tag or digest.
+	reference string
+}
+
+// parseOCIRef parses a ref like "oci://registry/repository:tag" or
+// "oci://registry/repository@sha256:digest". The tag defaults to "latest".
+func parseOCIRef(ref string) (*ociRef, error) {
+	rest := strings.TrimPrefix(ref, schemeOCI)
+
+	registry, repository, ok := strings.Cut(rest, "/")
+	if !ok || registry == "" || repository == "" {
+		return nil, fmt.Errorf("%w: %q: expected oci://registry/repository[:tag]", ErrInvalidRef, ref)
+	}
+
+	r := &ociRef{scheme: "https", registry: registry, repository: repository, reference: defaultTag}
+
+	name, digest, isDigest := strings.Cut(repository, "@")
+	tag := strings.LastIndex(repository, ":")
+
+	switch {
+	case isDigest:
+		r.repository = name
+		r.reference = digest
+	case tag > strings.LastIndex(repository, "/"):
+		r.repository = repository[:tag]
+		r.reference = repository[tag+1:]
+	}
+
+	if r.repository == "" || r.reference == "" {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidRef, ref)
+	}
+
+	return r, nil
+}
+
+// url returns the registry API URL of a manifest or blob.
+func (r *ociRef) url(kind, reference string) string {
+	return fmt.Sprintf("%s://%s/v2/%s/%s/%s", r.scheme, r.registry, r.repository, kind, reference)
+}
+
+// ociDescriptor describes content in an OCI registry.
+type ociDescriptor struct {
+	MediaType string `json:"mediaType"`
+	Digest    string `json:"digest"`
+	Size      int64  `json:"size"`
+}
+
+// ociManifest is an OCI image manifest.
+type ociManifest struct {
+	Layers []ociDescriptor `json:"layers"`
+}
+
+// chartLayer returns the layer containing the chart, or the first gzipped
+// tarball if the artifact is not a Helm chart.
+func (m *ociManifest) chartLayer() (ociDescriptor, bool) {
+	for _, l := range m.Layers {
+		if l.MediaType == mediaTypeHelmChart {
+			return l, true
+		}
+	}
+
+	for _, l := range m.Layers {
+		if strings.HasSuffix(l.MediaType, "tar+gzip") || strings.HasSuffix(l.MediaType, ".tar.gzip") {
+			return l, true
+		}
+	}
+
+	return ociDescriptor{}, false
+}
+
+// ociClient is a minimal client for the OCI distribution API. It supports
+// anonymous bearer tokens, as used by public registries.
+type ociClient struct {
+	client *http.Client
+	token  string
+}
+
+// fetchOCI pulls a Helm chart (or other gzipped tarball artifact) from an OCI
+// registry and extracts it into dir, returning the path of the project
+// within dir.
+func (f *Fetcher) fetchOCI(ctx context.Context, ref, dir string) (string, error) {
+	r, err := parseOCIRef(ref)
+	if err != nil {
+		return "", err
+	}
+
+	if f.plainHTTP {
+		r.scheme = "http"
+	}
+
+	c := &ociClient{client: f.client}
+
+	resp, err := c.get(ctx, r.url("manifests", r.reference), mediaTypeOCIManifest+", "+mediaTypeDockerManifest)
+	if err != nil {
+		return "", fmt.Errorf("get manifest: %w", err)
+	}
+
+	var manifest ociManifest
+
+	err = json.NewDecoder(resp.Body).Decode(&manifest)
+	resp.Body.Close() //nolint:errcheck,gosec // Ignore errors.
+	if err != nil {
+		return "", fmt.Errorf("decode manifest: %w", err)
+	}
+
+	layer, ok := manifest.chartLayer()
+	if !ok {
+		return "", fmt.Errorf("%s: no chart layer in manifest", ref)
+	}
+
+	algorithm, want, ok := strings.Cut(layer.Digest, ":")
+	if !ok || algorithm != "sha256" {
+		return "", fmt.Errorf("%s: unsupported layer digest %q", ref, layer.Digest)
+	}
+
+	resp, err = c.get(ctx, r.url("blobs", layer.Digest), "")
+	if err != nil {
+		return "", fmt.Errorf("get layer: %w", err)
+	}
+	defer resp.Body.Close() //nolint:errcheck // Ignore errors.
+
+	h := sha256.New()
+
+	path, err := f.extract(io.TeeReader(resp.Body, h), dir)
+	if err != nil {
+		return "", err
+	}
+
+	// Read any trailing data, so that the digest covers the whole blob.
+	_, err = io.Copy(h, resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("read layer: %w", err)
+	}
+
+	got := hex.EncodeToString(h.Sum(nil))
+	if got != want {
+		return "", fmt.Errorf("%s: layer digest mismatch: got sha256:%s, want %s", ref, got, layer.Digest)
+	}
+
+	return path, nil
+}
+
+// get sends a GET request, authenticating with an anonymous bearer token if
+// the registry requires one. The caller must close the response body.
+func (c *ociClient) get(ctx context.Context, u, accept string) (*http.Response, error) {
+	resp, err := c.do(ctx, u, accept)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode == http.StatusUnauthorized && c.token == "" {
+		challenge := resp.Header.Get("WWW-Authenticate")
+		resp.Body.Close() //nolint:errcheck,gosec // Ignore errors.
+
+		c.token, err = c.authenticate(ctx, challenge)
+		if err != nil {
+			return nil, err
+		}
+
+		resp, err = c.do(ctx, u, accept)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close() //nolint:errcheck,gosec // Ignore errors.
+
+		return nil, fmt.Errorf("GET %s: unexpected status %s", u, resp.Status)
+	}
+
+	return resp, nil
+}
+
+func (c *ociClient) do(ctx context.Context, u, accept string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, http.NoBody)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidRef, err)
+	}
+
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("GET %s: %w", u, err)
+	}
+
+	return resp, nil
+}
+
+// authenticate requests an anonymous token for a bearer challenge, e.g.
+// `Bearer realm="https://ghcr.io/token",service="ghcr.io",scope="..."`.
+func (c *ociClient) authenticate(ctx context.Context, challenge string) (string, error) {
+	scheme, params, _ := strings.Cut(challenge, " ")
+	if !strings.EqualFold(scheme, "Bearer") {
+		return "", fmt.Errorf("unsupported authentication challenge %q", challenge)
+	}
+
+	p := parseChallengeParams(params)
+
+	realm, err := url.Parse(p["realm"])
+	if err != nil || realm.Host == "" {
+		return "", fmt.Errorf("invalid authentication realm %q", p["realm"])
+	}
+
+	q := realm.Query()
+	for _, key := range []string{"service", "scope"} {
+		v := p[key]
+		if v != "" {
+			q.Set(key, v)
+		}
+	}
+
+	realm.RawQuery = q.Encode()
+
+	resp, err := c.do(ctx, realm.String(), "")
+	if err != nil {
+		return "", fmt.Errorf("get token: %w", err)
+	}
+	defer resp.Body.Close() //nolint:errcheck // Ignore errors.
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("get token: unexpected status %s", resp.Status)
+	}
+
+	var token struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"` //nolint:tagliatelle // Defined by the registry API.
+	}
+
+	err = json.NewDecoder(resp.Body).Decode(&token)
+	if err != nil {
+		return "", fmt.Errorf("decode token: %w", err)
+	}
+
+	if token.Token != "" {
+		return token.Token, nil
+	}
+
+	return token.AccessToken, nil
+}
+
+// parseChallengeParams parses comma-separated key="value" parameters.
+func parseChallengeParams(s string) map[string]string {
+	params := map[string]string{}
+
+	for s != "" {
+		var key, value string
+
+		key, s, _ = strings.Cut(strings.TrimLeft(s, ", "), "=")
+		if strings.HasPrefix(s, `"`) {
+			value, s, _ = strings.Cut(s[1:], `"`)
+		} else {
+			value, s, _ = strings.Cut(s, ",")
+		}
+
+		params[strings.ToLower(strings.TrimSpace(key))] = value
+	}
+
+	return params
+}
@@ -0,0 +1,183 @@
+package source
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+)
+
+const (
+	schemeOCI   = "oci://"
+	schemeHTTP  = "http://"
+	schemeHTTPS = "https://"
+	prefixGit   = "git::"
+
+	// DefaultMaxSize is the default maximum size of an extracted source.
+	DefaultMaxSize int64 = 512 << 20
+)
+
+var (
+	// ErrUnsupportedSource is returned when a ref is not a remote source.
+	ErrUnsupportedSource = errors.New("unsupported source")
+	// ErrInvalidRef is returned when a remote source ref cannot be parsed.
+	ErrInvalidRef = errors.New("invalid ref")
+)
+
+// IsRemote reports whether the ref refers to a remote source, e.g.
+// "oci://registry/chart:1.2.3", "https://example.com/chart.tgz", or
+// "git::https://example.com/repo//path?ref=v1".
+func IsRemote(ref string) bool {
+	for _, prefix := range []string{schemeOCI, schemeHTTP, schemeHTTPS, prefixGit} {
+		if strings.HasPrefix(ref, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Source is a remote source that was fetched into a local directory.
+type Source struct {
+	root *os.Root
+
+	// Ref is the ref that the source was fetched from.
+	Ref string
+	// Dir is the temporary directory containing the source.
+	Dir string
+	// Path is the path of the project within Dir.
+	Path string
+}
+
+// Root opens [Source.Dir] as an [os.Root]. The root is opened once, and is
+// closed by [Source.Close].
+func (s *Source) Root() (*os.Root, error) {
+	if s.root != nil {
+		return s.root, nil
+	}
+
+	root, err := os.OpenRoot(s.Dir)
+	if err != nil {
+		return nil, fmt.Errorf("open source directory %q: %w", s.Dir, err)
+	}
+
+	s.root = root
+
+	return root, nil
+}
+
+// Close closes the source's root, if it was opened, and removes the source's
+// directory.
+func (s *Source) Close() error {
+	var err error
+
+	if s.root != nil {
+		err = s.root.Close()
+		if err != nil {
+			err = fmt.Errorf("close source directory %q: %w", s.Dir, err)
+		}
+
+		s.root = nil
+	}
+
+	return errors.Join(err, os.RemoveAll(s.Dir))
+}
+
+// Fetcher fetches remote sources.
+type Fetcher struct {
+	client    *http.Client
+	git       string
+	tmpDir    string
+	maxSize   int64
+	plainHTTP bool
+}
+
+// FetcherOpt is a functional option for configuring a [Fetcher].
+type FetcherOpt func(*Fetcher)
+
+// NewFetcher creates a new [Fetcher].
+func NewFetcher(opts ...FetcherOpt) *Fetcher {
+	f := &Fetcher{
+		client:  http.DefaultClient,
+		git:     "git",
+		maxSize: DefaultMaxSize,
+	}
+
+	for _, opt := range opts {
+		opt(f)
+	}
+
+	return f
+}
+
+// WithHTTPClient sets the HTTP client used to fetch archives and OCI
+// artifacts.
+func WithHTTPClient(c *http.Client) FetcherOpt {
+	return func(f *Fetcher) {
+		f.client = c
+	}
+}
+
+// WithPlainHTTP sets whether OCI registries are accessed over plain HTTP
+// instead of HTTPS, e.g. for a local registry at localhost:5000.
+func WithPlainHTTP(plain bool) FetcherOpt {
+	return func(f *Fetcher) {
+		f.plainHTTP = plain
+	}
+}
+
+// WithGit sets the path of the git executable used to fetch Git sources.
+func WithGit(path string) FetcherOpt {
+	return func(f *Fetcher) {
+		f.git = path
+	}
+}
+
+// WithTempDir sets the directory in which temporary source directories are
+// created. If empty, [os.TempDir] is used.
+func WithTempDir(dir string) FetcherOpt {
+	return func(f *Fetcher) {
+		f.tmpDir = dir
+	}
+}
+
+// WithMaxSize sets the maximum size of an extracted archive, in bytes.
+func WithMaxSize(size int64) FetcherOpt {
+	return func(f *Fetcher) {
+		f.maxSize = size
+	}
+}
+
+// Fetch fetches the remote source into a new temporary directory. The caller
+// must call [Source.Close] to remove it.
+func (f *Fetcher) Fetch(ctx context.Context, ref string) (*Source, error) {
+	var fetch func(ctx context.Context, ref, dir string) (string, error)
+
+	switch {
+	case strings.HasPrefix(ref, prefixGit):
+		fetch = f.fetchGit
+	case strings.HasPrefix(ref, schemeOCI):
+		fetch = f.fetchOCI
+	case strings.HasPrefix(ref, schemeHTTP), strings.HasPrefix(ref, schemeHTTPS):
+		fetch = f.fetchArchive
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedSource, ref)
+	}
+
+	dir, err := os.MkdirTemp(f.tmpDir, "kat-source-")
+	if err != nil {
+		return nil, fmt.Errorf("create temporary directory: %w", err)
+	}
+
+	path, err := fetch(ctx, ref, dir)
+	if err != nil {
+		//nolint:errcheck // Best effort cleanup.
+		os.RemoveAll(dir)
+
+		return nil, err
+	}
+
+	return &Source{Ref: ref, Dir: dir, Path: path}, nil
+}
@@ -0,0 +1,339 @@
+package source_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/macropower/kat/pkg/source"
+)
+
+func TestIsRemote(t *testing.T) {
+	t.Parallel()
+
+	tcs := map[string]bool{
+		"oci://ghcr.io/org/chart:1.2.3":                  true,
+		"https://example.com/chart-1.2.3.tgz":            true,
+		"git::https://github.com/org/repo//charts?ref=1": true,
+		".":                 false,
+		"./example/helm":    false,
+		"/abs/path":         false,
+		"example.com/chart": false,
+	}
+
+	for ref, want := range tcs {
+		t.Run(ref, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, want, source.IsRemote(ref))
+		})
+	}
+}
+
+func TestSource_Root(t *testing.T) {
+	t.Parallel()
+
+	dir := filepath.Join(t.TempDir(), "source")
+	require.NoError(t, os.Mkdir(dir, 0o755))
+
+	src := &source.Source{Dir: dir}
+
+	root, err := src.Root()
+	require.NoError(t, err)
+
+	again, err := src.Root()
+	require.NoError(t, err)
+	assert.Same(t, root, again)
+
+	require.NoError(t, src.Close())
+	assert.NoDirExists(t, dir)
+
+	// The root is closed with the source.
+	_, err = root.Stat(".")
+	require.Error(t, err)
+}
+
+func TestFetcher_Fetch(t *testing.T) {
+	t.Parallel()
+
+	chart := tarball(t, map[string]string{
+		"mychart/Chart.yaml":          "name: mychart\nversion: 1.2.3\n",
+		"mychart/templates/demo.yaml": "kind: ConfigMap\n",
+	})
+	chartDigest := digest(chart)
+
+	manifest, err := json.Marshal(map[string]any{
+		"schemaVersion": 2,
+		"layers": []map[string]any{{
+			"mediaType": "application/vnd.cncf.helm.chart.content.v1.tar+gzip",
+			"digest":    chartDigest,
+			"size":      len(chart),
+		}},
+	})
+	require.NoError(t, err)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/files/chart.tgz", func(w http.ResponseWriter, _ *http.Request) {
+		w.Write(chart) //nolint:errcheck // Test server.
+	})
+	mux.HandleFunc("/files/flat.tgz", func(w http.ResponseWriter, _ *http.Request) {
+		w.Write(tarball(t, map[string]string{"kustomization.yaml": "resources: []\n"})) //nolint:errcheck // Test server.
+	})
+	mux.HandleFunc("/files/escape.tgz", func(w http.ResponseWriter, _ *http.Request) {
+		w.Write(tarball(t, map[string]string{"../escape.yaml": "kind: ConfigMap\n"})) //nolint:errcheck // Test server.
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "repository:charts/mychart:pull", r.URL.Query().Get("scope"))
+		fmt.Fprint(w, `{"token":"anonymous"}`)
+	})
+	mux.HandleFunc("/v2/charts/mychart/", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer anonymous" {
+			scheme := "https"
+			if r.TLS == nil {
+				scheme = "http"
+			}
+
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf(
+				`Bearer realm="%s://%s/token",service="test",scope="repository:charts/mychart:pull"`, scheme, r.Host))
+			w.WriteHeader(http.StatusUnauthorized)
+
+			return
+		}
+
+		switch r.URL.Path {
+		case "/v2/charts/mychart/manifests/1.2.3":
+			w.Write(manifest) //nolint:errcheck // Test server.
+		case "/v2/charts/mychart/blobs/" + chartDigest:
+			w.Write(chart) //nolint:errcheck // Test server.
+		default:
+			http.NotFound(w, r)
+		}
+	})
+
+	srv := httptest.NewTLSServer(mux)
+	t.Cleanup(srv.Close)
+
+	host := strings.TrimPrefix(srv.URL, "https://")
+
+	plainSrv := httptest.NewServer(mux)
+	t.Cleanup(plainSrv.Close)
+
+	plainHost := strings.TrimPrefix(plainSrv.URL, "http://")
+	repo, sha := gitRepo(t)
+
+	tcs := map[string]struct {
+		ref       string
+		wantPath  string
+		wantFiles []string
+		noFiles   []string
+		opts      []source.FetcherOpt
+		wantErr   bool
+	}{
+		"archive": {
+			ref:       srv.URL + "/files/chart.tgz",
+			wantPath:  "mychart",
+			wantFiles: []string{"mychart/Chart.yaml", "mychart/templates/demo.yaml"},
+		},
+		"archive without top-level directory": {
+			ref:       srv.URL + "/files/flat.tgz",
+			wantPath:  ".",
+			wantFiles: []string{"kustomization.yaml"},
+		},
+		"archive escaping the directory": {
+			ref:     srv.URL + "/files/escape.tgz",
+			wantErr: true,
+		},
+		"archive not found": {
+			ref:     srv.URL + "/files/missing.tgz",
+			wantErr: true,
+		},
+		"oci": {
+			ref:       "oci://" + host + "/charts/mychart:1.2.3",
+			wantPath:  "mychart",
+			wantFiles: []string{"mychart/Chart.yaml", "mychart/templates/demo.yaml"},
+		},
+		"oci tag not found": {
+			ref:     "oci://" + host + "/charts/mychart:9.9.9",
+			wantErr: true,
+		},
+		"oci over plain http": {
+			ref:       "oci://" + plainHost + "/charts/mychart:1.2.3",
+			opts:      []source.FetcherOpt{source.WithPlainHTTP(true)},
+			wantPath:  "mychart",
+			wantFiles: []string{"mychart/Chart.yaml", "mychart/templates/demo.yaml"},
+		},
+		"oci over https from a plain http registry": {
+			ref:     "oci://" + plainHost + "/charts/mychart:1.2.3",
+			wantErr: true,
+		},
+		"oci without repository": {
+			ref:     "oci://" + host,
+			wantErr: true,
+		},
+		"git": {
+			ref:       "git::file://" + repo + "//charts/app?ref=v1",
+			wantPath:  "charts/app",
+			wantFiles: []string{"charts/app/Chart.yaml"},
+		},
+		"git without path": {
+			ref:       "git::file://" + repo,
+			wantPath:  ".",
+			wantFiles: []string{"charts/app/Chart.yaml"},
+		},
+		"git commit": {
+			ref:       "git::file://" + repo + "//charts/app?ref=" + sha,
+			wantPath:  "charts/app",
+			wantFiles: []string{"charts/app/Chart.yaml"},
+			noFiles:   []string{"charts/app/values.yaml"},
+		},
+		"git default branch": {
+			ref:       "git::file://" + repo + "//charts/app",
+			wantPath:  "charts/app",
+			wantFiles: []string{"charts/app/Chart.yaml", "charts/app/values.yaml"},
+		},
+		"git ref not found": {
+			ref:     "git::file://" + repo + "?ref=v2",
+			wantErr: true,
+		},
+		"local path": {
+			ref:     "./example/helm",
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			if strings.HasPrefix(tc.ref, "git::") && repo == "" {
+				t.Skip("git is not installed")
+			}
+
+			opts := append([]source.FetcherOpt{
+				source.WithHTTPClient(srv.Client()),
+				source.WithTempDir(t.TempDir()),
+			}, tc.opts...)
+
+			f := source.NewFetcher(opts...)
+
+			src, err := f.Fetch(t.Context(), tc.ref)
+			if tc.wantErr {
+				require.Error(t, err)
+
+				return
+			}
+
+			require.NoError(t, err)
+			t.Cleanup(func() {
+				require.NoError(t, src.Close())
+				assert.NoDirExists(t, src.Dir)
+			})
+
+			assert.Equal(t, tc.ref, src.Ref)
+			assert.Equal(t, tc.wantPath, src.Path)
+
+			for _, file := range tc.wantFiles {
+				assert.FileExists(t, filepath.Join(src.Dir, file))
+			}
+
+			for _, file := range tc.noFiles {
+				assert.NoFileExists(t, filepath.Join(src.Dir, file))
+			}
+		})
+	}
+}
+
+// tarball returns a gzipped tarball containing the files.
+func tarball(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+
+	for name, content := range files {
+		err := tw.WriteHeader(&tar.Header{
+			Name:     name,
+			Mode:     0o644,
+			Size:     int64(len(content)),
+			Typeflag: tar.TypeReg,
+		})
+		require.NoError(t, err)
+
+		_, err = tw.Write([]byte(content))
+		require.NoError(t, err)
+	}
+
+	require.NoError(t, tw.Close())
+	require.NoError(t, gz.Close())
+
+	return buf.Bytes()
+}
+
+func digest(b []byte) string {
+	sum := sha256.Sum256(b)
+
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// gitRepo creates a Git repository with a chart, tagged v1, and a later
+// commit that adds a values file. It returns the repository and the commit
+// SHA of v1, or empty strings if git is not installed.
+func gitRepo(t *testing.T) (string, string) {
+	t.Helper()
+
+	_, err := exec.LookPath("git")
+	if err != nil {
+		return "", ""
+	}
+
+	dir := t.TempDir()
+
+	err = os.MkdirAll(filepath.Join(dir, "charts", "app"), 0o755)
+	require.NoError(t, err)
+
+	err = os.WriteFile(filepath.Join(dir, "charts", "app", "Chart.yaml"), []byte("name: app\n"), 0o644)
+	require.NoError(t, err)
+
+	git := func(args ...string) string {
+		t.Helper()
+
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(out))
+
+		return strings.TrimSpace(string(out))
+	}
+
+	commit := []string{"-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "--quiet"}
+
+	git("init", "--quiet")
+	git("add", ".")
+	git(append(commit, "-m", "init")...)
+	git("tag", "v1")
+
+	err = os.WriteFile(filepath.Join(dir, "charts", "app", "values.yaml"), []byte("replicas: 1\n"), 0o644)
+	require.NoError(t, err)
+
+	git("add", ".")
+	git(append(commit, "-m", "values")...)
+
+	return dir, git("rev-parse", "v1")
+}
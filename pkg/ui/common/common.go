@@ -17,10 +17,14 @@ type Commander interface {
 	Subscribe(ch chan<- command.Event)
 	GetProfiles() map[string]*profile.Profile
 	GetCurrentProfile() (string, *profile.Profile)
+	GetCurrentVariant() string
 	GetPath() string
+	GetWorkspace() string
+	FindVariants(path, profileName string) ([]string, error)
 	FindProfiles(path string) ([]command.ProfileMatch, error)
+	RunMatrixContext(ctx context.Context, names ...string) (command.MatrixOutput, error)
 	ConfigureContext(ctx context.Context, opts ...command.RunnerOpt) error
-	RunPluginContext(ctx context.Context, name string) command.Output
+	RunPluginContext(ctx context.Context, name string, opts ...command.PluginRunOpt) command.Output
 	FS() (*command.FilteredFS, error)
 }
 
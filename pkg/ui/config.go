@@ -9,6 +9,7 @@ import (
 	"go.jacobcolvin.com/niceyaml/style"
 
 	"github.com/macropower/kat/pkg/keys"
+	"github.com/macropower/kat/pkg/kube"
 	"github.com/macropower/kat/pkg/ui/common"
 	"github.com/macropower/kat/pkg/ui/menu"
 	"github.com/macropower/kat/pkg/ui/pager"
@@ -24,6 +25,12 @@ type Config struct {
 	Themes map[string]ThemeConfig `json:"themes,omitempty" jsonschema:"title=Themes"`
 	// UI contains general UI display settings.
 	UI *UIConfig `json:"ui,omitempty" jsonschema:"title=UI"`
+	// Queries are saved queries that filter the resource list when their keys
+	// are pressed. Each query is a field selector, label selector, or CEL
+	// expression.
+	Queries []*resourcelist.SavedQuery `json:"queries,omitempty" jsonschema:"title=Saved Queries"`
+	// Secrets configures masking of secret values in the pager.
+	Secrets *SecretsConfig `json:"secrets,omitempty" jsonschema:"title=Secrets"`
 }
 
 func NewConfig() *Config {
@@ -45,6 +52,14 @@ type UIConfig struct {
 	// Theme specifies the theme name to use. This can be a custom theme added under `themes`,
 	// or a built-in niceyaml theme (e.g., "github-dark", "github", "charm").
 	Theme string `json:"theme,omitempty" jsonschema:"title=Theme Name"`
+	// GroupBy specifies how resources are initially grouped in the list.
+	GroupBy string `json:"groupBy,omitempty" jsonschema:"title=Group By,enum=none,enum=namespace,enum=kind,enum=label,enum=tree,default=none"`
+	// GroupLabel specifies the label used to group resources when grouping by label.
+	GroupLabel string `json:"groupLabel,omitempty" jsonschema:"title=Group Label,default=app.kubernetes.io/component"`
+	// SortBy specifies how resources are initially sorted in the list.
+	// The `apply` order lists resources in the order they should be applied,
+	// e.g. with Namespaces and CustomResourceDefinitions first.
+	SortBy string `json:"sortBy,omitempty" jsonschema:"title=Sort By,enum=name,enum=apply,enum=render,default=name"`
 }
 
 func (c *UIConfig) EnsureDefaults() {
@@ -64,6 +79,15 @@ func (c UIConfig) JSONSchemaExtend(schema *jsonschema.Schema) {
 	schema.Properties.Set("minimumDelay", minimumDelay)
 }
 
+// SecretsConfig configures masking of secret values in the pager.
+type SecretsConfig struct {
+	// Mask hides secret values until they are revealed.
+	Mask *bool `json:"mask,omitempty" jsonschema:"title=Mask Secret Values,default=true"`
+	// Kinds contains kinds of resources with secret values, in addition to
+	// Secrets, e.g. SealedSecrets.
+	Kinds []kube.SecretKind `json:"kinds,omitempty" jsonschema:"title=Secret Kinds"`
+}
+
 // ThemeConfig defines custom theme configuration.
 type ThemeConfig struct {
 	// Styles contains niceyaml style entries for syntax highlighting.
@@ -87,12 +111,53 @@ func (c *Config) EnsureDefaults() {
 
 	c.UI.EnsureDefaults()
 
+	if c.Secrets == nil {
+		c.Secrets = &SecretsConfig{}
+	}
+
+	setDefaultBool(&c.Secrets.Mask, true)
+
 	// Set defaults for UIConfig in this Config context only.
 	setDefaultBool(&c.UI.Compact, false)
 	setDefaultBool(&c.UI.WordWrap, true)
 	setDefaultBool(&c.UI.LineNumbers, true)
 }
 
+// Validate validates the configuration, including parsing saved queries and
+// checking that their keys do not conflict with list key bindings.
+func (c *Config) Validate() error {
+	queryBinds := make([]keys.KeyBind, 0, len(c.Queries))
+
+	for _, q := range c.Queries {
+		err := q.Validate()
+		if err != nil {
+			return fmt.Errorf("saved queries: %w", err)
+		}
+
+		queryBinds = append(queryBinds, q.KeyBind())
+	}
+
+	if c.KeyBinds == nil {
+		return nil
+	}
+
+	err := c.KeyBinds.Validate()
+	if err != nil {
+		return err
+	}
+
+	err = keys.ValidateBinds(
+		c.KeyBinds.Common.GetKeyBinds(),
+		c.KeyBinds.List.GetKeyBinds(),
+		queryBinds,
+	)
+	if err != nil {
+		return fmt.Errorf("saved queries: %w", err)
+	}
+
+	return nil
+}
+
 func setDefaultBool(b **bool, value bool) {
 	if *b == nil {
 		*b = &value
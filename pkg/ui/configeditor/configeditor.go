@@ -20,6 +20,7 @@ import (
 
 const (
 	FieldFile      = "file"
+	FieldVariant   = "variant"
 	FieldExtraArgs = "extraArgs"
 )
 
@@ -30,20 +31,25 @@ type Model struct {
 	profiles            map[string]*profile.Profile
 	selectedProfileName *string
 	selectedPath        *string
+	selectedVariant     *string
 	height              int
 }
 
 type Result struct {
 	File      string
 	Profile   string
+	Variant   string
 	ExtraArgs []string
 }
 
 type Commander interface {
 	GetProfiles() map[string]*profile.Profile
 	GetCurrentProfile() (string, *profile.Profile)
+	GetCurrentVariant() string
 	GetPath() string
+	GetWorkspace() string
 	FindProfiles(path string) ([]command.ProfileMatch, error)
+	FindVariants(path, profileName string) ([]string, error)
 	FS() (*command.FilteredFS, error)
 }
 
@@ -60,6 +66,9 @@ func NewModel(cmd Commander, t huh.Theme, km *huh.KeyMap) (Model, error) {
 	pName, _ := cmd.GetCurrentProfile()
 	m.selectedProfileName = &pName
 
+	variant := cmd.GetCurrentVariant()
+	m.selectedVariant = &variant
+
 	m.cmd = cmd
 
 	fsys, err := cmd.FS()
@@ -70,17 +79,42 @@ func NewModel(cmd Commander, t huh.Theme, km *huh.KeyMap) (Model, error) {
 	// Start the file picker in the parent of the current path.
 	startDir := filepath.Dir(cmd.GetPath())
 
+	selectedPath = cmd.GetPath()
+
+	var fileField huh.Field = NewFilePicker(filepicker.New(fsys)).
+		Key(FieldFile).
+		Picking(true).
+		CurrentDirectory(startDir).
+		Title("Select a file or directory").
+		ShowPermissions(true).
+		ShowSize(true).
+		DirAllowed(true).
+		FileAllowed(true)
+
+	if workspace := cmd.GetWorkspace(); workspace != "" {
+		// Select from the projects in the workspace instead of browsing.
+		projects, err := fsys.FindProjects(workspace)
+		if err != nil {
+			return Model{}, fmt.Errorf("finding projects: %w", err)
+		}
+
+		fileField = huh.NewSelect[string]().
+			Key(FieldFile).
+			Title(fmt.Sprintf("Select a project (%d in %s)", len(projects), workspace)).
+			Options(projectOptions(projects)...).
+			Value(m.selectedPath)
+	}
+
 	m.form = huh.NewForm(
 		huh.NewGroup(
-			NewFilePicker(filepicker.New(fsys)).
-				Key(FieldFile).
-				Picking(true).
-				CurrentDirectory(startDir).
-				Title("Select a file or directory").
-				ShowPermissions(true).
-				ShowSize(true).
-				DirAllowed(true).
-				FileAllowed(true),
+			fileField,
+
+			huh.NewSelect[string]().
+				Key(FieldVariant).
+				Title("Variant").
+				Inline(true).
+				Value(m.selectedVariant).
+				OptionsFunc(m.variantOptions, []*string{m.selectedPath, m.selectedProfileName}),
 
 			huh.NewText().
 				Key(FieldExtraArgs).
@@ -153,6 +187,11 @@ func (m *Model) Update(msg tea.Msg) tea.Cmd {
 
 			matchedProfile := profiles[0]
 
+			if matchedProfile.Name != *m.selectedProfileName {
+				// Variants are specific to each profile.
+				*m.selectedVariant = ""
+			}
+
 			*m.selectedPath = filePath
 			*m.selectedProfileName = matchedProfile.Name
 
@@ -194,10 +233,48 @@ func (m *Model) Result() Result {
 	return Result{
 		File:      m.form.GetString(FieldFile),
 		Profile:   *m.selectedProfileName,
+		Variant:   *m.selectedVariant,
 		ExtraArgs: extraArgs,
 	}
 }
 
+// projectOptions returns an option for each project, labeled with the
+// profiles that match it.
+func projectOptions(projects []command.Project) []huh.Option[string] {
+	options := make([]huh.Option[string], 0, len(projects))
+	for _, p := range projects {
+		label := fmt.Sprintf("%s (%s)", p.Path, strings.Join(p.Profiles, ", "))
+		options = append(options, huh.NewOption(label, p.Path))
+	}
+
+	return options
+}
+
+// variantOptions returns the variants of the selected profile for the
+// selected path. The first option uses no variant.
+func (m *Model) variantOptions() []huh.Option[string] {
+	options := []huh.Option[string]{huh.NewOption("none", "")}
+
+	if *m.selectedProfileName == "" {
+		return options
+	}
+
+	variants, err := m.cmd.FindVariants(*m.selectedPath, *m.selectedProfileName)
+	if err != nil {
+		slog.Debug("error finding variants",
+			slog.Any("error", err),
+		)
+
+		return options
+	}
+
+	for _, name := range variants {
+		options = append(options, huh.NewOption(name, name))
+	}
+
+	return options
+}
+
 func (m *Model) View() string {
 	if m.form.State == huh.StateCompleted {
 		return lipgloss.NewStyle().
@@ -27,6 +27,14 @@ type KeyBinds struct {
 	ToggleDiffMode *keys.KeyBind `json:"toggleDiffMode,omitempty"`
 	ToggleViewMode *keys.KeyBind `json:"toggleViewMode,omitempty"`
 	ToggleWordWrap *keys.KeyBind `json:"toggleWordWrap,omitempty"`
+	ToggleSplit    *keys.KeyBind `json:"toggleSplit,omitempty"`
+
+	// Secrets.
+	RevealSecrets *keys.KeyBind `json:"revealSecrets,omitempty"`
+
+	// References.
+	GoToReference *keys.KeyBind `json:"goToReference,omitempty"`
+	ShowReferrers *keys.KeyBind `json:"showReferrers,omitempty"`
 }
 
 func (kb *KeyBinds) EnsureDefaults() {
@@ -86,6 +94,22 @@ func (kb *KeyBinds) EnsureDefaults() {
 		keys.NewBind("word wrap",
 			keys.New("w"),
 		))
+	keys.SetDefaultBind(&kb.ToggleSplit,
+		keys.NewBind("split view",
+			keys.New("|"),
+		))
+	keys.SetDefaultBind(&kb.RevealSecrets,
+		keys.NewBind("reveal secrets",
+			keys.New("s"),
+		))
+	keys.SetDefaultBind(&kb.GoToReference,
+		keys.NewBind("go to reference",
+			keys.New(">"),
+		))
+	keys.SetDefaultBind(&kb.ShowReferrers,
+		keys.NewBind("show referrers",
+			keys.New("<"),
+		))
 }
 
 func (kb *KeyBinds) GetKeyBinds() []keys.KeyBind {
@@ -103,6 +127,10 @@ func (kb *KeyBinds) GetKeyBinds() []keys.KeyBind {
 		*kb.ToggleDiffMode,
 		*kb.ToggleViewMode,
 		*kb.ToggleWordWrap,
+		*kb.ToggleSplit,
+		*kb.RevealSecrets,
+		*kb.GoToReference,
+		*kb.ShowReferrers,
 	}
 }
 
@@ -124,6 +152,12 @@ func NewKeyHandler(kb *KeyBinds, ckb *common.KeyBinds) *KeyHandler {
 func (h *KeyHandler) HandlePagerKeys(m *Model, msg tea.KeyMsg) tea.Cmd {
 	key := msg.String()
 
+	if m.IsShowingRelated() {
+		if i, ok := relatedIndex(key); ok {
+			return m.OpenRelated(i)
+		}
+	}
+
 	switch {
 	case h.kb.Home.Match(key):
 		m.GoToTop()
@@ -172,6 +206,18 @@ func (h *KeyHandler) HandlePagerKeys(m *Model, msg tea.KeyMsg) tea.Cmd {
 
 	case h.kb.ToggleWordWrap.Match(key):
 		m.ToggleWordWrap()
+
+	case h.kb.ToggleSplit.Match(key):
+		return m.ToggleSplit()
+
+	case h.kb.RevealSecrets.Match(key):
+		return m.ToggleSecrets()
+
+	case h.kb.GoToReference.Match(key):
+		return m.ShowReferences()
+
+	case h.kb.ShowReferrers.Match(key):
+		return m.ShowReferrers()
 	}
 
 	return nil
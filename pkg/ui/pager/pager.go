@@ -16,13 +16,20 @@ import (
 
 	"github.com/macropower/kat/pkg/keys"
 	"github.com/macropower/kat/pkg/kube"
+	"github.com/macropower/kat/pkg/profile"
 	"github.com/macropower/kat/pkg/ui/common"
 	"github.com/macropower/kat/pkg/ui/statusbar"
 	"github.com/macropower/kat/pkg/ui/theme"
 	"github.com/macropower/kat/pkg/ui/yamls"
 )
 
-const statusBarHeight = 1
+const (
+	statusBarHeight = 1
+
+	// maxDiagnosticLines is the maximum height of the diagnostics shown
+	// below the document.
+	maxDiagnosticLines = 5
+)
 
 type ViewState int
 
@@ -45,18 +52,30 @@ type RevisionMsg struct {
 type ExitSearchMsg struct{}
 
 type Model struct {
+	theme           *theme.Theme
 	keyBinds        *common.KeyBinds
 	keyHandler      *KeyHandler
+	graph           *kube.Graph
+	secrets         *kube.SecretMasker
 	CurrentDocument yamls.Document
-	StatusMessage   statusbar.StatusMessageModel
-	Help            statusbar.HelpModel
-	statusBar       *statusbar.StatusBarRenderer
-	searchInput     textinput.Model
-	viewport        yamlviewport.Model
-	height          int
-	width           int
-	ViewState       ViewState
-	showingResult   bool
+	// SplitDocument is shown next to the current document in the split view.
+	SplitDocument yamls.Document
+	StatusMessage statusbar.StatusMessageModel
+	Help          statusbar.HelpModel
+	statusBar     *statusbar.StatusBarRenderer
+	searchInput   textinput.Model
+	viewport      yamlviewport.Model
+	splitViewport yamlviewport.Model
+	// previous is the previous revision of the current document, if any.
+	previous      *yamls.Document
+	relatedItems  []relatedItem
+	height        int
+	width         int
+	ViewState     ViewState
+	related       relatedMode
+	split         splitMode
+	showingResult bool
+	revealed      bool
 }
 
 type Config struct {
@@ -65,6 +84,9 @@ type Config struct {
 	CKeyBinds *common.KeyBinds
 	Cmd       common.Commander
 	Printer   *niceyaml.Printer
+	// Secrets masks secret values until they are revealed. If nil, secret
+	// values are always shown.
+	Secrets *kube.SecretMasker
 }
 
 func NewModel(c Config) Model {
@@ -79,6 +101,10 @@ func NewModel(c Config) Model {
 	// Disable yamlviewport's built-in KeyMap — kat's key system routes events.
 	vp.KeyMap = yamlviewport.KeyMap{}
 
+	// The split viewport is scrolled together with the main viewport.
+	svp := yamlviewport.New(opts...)
+	svp.KeyMap = yamlviewport.KeyMap{}
+
 	kbr := &keys.KeyBindRenderer{}
 	ckb := c.CKeyBinds
 	kb := c.KeyBinds
@@ -102,15 +128,21 @@ func NewModel(c Config) Model {
 		*kb.ToggleDiffMode,
 		*kb.ToggleViewMode,
 		*kb.ToggleWordWrap,
+		*kb.ToggleSplit,
+		*kb.RevealSecrets,
 		*ckb.Escape,
 		*ckb.Help,
 		*ckb.Quit,
 	)
+	kbr.AddColumn(
+		*kb.GoToReference,
+		*kb.ShowReferrers,
+	)
 
 	// Add plugin keybinds column if plugins are available.
 	_, prof := c.Cmd.GetCurrentProfile()
 	if prof != nil {
-		pluginBinds := prof.GetPluginKeyBinds()
+		pluginBinds := prof.GetPluginKeyBinds(profile.PluginScopePager)
 		// Truncate to maximum of 6 plugin keybinds (shown in help).
 		if len(pluginBinds) > 6 {
 			pluginBinds = pluginBinds[:6]
@@ -130,13 +162,16 @@ func NewModel(c Config) Model {
 	si.Focus()
 
 	m := Model{
-		keyBinds:    c.CKeyBinds,
-		keyHandler:  NewKeyHandler(c.KeyBinds, c.CKeyBinds),
-		Help:        statusbar.NewHelpModel(statusbar.NewHelpRenderer(c.Theme, kbr)),
-		statusBar:   statusbar.NewStatusBarRenderer(c.Theme, 0),
-		ViewState:   StateReady,
-		viewport:    vp,
-		searchInput: si,
+		theme:         c.Theme,
+		keyBinds:      c.CKeyBinds,
+		keyHandler:    NewKeyHandler(c.KeyBinds, c.CKeyBinds),
+		secrets:       c.Secrets,
+		Help:          statusbar.NewHelpModel(statusbar.NewHelpRenderer(c.Theme, kbr)),
+		statusBar:     statusbar.NewStatusBarRenderer(c.Theme, 0),
+		ViewState:     StateReady,
+		viewport:      vp,
+		splitViewport: svp,
+		searchInput:   si,
 	}
 
 	return m
@@ -152,16 +187,45 @@ func (m *Model) Update(msg tea.Msg) tea.Cmd {
 
 	switch msg := msg.(type) {
 	case LoadDocumentMsg:
+		// Revealed values only apply to the document they were revealed in.
+		m.revealed = false
 		m.CurrentDocument = msg.Document
-		m.SetContent(msg.Document.Body)
+		m.SetContent(m.displaySource(msg.Document))
+
+		m.related = relatedNone
+		m.relatedItems = nil
+		m.previous = nil
+		m.split = splitNone
+
+		// The diagnostics height may have changed.
+		return m.SetSize(m.width, m.height)
+
+	case LoadSplitMsg:
+		m.revealed = false
+		m.CurrentDocument = msg.Right
+		m.SetContent(m.displaySource(msg.Right))
+
+		m.related = relatedNone
+		m.relatedItems = nil
+		m.previous = nil
+		m.openSplit(splitDocument, msg.Left)
 
 		return nil
 
 	case RevisionMsg:
+		previous := m.CurrentDocument
+		m.previous = &previous
+
 		m.CurrentDocument = msg.Document
-		m.AddRevision(msg.Document.Body)
+		m.AddRevision(m.displaySource(msg.Document))
 
-		return nil
+		if m.split == splitRevision {
+			left := previous
+			left.Title += " (previous)"
+			m.openSplit(splitRevision, left)
+		}
+
+		return m.SetSize(m.width, m.height)
 
 	case ExitSearchMsg:
 		m.ExitSearch()
@@ -189,6 +253,8 @@ func (m *Model) Update(msg tea.Msg) tea.Cmd {
 	m.viewport, cmd = m.viewport.Update(msg)
 	cmds = append(cmds, cmd)
 
+	m.syncSplit()
+
 	return tea.Batch(cmds...)
 }
 
@@ -206,15 +272,64 @@ func (m Model) View() string {
 		bottom = lipgloss.JoinVertical(lipgloss.Top, bottomBar, m.helpView())
 	}
 
+	if diagnostics := m.diagnosticsView(); diagnostics != "" {
+		bottom = lipgloss.JoinVertical(lipgloss.Top, diagnostics, bottom)
+	}
+
+	if related := m.relatedView(); related != "" {
+		bottom = lipgloss.JoinVertical(lipgloss.Top, related, bottom)
+	}
+
 	return lipgloss.JoinVertical(
 		lipgloss.Top,
-		m.viewport.View(),
+		m.documentView(),
 		bottom,
 	)
 }
 
 func (m Model) chromeHeight() int {
-	return statusBarHeight + m.Help.Height()
+	return statusBarHeight + m.Help.Height() + m.diagnosticsHeight() + m.relatedHeight()
+}
+
+func (m Model) diagnosticsHeight() int {
+	return min(len(m.CurrentDocument.Diagnostics), maxDiagnosticLines)
+}
+
+// diagnosticsView renders one line per diagnostic of the current document.
+// If there are too many diagnostics, the last line shows how many were
+// omitted.
+func (m Model) diagnosticsView() string {
+	height := m.diagnosticsHeight()
+	if height == 0 {
+		return ""
+	}
+
+	diags := m.CurrentDocument.Diagnostics
+
+	shown := diags
+	if len(diags) > height {
+		shown = diags[:height-1]
+	}
+
+	lines := make([]string, 0, height)
+
+	for _, d := range shown {
+		severityStyle := m.theme.Style(style.TextAccent)
+		if d.Severity == kube.SeverityError {
+			severityStyle = m.theme.Style(style.TextError)
+		}
+
+		line := severityStyle.Render(string(d.Severity)) + " " +
+			m.theme.Style(style.TextSubtleDim).Render(d.Source) + " " +
+			m.theme.Style(style.TextSubtle).Render(d.String())
+		lines = append(lines, ansi.Truncate(line, m.width, m.theme.Ellipsis))
+	}
+
+	if omitted := len(diags) - len(shown); omitted > 0 {
+		lines = append(lines, m.theme.Style(style.TextSubtleDim).Render(fmt.Sprintf("and %d more", omitted)))
+	}
+
+	return lipgloss.JoinVertical(lipgloss.Left, lines...)
 }
 
 func (m *Model) SetSize(w, h int) tea.Cmd {
@@ -225,8 +340,16 @@ func (m *Model) SetSize(w, h int) tea.Cmd {
 
 	m.searchInput.SetWidth(w - ansi.StringWidth(m.searchInput.Prompt))
 
-	m.viewport.SetWidth(w)
 	m.viewport.SetHeight(h - m.chromeHeight())
+	m.splitViewport.SetHeight(h - m.chromeHeight())
+
+	if m.split == splitNone {
+		m.viewport.SetWidth(w)
+	} else {
+		left, right := splitWidths(w)
+		m.splitViewport.SetWidth(left)
+		m.viewport.SetWidth(right)
+	}
 
 	return nil
 }
@@ -262,6 +385,12 @@ func (m *Model) Unload() {
 	}
 
 	m.showingResult = false
+	m.revealed = false
+	m.related = relatedNone
+	m.relatedItems = nil
+	m.previous = nil
+	m.split = splitNone
+	m.SplitDocument = yamls.Document{}
 	m.viewport.ClearRevisions()
 	m.viewport.ClearSearch()
 
@@ -304,7 +433,7 @@ func (m Model) statusBarView() string {
 
 	m.statusBar.Apply(opts...)
 
-	return m.statusBar.RenderWithScroll(m.CurrentDocument.Title, m.viewport.ScrollPercent())
+	return m.statusBar.RenderWithScroll(m.title(), m.viewport.ScrollPercent())
 }
 
 func (m Model) helpView() string {
@@ -391,41 +520,49 @@ func (m *Model) ExitSearch() {
 // MoveUp moves the viewport up.
 func (m *Model) MoveUp() {
 	m.viewport.ScrollUp(1)
+	m.syncSplit()
 }
 
 // MoveDown moves the viewport down.
 func (m *Model) MoveDown() {
 	m.viewport.ScrollDown(1)
+	m.syncSplit()
 }
 
 // PageUp moves the viewport up by one page.
 func (m *Model) PageUp() {
 	m.viewport.PageUp()
+	m.syncSplit()
 }
 
 // PageDown moves the viewport down by one page.
 func (m *Model) PageDown() {
 	m.viewport.PageDown()
+	m.syncSplit()
 }
 
 // GoToTop moves to the top of the document.
 func (m *Model) GoToTop() {
 	m.viewport.GotoTop()
+	m.syncSplit()
 }
 
 // GoToBottom moves to the bottom of the document.
 func (m *Model) GoToBottom() {
 	m.viewport.GotoBottom()
+	m.syncSplit()
 }
 
 // HalfPageUp moves the viewport up by half a page.
 func (m *Model) HalfPageUp() {
 	m.viewport.HalfPageUp()
+	m.syncSplit()
 }
 
 // HalfPageDown moves the viewport down by half a page.
 func (m *Model) HalfPageDown() {
 	m.viewport.HalfPageDown()
+	m.syncSplit()
 }
 
 // NextMatch goes to the next search match.
@@ -436,6 +573,7 @@ func (m *Model) NextMatch() tea.Cmd {
 	}
 
 	m.viewport.SearchNext()
+	m.syncSplit()
 
 	idx := m.viewport.SearchIndex()
 	statusMsg := fmt.Sprintf("match %d/%d", idx+1, count)
@@ -451,6 +589,7 @@ func (m *Model) PrevMatch() tea.Cmd {
 	}
 
 	m.viewport.SearchPrevious()
+	m.syncSplit()
 
 	idx := m.viewport.SearchIndex()
 	statusMsg := fmt.Sprintf("match %d/%d", idx+1, count)
@@ -458,9 +597,10 @@ func (m *Model) PrevMatch() tea.Cmd {
 	return m.sendStatusMessage(statusMsg, statusbar.StyleSuccess)
 }
 
-// CopyContent copies the current document content to clipboard.
+// CopyContent copies the current document content to clipboard. Secret
+// values are copied masked, unless they have been revealed.
 func (m *Model) CopyContent() tea.Cmd {
-	content := m.CurrentDocument.Body.Content()
+	content := m.copyContent()
 
 	return tea.Sequence(
 		tea.SetClipboard(content),
@@ -491,4 +631,6 @@ func (m *Model) ToggleViewMode() {
 // ToggleWordWrap toggles word wrapping.
 func (m *Model) ToggleWordWrap() {
 	m.viewport.ToggleWordWrap()
+	m.splitViewport.ToggleWordWrap()
+	m.syncSplit()
 }
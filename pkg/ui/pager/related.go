@@ -0,0 +1,238 @@
+package pager
+
+import (
+	"fmt"
+	"strconv"
+
+	"charm.land/lipgloss/v2"
+	"github.com/charmbracelet/x/ansi"
+	"go.jacobcolvin.com/niceyaml/style"
+
+	tea "charm.land/bubbletea/v2"
+
+	"github.com/macropower/kat/pkg/kube"
+	"github.com/macropower/kat/pkg/ui/statusbar"
+)
+
+// maxRelatedItems is the maximum number of related resources shown below the
+// document. Each one can be opened with its number key.
+const maxRelatedItems = 9
+
+// OpenResourceMsg instructs the UI to open a resource in the pager.
+type OpenResourceMsg struct {
+	Resource *kube.Resource
+}
+
+type relatedMode int
+
+const (
+	relatedNone relatedMode = iota
+	relatedReferences
+	relatedReferrers
+)
+
+// String returns the title of the related resources panel.
+func (r relatedMode) String() string {
+	switch r {
+	case relatedReferences:
+		return "references"
+	case relatedReferrers:
+		return "referrers"
+	}
+
+	return ""
+}
+
+// relatedItem is a resource that is related to the current document.
+type relatedItem struct {
+	// Resource is nil if the referenced resource was not rendered.
+	resource *kube.Resource
+	label    string
+	path     string
+}
+
+// SetGraph sets the [kube.Graph] used to find resources that are related to
+// the current document.
+func (m *Model) SetGraph(g *kube.Graph) {
+	m.graph = g
+}
+
+// IsShowingRelated reports whether related resources are shown below the
+// document.
+func (m *Model) IsShowingRelated() bool {
+	return m.related != relatedNone
+}
+
+// CloseRelated hides the related resources.
+func (m *Model) CloseRelated() {
+	if m.related == relatedNone {
+		return
+	}
+
+	m.related = relatedNone
+	m.relatedItems = nil
+	m.SetSize(m.width, m.height)
+}
+
+// ShowReferences shows the resources referenced by the current document. If
+// it references exactly one rendered resource, that resource is opened.
+func (m *Model) ShowReferences() tea.Cmd {
+	r := m.currentResource()
+	if r == nil {
+		return m.sendStatusMessage("no references", statusbar.StyleError)
+	}
+
+	var items []relatedItem
+
+	for _, e := range m.graph.References(r) {
+		item := relatedItem{resource: e.To, path: e.Path}
+		if e.Dangling() {
+			item.label = e.Reference.String()
+		} else {
+			item.label = resourceLabel(e.To)
+		}
+
+		items = append(items, item)
+	}
+
+	return m.showRelated(relatedReferences, items)
+}
+
+// ShowReferrers shows the resources that reference the current document. If
+// it is referenced by exactly one resource, that resource is opened.
+func (m *Model) ShowReferrers() tea.Cmd {
+	r := m.currentResource()
+	if r == nil {
+		return m.sendStatusMessage("no referrers", statusbar.StyleError)
+	}
+
+	var items []relatedItem
+
+	for _, e := range m.graph.Referrers(r) {
+		items = append(items, relatedItem{
+			resource: e.From,
+			label:    resourceLabel(e.From),
+			path:     e.Path,
+		})
+	}
+
+	return m.showRelated(relatedReferrers, items)
+}
+
+// OpenRelated opens the related resource at index i.
+func (m *Model) OpenRelated(i int) tea.Cmd {
+	if i < 0 || i >= len(m.relatedItems) {
+		return nil
+	}
+
+	item := m.relatedItems[i]
+	if item.resource == nil {
+		return m.sendStatusMessage(item.label+" is not rendered", statusbar.StyleError)
+	}
+
+	m.CloseRelated()
+
+	return func() tea.Msg {
+		return OpenResourceMsg{Resource: item.resource}
+	}
+}
+
+func (m *Model) showRelated(mode relatedMode, items []relatedItem) tea.Cmd {
+	if m.related == mode {
+		// Pressing the key again hides the panel.
+		m.CloseRelated()
+
+		return nil
+	}
+
+	if len(items) == 0 {
+		m.CloseRelated()
+
+		return m.sendStatusMessage("no "+mode.String(), statusbar.StyleError)
+	}
+
+	if len(items) == 1 && items[0].resource != nil {
+		m.relatedItems = items
+
+		return m.OpenRelated(0)
+	}
+
+	m.related = mode
+	m.relatedItems = items
+
+	return m.SetSize(m.width, m.height)
+}
+
+// currentResource returns the rendered resource of the current document, or
+// nil if there is none.
+func (m *Model) currentResource() *kube.Resource {
+	if m.graph == nil || m.showingResult {
+		return nil
+	}
+
+	return m.graph.Find(m.CurrentDocument.Object)
+}
+
+func (m Model) relatedHeight() int {
+	if m.related == relatedNone {
+		return 0
+	}
+
+	// Title, items, and the number of omitted items.
+	height := 1 + min(len(m.relatedItems), maxRelatedItems)
+	if len(m.relatedItems) > maxRelatedItems {
+		height++
+	}
+
+	return height
+}
+
+// relatedView renders one numbered line per related resource.
+func (m Model) relatedView() string {
+	if m.related == relatedNone {
+		return ""
+	}
+
+	title := fmt.Sprintf("%s (press a number to open)", m.related)
+
+	lines := []string{
+		ansi.Truncate(m.theme.Style(style.TextAccent).Render(title), m.width, m.theme.Ellipsis),
+	}
+
+	for i, item := range m.relatedItems[:min(len(m.relatedItems), maxRelatedItems)] {
+		labelStyle := m.theme.Style(style.TextSubtle)
+		label := item.label
+
+		if item.resource == nil {
+			labelStyle = m.theme.Style(style.TextError)
+			label += " (not rendered)"
+		}
+
+		line := m.theme.Style(style.TextAccent).Render(strconv.Itoa(i+1)) + " " +
+			labelStyle.Render(label) + " " +
+			m.theme.Style(style.TextSubtleDim).Render(item.path)
+		lines = append(lines, ansi.Truncate(line, m.width, m.theme.Ellipsis))
+	}
+
+	if omitted := len(m.relatedItems) - maxRelatedItems; omitted > 0 {
+		lines = append(lines, m.theme.Style(style.TextSubtleDim).Render(fmt.Sprintf("and %d more", omitted)))
+	}
+
+	return lipgloss.JoinVertical(lipgloss.Left, lines...)
+}
+
+// relatedIndex returns the index of the related resource selected by a
+// number key.
+func relatedIndex(key string) (int, bool) {
+	n, err := strconv.Atoi(key)
+	if err != nil || n < 1 || n > maxRelatedItems {
+		return 0, false
+	}
+
+	return n - 1, true
+}
+
+// resourceLabel returns the kind and namespaced name of the resource.
+func resourceLabel(r *kube.Resource) string {
+	return r.Object.GetKind() + " " + r.Object.GetNamespacedName()
+}
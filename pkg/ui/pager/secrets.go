@@ -0,0 +1,75 @@
+package pager
+
+import (
+	"fmt"
+
+	"go.jacobcolvin.com/niceyaml"
+
+	tea "charm.land/bubbletea/v2"
+
+	"github.com/macropower/kat/pkg/ui/statusbar"
+	"github.com/macropower/kat/pkg/ui/yamls"
+)
+
+// isSecret reports whether the document contains secret values that are
+// masked until they are revealed.
+func (m *Model) isSecret(doc yamls.Document) bool {
+	return m.secrets != nil && !m.showingResult && m.secrets.IsSecret(doc.Object)
+}
+
+// displaySource returns the source of the document as it is displayed, with
+// secret values masked or revealed. If the values cannot be masked, the
+// document is not displayed at all.
+func (m *Model) displaySource(doc yamls.Document) *niceyaml.Source {
+	if doc.Body == nil || !m.isSecret(doc) {
+		return doc.Body
+	}
+
+	content := doc.Body.Content()
+
+	var err error
+	if m.revealed {
+		content, err = m.secrets.Reveal(doc.Object, content)
+	} else {
+		content, err = m.secrets.Mask(doc.Object, content)
+	}
+
+	if err != nil {
+		return niceyaml.NewSourceFromString(fmt.Sprintf("# Secret values are hidden: %v\n", err))
+	}
+
+	return niceyaml.NewSourceFromString(content)
+}
+
+// ToggleSecrets reveals or masks the secret values of the current document.
+// Revealed values are base64-decoded where possible.
+func (m *Model) ToggleSecrets() tea.Cmd {
+	if !m.isSecret(m.CurrentDocument) {
+		return m.sendStatusMessage("no secret values", statusbar.StyleError)
+	}
+
+	m.revealed = !m.revealed
+	m.SetContent(m.displaySource(m.CurrentDocument))
+
+	if m.IsShowingSplit() {
+		m.splitViewport.SetTokens(m.displaySource(m.SplitDocument))
+		m.syncSplit()
+	}
+
+	if m.revealed {
+		return m.sendStatusMessage("revealed secret values", statusbar.StyleSuccess)
+	}
+
+	return m.sendStatusMessage("masked secret values", statusbar.StyleSuccess)
+}
+
+// copyContent returns the content that is copied to the clipboard. Secret
+// values stay masked unless they have been revealed, in which case the
+// original document is copied, so that it remains a valid resource.
+func (m *Model) copyContent() string {
+	if m.revealed || !m.isSecret(m.CurrentDocument) {
+		return m.CurrentDocument.Body.Content()
+	}
+
+	return m.displaySource(m.CurrentDocument).Content()
+}
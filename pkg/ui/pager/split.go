@@ -0,0 +1,130 @@
+package pager
+
+import (
+	"strings"
+
+	"charm.land/lipgloss/v2"
+	"go.jacobcolvin.com/niceyaml/style"
+
+	tea "charm.land/bubbletea/v2"
+
+	"github.com/macropower/kat/pkg/ui/statusbar"
+	"github.com/macropower/kat/pkg/ui/yamls"
+)
+
+// splitSeparatorWidth is the width of the separator between the panes of the
+// split view.
+const splitSeparatorWidth = 1
+
+// LoadSplitMsg instructs the pager to display two documents side-by-side.
+// Right is loaded as the current document, and Left is shown next to it.
+type LoadSplitMsg struct {
+	Left  yamls.Document
+	Right yamls.Document
+}
+
+type splitMode int
+
+const (
+	splitNone splitMode = iota
+	// splitRevision shows the previous revision of the current document.
+	splitRevision
+	// splitDocument shows another document.
+	splitDocument
+)
+
+// IsShowingSplit reports whether two documents are shown side-by-side.
+func (m *Model) IsShowingSplit() bool {
+	return m.split != splitNone
+}
+
+// ToggleSplit shows the previous revision of the current document next to
+// it, or closes the split view if it is shown.
+func (m *Model) ToggleSplit() tea.Cmd {
+	if m.IsShowingSplit() {
+		m.CloseSplit()
+
+		return nil
+	}
+
+	if m.previous == nil {
+		return m.sendStatusMessage("no previous revision", statusbar.StyleError)
+	}
+
+	left := *m.previous
+	left.Title += " (previous)"
+
+	m.openSplit(splitRevision, left)
+
+	return nil
+}
+
+// CloseSplit hides the left pane of the split view.
+func (m *Model) CloseSplit() {
+	if m.split == splitNone {
+		return
+	}
+
+	m.split = splitNone
+	m.SplitDocument = yamls.Document{}
+	m.SetSize(m.width, m.height)
+}
+
+// openSplit shows the document in the left pane, scrolled to the same
+// position as the current document.
+func (m *Model) openSplit(mode splitMode, doc yamls.Document) {
+	m.split = mode
+	m.SplitDocument = doc
+	m.splitViewport.SetTokens(m.displaySource(doc))
+	m.SetSize(m.width, m.height)
+	m.syncSplit()
+}
+
+// syncSplit scrolls the left pane to the same offset as the current
+// document, so that both panes scroll together.
+func (m *Model) syncSplit() {
+	if m.split == splitNone {
+		return
+	}
+
+	m.splitViewport.SetYOffset(m.viewport.YOffset())
+}
+
+// splitWidths returns the widths of the left and right panes for the total
+// width w.
+func splitWidths(w int) (int, int) {
+	left := max(0, (w-splitSeparatorWidth)/2)
+	right := max(0, w-splitSeparatorWidth-left)
+
+	return left, right
+}
+
+// documentView renders the current document, next to the split document if
+// the split view is shown.
+func (m Model) documentView() string {
+	if m.split == splitNone {
+		return m.viewport.View()
+	}
+
+	height := m.viewport.Height()
+	sep := m.theme.Style(style.TextSubtleDim).Render(
+		strings.TrimSuffix(strings.Repeat("│\n", height), "\n"),
+	)
+
+	left, right := splitWidths(m.width)
+
+	return lipgloss.JoinHorizontal(lipgloss.Top,
+		lipgloss.NewStyle().Width(left).MaxWidth(left).Height(height).MaxHeight(height).Render(m.splitViewport.View()),
+		sep,
+		lipgloss.NewStyle().Width(right).MaxWidth(right).Height(height).MaxHeight(height).Render(m.viewport.View()),
+	)
+}
+
+// title returns the title shown in the status bar.
+func (m Model) title() string {
+	if m.split == splitNone {
+		return m.CurrentDocument.Title
+	}
+
+	return m.SplitDocument.Title + " │ " + m.CurrentDocument.Title
+}
@@ -13,6 +13,7 @@ import (
 	tea "charm.land/bubbletea/v2"
 
 	"github.com/macropower/kat/pkg/keys"
+	"github.com/macropower/kat/pkg/kube"
 	"github.com/macropower/kat/pkg/ui/theme"
 	"github.com/macropower/kat/pkg/ui/yamls"
 )
@@ -23,6 +24,15 @@ type ItemDelegate struct {
 	openKey *keys.KeyBind
 	compact bool
 
+	filter *itemFilter
+
+	// selection contains the selected documents, which are marked in the
+	// gutter.
+	selection selection
+
+	// depth is the number of group levels above each document.
+	depth int
+
 	maxGroupWidth int
 	maxKindWidth  int
 	maxNameWidth  int
@@ -78,24 +88,50 @@ func (d *ItemDelegate) Update(msg tea.Msg, m *list.Model) tea.Cmd {
 
 // Render renders a single list item.
 func (d *ItemDelegate) Render(w io.Writer, m list.Model, index int, item list.Item) {
+	isSelected := index == m.Index()
+	isFiltering := m.FilterState() == list.Filtering
+
+	if h, ok := item.(*groupHeader); ok {
+		d.renderHeader(w, h, isSelected && !isFiltering, m.Width())
+
+		return
+	}
+
 	doc, ok := item.(*yamls.Document)
 	if !ok {
 		return
 	}
 
-	isSelected := index == m.Index()
-	isFiltering := m.FilterState() == list.Filtering
 	filterValue := m.FilterValue()
 	hasEmptyFilter := isFiltering && filterValue == ""
+
+	// Queries do not match characters, so nothing is highlighted.
+	if d.filter != nil && d.filter.isQueryMode() {
+		filterValue = ""
+	}
 	singleFilteredItem := isFiltering && len(m.VisibleItems()) == 1
 
 	shouldHighlight := (isSelected && !isFiltering) || singleFilteredItem
 
+	// Group headers are hidden while filtering, so documents are only
+	// indented below their headers when the list is unfiltered.
+	depth := 0
+	if m.FilterState() == list.Unfiltered {
+		depth = d.depth
+	}
+
+	width := m.Width() - depth*2
+
+	var b strings.Builder
+
 	if d.compact {
-		d.renderCompact(w, doc, filterValue, shouldHighlight, hasEmptyFilter, m.Width())
+		d.renderCompact(&b, doc, filterValue, shouldHighlight, hasEmptyFilter, width)
 	} else {
-		d.renderNormal(w, doc, filterValue, shouldHighlight, hasEmptyFilter, m.Width())
+		d.renderNormal(&b, doc, filterValue, shouldHighlight, hasEmptyFilter, width)
 	}
+
+	//nolint:errcheck // Writer is an in-memory buffer.
+	io.WriteString(w, indent(b.String(), depth*2))
 }
 
 // UpdateColumnWidths recalculates the maximum column widths across all items.
@@ -131,6 +167,52 @@ func (d *ItemDelegate) itemChrome(highlighted bool, unselectedSep string) (strin
 	return " ", unselectedSep
 }
 
+// selectionGutter returns the gutter marker for the first row of a
+// document, which marks selected documents.
+func (d *ItemDelegate) selectionGutter(doc *yamls.Document, highlighted bool, gutter string) string {
+	if !d.selection.has(doc) {
+		return gutter
+	}
+
+	if highlighted {
+		return d.theme.Style(style.TextAccent).Render("●")
+	}
+
+	return d.theme.Style(style.TextSubtle).Render("●")
+}
+
+// diffMarker returns a single-character marker describing how the document
+// changed since the previous render. Unchanged documents get a blank space.
+func (d *ItemDelegate) diffMarker(doc *yamls.Document) string {
+	switch doc.Diff {
+	case kube.DiffAdded:
+		return d.theme.Style(style.GenericInserted).Render("+")
+	case kube.DiffRemoved:
+		return d.theme.Style(style.GenericDeleted).Render("-")
+	case kube.DiffModified:
+		return d.theme.Style(style.TextAccent).Render("~")
+	case kube.DiffUnchanged:
+	}
+
+	return " "
+}
+
+// diagnosticBadge returns a badge with the number of diagnostics for the
+// document, styled by the most severe diagnostic. Documents without
+// diagnostics get an empty string.
+func (d *ItemDelegate) diagnosticBadge(doc *yamls.Document) string {
+	if len(doc.Diagnostics) == 0 {
+		return ""
+	}
+
+	badgeStyle := d.theme.Style(style.TextAccent)
+	if doc.DiagnosticSeverity() == kube.SeverityError {
+		badgeStyle = d.theme.Style(style.TextError)
+	}
+
+	return badgeStyle.Render(fmt.Sprintf("!%d", len(doc.Diagnostics)))
+}
+
 // styleItemText applies filter-aware styling to a single text segment.
 // When a filter is active, matched characters are underlined.
 // When hasEmptyFilter is true, all text is rendered with the dim style.
@@ -158,7 +240,9 @@ func (d *ItemDelegate) renderCompact(
 		kind  = doc.Object.GetKind()
 		name  = doc.Title
 
-		horizontalPadding = listViewHorizontalPadding - compactExtraHorizontalPadding
+		badge = d.diagnosticBadge(doc)
+
+		horizontalPadding = listViewHorizontalPadding - compactExtraHorizontalPadding + badgeWidth(badge)
 
 		maxWidth   = float64(d.maxGroupWidth + d.maxKindWidth + d.maxNameWidth)
 		groupWidth = int(max(0, float64(width)*float64(float64(d.maxGroupWidth)/maxWidth)))
@@ -186,8 +270,14 @@ func (d *ItemDelegate) renderCompact(
 	styledKind += strings.Repeat(" ", max(0, min(kindWidth, d.maxKindWidth)-len(kind)))
 	styledName += strings.Repeat(" ", max(0, min(nameWidth, d.maxNameWidth)-len(name)))
 
+	if badge != "" {
+		styledName += " " + badge
+	}
+
 	//nolint:errcheck // Writer is an in-memory buffer.
-	fmt.Fprintf(w, "%s %s%s%s  %s  %s", gutter, separator, separator, styledGroup, styledKind, styledName)
+	fmt.Fprintf(w, "%s%s%s%s%s  %s  %s",
+		d.selectionGutter(doc, shouldHighlight, gutter), d.diffMarker(doc), separator, separator,
+		styledGroup, styledKind, styledName)
 }
 
 func (d *ItemDelegate) renderNormal(
@@ -198,8 +288,9 @@ func (d *ItemDelegate) renderNormal(
 	width int,
 ) {
 	truncateTo := max(0, width-listViewHorizontalPadding*2)
+	badge := d.diagnosticBadge(doc)
 
-	title := ansi.Truncate(doc.Title, truncateTo, d.theme.Ellipsis)
+	title := ansi.Truncate(doc.Title, max(0, truncateTo-badgeWidth(badge)), d.theme.Ellipsis)
 	desc := ansi.Truncate(doc.Desc, truncateTo, d.theme.Ellipsis)
 
 	gutter, separator := d.itemChrome(shouldHighlight, d.theme.Style(style.Text).Render(""))
@@ -222,8 +313,61 @@ func (d *ItemDelegate) renderNormal(
 	}
 
 	styledTitle := styleItemText(title, filterValue, hasEmptyFilter, titleStyle)
+	if badge != "" {
+		styledTitle += " " + badge
+	}
 	styledDesc := styleItemText(desc, filterValue, hasEmptyFilter, descStyle)
 
 	//nolint:errcheck // Writer is an in-memory buffer.
-	fmt.Fprintf(w, "%s %s%s%s\n%s %s", gutter, separator, separator, styledTitle, gutter, styledDesc)
+	fmt.Fprintf(w, "%s%s%s%s%s\n%s %s",
+		d.selectionGutter(doc, shouldHighlight, gutter), d.diffMarker(doc), separator, separator,
+		styledTitle, gutter, styledDesc)
+}
+
+// renderHeader renders a group header, with the number of resources in the
+// group. Headers are indented by their depth.
+func (d *ItemDelegate) renderHeader(w io.Writer, h *groupHeader, highlighted bool, width int) {
+	depth := h.depth * 2
+	truncateTo := max(0, width-listViewHorizontalPadding*2-depth)
+
+	arrow := "▾"
+	if h.collapsed {
+		arrow = "▸"
+	}
+
+	gutter, _ := d.itemChrome(highlighted, "")
+
+	titleStyle := d.theme.Style(style.Text)
+	countStyle := d.theme.Style(style.TextSubtleDim)
+
+	if highlighted {
+		titleStyle = d.theme.Style(style.TextAccent)
+		countStyle = d.theme.Style(style.TextAccentDim)
+	}
+
+	title := titleStyle.Render(arrow + " " + ansi.Truncate(h.title, truncateTo, d.theme.Ellipsis))
+
+	count := fmt.Sprintf("%d resources", h.count)
+	if h.count == 1 {
+		count = "1 resource"
+	}
+
+	var s string
+	if d.compact {
+		s = fmt.Sprintf("%s %s %s", gutter, title, countStyle.Render(count))
+	} else {
+		s = fmt.Sprintf("%s %s\n%s   %s", gutter, title, gutter, countStyle.Render(count))
+	}
+
+	//nolint:errcheck // Writer is an in-memory buffer.
+	io.WriteString(w, indent(s, depth))
+}
+
+// badgeWidth returns the width taken by a badge, including its leading space.
+func badgeWidth(badge string) int {
+	if badge == "" {
+		return 0
+	}
+
+	return ansi.StringWidth(badge) + 1
 }
@@ -0,0 +1,264 @@
+package resourcelist
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+
+	"charm.land/bubbles/v2/list"
+
+	"github.com/macropower/kat/pkg/kube"
+	"github.com/macropower/kat/pkg/ui/yamls"
+)
+
+// DefaultGroupLabel is the label used to group resources by default, when
+// grouping by label.
+const DefaultGroupLabel = "app.kubernetes.io/component"
+
+// GroupBy describes how resources are grouped in the list.
+type GroupBy string
+
+const (
+	// GroupByNone shows resources as a flat list.
+	GroupByNone GroupBy = "none"
+	// GroupByNamespace groups resources by namespace.
+	GroupByNamespace GroupBy = "namespace"
+	// GroupByKind groups resources by group and kind.
+	GroupByKind GroupBy = "kind"
+	// GroupByLabel groups resources by the value of a label.
+	GroupByLabel GroupBy = "label"
+	// GroupByTree groups resources by namespace, and then by group and kind.
+	GroupByTree GroupBy = "tree"
+)
+
+// groupByModes contains the [GroupBy] modes, in the order they are cycled.
+var groupByModes = []GroupBy{GroupByNone, GroupByNamespace, GroupByKind, GroupByLabel, GroupByTree}
+
+// SortBy describes how resources are sorted in the list.
+type SortBy string
+
+const (
+	// SortByName sorts resources by group, kind, namespace and name.
+	SortByName SortBy = "name"
+	// SortByApply sorts resources in the order they should be applied, e.g.
+	// with Namespaces and CustomResourceDefinitions first.
+	SortByApply SortBy = "apply"
+	// SortByRender keeps resources in the order they were rendered.
+	SortByRender SortBy = "render"
+)
+
+// sortByModes contains the [SortBy] modes, in the order they are cycled.
+var sortByModes = []SortBy{SortByName, SortByApply, SortByRender}
+
+// String returns a human-readable description of the grouping mode.
+func (g GroupBy) String() string {
+	switch g {
+	case GroupByNamespace:
+		return "namespace"
+	case GroupByKind:
+		return "kind"
+	case GroupByLabel:
+		return "label"
+	case GroupByTree:
+		return "namespace and kind"
+	case GroupByNone:
+	}
+
+	return "none"
+}
+
+// String returns a human-readable description of the sort mode.
+func (s SortBy) String() string {
+	switch s {
+	case SortByApply:
+		return "apply order"
+	case SortByRender:
+		return "render order"
+	case SortByName:
+	}
+
+	return "name"
+}
+
+// next returns the mode after the current one in modes.
+func next[T comparable](modes []T, current T) T {
+	i := slices.Index(modes, current)
+
+	return modes[(i+1)%len(modes)]
+}
+
+// groupHeader is a list item that heads a group of resources. Selecting it
+// collapses or expands the group.
+type groupHeader struct {
+	id        string
+	title     string
+	count     int
+	depth     int
+	collapsed bool
+}
+
+// FilterValue returns an empty string, so that headers are hidden while
+// filtering.
+func (h *groupHeader) FilterValue() string {
+	return ""
+}
+
+// groupLevel describes one level of grouping.
+type groupLevel struct {
+	// key returns the key and title of the group of a document.
+	key func(doc *yamls.Document) (string, string)
+	// sorted groups by key. Otherwise, groups are ordered by their first
+	// document.
+	sorted bool
+	// emptyFirst places the group of documents without a key first, instead
+	// of last, when sorted.
+	emptyFirst bool
+}
+
+// groupLevels returns the levels of grouping for a [GroupBy] mode.
+func groupLevels(groupBy GroupBy, label string) []groupLevel {
+	namespace := groupLevel{
+		key: func(doc *yamls.Document) (string, string) {
+			ns := doc.Object.GetNamespace()
+			if ns == "" {
+				return "", "(cluster)"
+			}
+
+			return ns, ns
+		},
+		sorted: true,
+		// Cluster-scoped resources are usually applied first.
+		emptyFirst: true,
+	}
+
+	kind := groupLevel{
+		key: func(doc *yamls.Document) (string, string) {
+			gk := doc.Object.GetGroupKind()
+
+			return gk, gk
+		},
+	}
+
+	switch groupBy {
+	case GroupByNamespace:
+		return []groupLevel{namespace}
+	case GroupByKind:
+		return []groupLevel{kind}
+	case GroupByLabel:
+		return []groupLevel{{
+			key: func(doc *yamls.Document) (string, string) {
+				v, ok := doc.Object.GetLabels()[label]
+				if !ok {
+					return "", "(no " + label + ")"
+				}
+
+				return v, label + "=" + v
+			},
+			sorted: true,
+		}}
+	case GroupByTree:
+		return []groupLevel{namespace, kind}
+	case GroupByNone:
+	}
+
+	return nil
+}
+
+// group is a group of documents with the same key.
+type group struct {
+	key   string
+	title string
+	docs  []*yamls.Document
+}
+
+// groupItems returns list items for the documents, with a header before
+// each group. The documents of collapsed groups are omitted. Each header is
+// identified by the keys of its group and its parents, prefixed by parent.
+func groupItems(docs []*yamls.Document, levels []groupLevel, parent string, depth int, collapsed map[string]bool) []list.Item {
+	if len(levels) == 0 {
+		items := make([]list.Item, 0, len(docs))
+		for _, doc := range docs {
+			items = append(items, doc)
+		}
+
+		return items
+	}
+
+	level := levels[0]
+
+	var groups []*group
+
+	byKey := map[string]*group{}
+
+	for _, doc := range docs {
+		key, title := level.key(doc)
+
+		g, ok := byKey[key]
+		if !ok {
+			g = &group{key: key, title: title}
+			byKey[key] = g
+			groups = append(groups, g)
+		}
+
+		g.docs = append(g.docs, doc)
+	}
+
+	if level.sorted {
+		slices.SortStableFunc(groups, func(a, b *group) int {
+			if (a.key == "") != (b.key == "") {
+				if (a.key == "") == level.emptyFirst {
+					return -1
+				}
+
+				return 1
+			}
+
+			return strings.Compare(a.key, b.key)
+		})
+	}
+
+	var items []list.Item
+
+	for _, g := range groups {
+		id := parent + "/" + g.key
+
+		h := &groupHeader{
+			id:        id,
+			title:     g.title,
+			count:     len(g.docs),
+			depth:     depth,
+			collapsed: collapsed[id],
+		}
+
+		items = append(items, h)
+
+		if !h.collapsed {
+			items = append(items, groupItems(g.docs, levels[1:], id, depth+1, collapsed)...)
+		}
+	}
+
+	return items
+}
+
+// sortDocuments returns the documents sorted by the [SortBy] mode. The
+// documents are expected to be in render order.
+func sortDocuments(docs []*yamls.Document, sortBy SortBy) []*yamls.Document {
+	sorted := slices.Clone(docs)
+
+	switch sortBy {
+	case SortByRender:
+	case SortByApply:
+		slices.SortStableFunc(sorted, func(a, b *yamls.Document) int {
+			return cmp.Compare(kube.ApplyOrder(a.Object.GetKind()), kube.ApplyOrder(b.Object.GetKind()))
+		})
+	case SortByName:
+		slices.SortStableFunc(sorted, func(a, b *yamls.Document) int {
+			return strings.Compare(
+				strings.ToLower(a.Desc+a.Title),
+				strings.ToLower(b.Desc+b.Title),
+			)
+		})
+	}
+
+	return sorted
+}
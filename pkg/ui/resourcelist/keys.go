@@ -10,6 +10,18 @@ type KeyBinds struct {
 	End      *keys.KeyBind `json:"end,omitempty"`
 	PageUp   *keys.KeyBind `json:"pageUp,omitempty"`
 	PageDown *keys.KeyBind `json:"pageDown,omitempty"`
+	Changed  *keys.KeyBind `json:"changed,omitempty"`
+	Group    *keys.KeyBind `json:"group,omitempty"`
+	Sort     *keys.KeyBind `json:"sort,omitempty"`
+	Query    *keys.KeyBind `json:"query,omitempty"`
+	Matrix   *keys.KeyBind `json:"matrix,omitempty"`
+	Compare  *keys.KeyBind `json:"compare,omitempty"`
+
+	Select       *keys.KeyBind `json:"select,omitempty"`
+	SelectAll    *keys.KeyBind `json:"selectAll,omitempty"`
+	CopySelected *keys.KeyBind `json:"copySelected,omitempty"`
+	Export       *keys.KeyBind `json:"export,omitempty"`
+	Warnings     *keys.KeyBind `json:"warnings,omitempty"`
 }
 
 // EnsureDefaults sets default keybindings for any unset bindings.
@@ -44,6 +56,50 @@ func (kb *KeyBinds) EnsureDefaults() {
 			keys.New("f"),
 			keys.New("d"),
 		))
+	keys.SetDefaultBind(&kb.Changed,
+		keys.NewBind("changed only",
+			keys.New("c"),
+		))
+	keys.SetDefaultBind(&kb.Group,
+		keys.NewBind("group by",
+			keys.New("v"),
+		))
+	keys.SetDefaultBind(&kb.Sort,
+		keys.NewBind("sort by",
+			keys.New("s"),
+		))
+	keys.SetDefaultBind(&kb.Query,
+		keys.NewBind("query",
+			keys.New("="),
+		))
+	keys.SetDefaultBind(&kb.Matrix,
+		keys.NewBind("compare variants",
+			keys.New("m"),
+		))
+	keys.SetDefaultBind(&kb.Compare,
+		keys.NewBind("compare side-by-side",
+			keys.New("x"),
+		))
+	keys.SetDefaultBind(&kb.Select,
+		keys.NewBind("toggle select",
+			keys.New("space"),
+		))
+	keys.SetDefaultBind(&kb.SelectAll,
+		keys.NewBind("select all",
+			keys.New("a"),
+		))
+	keys.SetDefaultBind(&kb.CopySelected,
+		keys.NewBind("copy selected",
+			keys.New("y"),
+		))
+	keys.SetDefaultBind(&kb.Export,
+		keys.NewBind("export selected",
+			keys.New("e"),
+		))
+	keys.SetDefaultBind(&kb.Warnings,
+		keys.NewBind("show warnings",
+			keys.New("w"),
+		))
 }
 
 // GetKeyBinds returns all keybindings for validation.
@@ -55,5 +111,16 @@ func (kb *KeyBinds) GetKeyBinds() []keys.KeyBind {
 		*kb.End,
 		*kb.PageUp,
 		*kb.PageDown,
+		*kb.Changed,
+		*kb.Group,
+		*kb.Sort,
+		*kb.Query,
+		*kb.Matrix,
+		*kb.Compare,
+		*kb.Select,
+		*kb.SelectAll,
+		*kb.CopySelected,
+		*kb.Export,
+		*kb.Warnings,
 	}
 }
@@ -2,7 +2,6 @@ package resourcelist
 
 import (
 	"fmt"
-	"slices"
 	"strings"
 
 	"charm.land/bubbles/v2/list"
@@ -12,7 +11,10 @@ import (
 
 	tea "charm.land/bubbletea/v2"
 
+	"github.com/macropower/kat/pkg/command"
 	"github.com/macropower/kat/pkg/keys"
+	"github.com/macropower/kat/pkg/kube"
+	"github.com/macropower/kat/pkg/profile"
 	"github.com/macropower/kat/pkg/ui/common"
 	"github.com/macropower/kat/pkg/ui/statusbar"
 	"github.com/macropower/kat/pkg/ui/theme"
@@ -28,6 +30,17 @@ const (
 
 type FetchedYAMLMsg *yamls.Document
 
+// CompareYAMLMsg signals that two YAML documents have been selected to be
+// compared side-by-side.
+type CompareYAMLMsg struct {
+	Left  *yamls.Document
+	Right *yamls.Document
+}
+
+// RenderMatrixMsg requests that every variant of the current profile is
+// rendered and compared.
+type RenderMatrixMsg struct{}
+
 // LoadYAML returns a command that signals a YAML document has been selected.
 func LoadYAML(md *yamls.Document) tea.Cmd {
 	return common.CmdHandler(FetchedYAMLMsg(md))
@@ -38,13 +51,27 @@ type Model struct {
 	inner         list.Model
 	cmd           common.Commander
 	delegate      *ItemDelegate
+	filter        *itemFilter
 	theme         *theme.Theme
 	keyBinds      *common.KeyBinds
+	listKeyBinds  *KeyBinds
 	statusBar     *statusbar.StatusBarRenderer
 	Help          statusbar.HelpModel
 	StatusMessage statusbar.StatusMessageModel
-	width         int
-	height        int
+	collapsed     map[string]bool
+	queries       []*SavedQuery
+	groupBy       GroupBy
+	sortBy        SortBy
+	groupLabel    string
+	docs          []*yamls.Document
+	warnings      []command.Warning
+	// compareDoc is the document marked for comparison, if any.
+	compareDoc  *yamls.Document
+	selection   selection
+	secrets     *kube.SecretMasker
+	width       int
+	height      int
+	changedOnly bool
 }
 
 // Config holds configuration for creating a new [Model].
@@ -53,17 +80,34 @@ type Config struct {
 	KeyBinds  *KeyBinds
 	CKeyBinds *common.KeyBinds
 	Cmd       common.Commander
-	Compact   bool
+	// GroupBy sets the initial grouping of resources. Defaults to [GroupByNone].
+	GroupBy GroupBy
+	// SortBy sets the initial order of resources. Defaults to [SortByName].
+	SortBy SortBy
+	// GroupLabel is the label used when grouping by label. Defaults to
+	// [DefaultGroupLabel].
+	GroupLabel string
+	// Secrets masks secret values when selected resources are copied or
+	// opened. If nil, secret values are not masked.
+	Secrets *kube.SecretMasker
+	// Queries are applied to the list with their key bindings.
+	Queries []*SavedQuery
+	Compact bool
 }
 
 // NewModel creates a new [Model].
 func NewModel(c Config) Model {
+	filter := &itemFilter{}
+	selected := selection{}
 	delegate := NewItemDelegate(c.Theme, c.KeyBinds.Open, c.Compact)
+	delegate.filter = filter
+	delegate.selection = selected
 
 	inner := list.New(nil, delegate, 0, 0)
+	inner.Filter = filter.Filter
 
 	// Configure filter input.
-	inner.FilterInput.Prompt = "Find:"
+	inner.FilterInput.Prompt = findPrompt
 	styles := inner.FilterInput.Styles()
 	styles.Focused.Prompt = c.Theme.Style(style.TextAccentDim).MarginRight(1)
 	styles.Blurred.Prompt = c.Theme.Style(style.TextAccentDim).MarginRight(1)
@@ -126,6 +170,19 @@ func NewModel(c Config) Model {
 		*kb.PageDown,
 		*kb.Home,
 		*kb.End,
+		*kb.Changed,
+		*kb.Group,
+		*kb.Sort,
+		*kb.Query,
+		*kb.Matrix,
+		*kb.Compare,
+	)
+	kbr.AddColumn(
+		*kb.Select,
+		*kb.SelectAll,
+		*kb.CopySelected,
+		*kb.Export,
+		*kb.Warnings,
 	)
 	kbr.AddColumn(
 		*ckb.Reload,
@@ -139,7 +196,7 @@ func NewModel(c Config) Model {
 	// Add plugin keybinds column if plugins are available.
 	_, prof := c.Cmd.GetCurrentProfile()
 	if prof != nil {
-		pluginBinds := prof.GetPluginKeyBinds()
+		pluginBinds := prof.GetPluginKeyBinds(profile.PluginScopeList)
 		if len(pluginBinds) > 6 {
 			pluginBinds = pluginBinds[:6]
 		}
@@ -147,14 +204,48 @@ func NewModel(c Config) Model {
 		kbr.AddColumn(pluginBinds...)
 	}
 
+	// Add saved query keybinds column if queries are configured.
+	if len(c.Queries) > 0 {
+		queryBinds := make([]keys.KeyBind, 0, len(c.Queries))
+		for _, q := range c.Queries[:min(len(c.Queries), 6)] {
+			queryBinds = append(queryBinds, q.KeyBind())
+		}
+
+		kbr.AddColumn(queryBinds...)
+	}
+
+	groupBy := c.GroupBy
+	if groupBy == "" {
+		groupBy = GroupByNone
+	}
+
+	sortBy := c.SortBy
+	if sortBy == "" {
+		sortBy = SortByName
+	}
+
+	groupLabel := c.GroupLabel
+	if groupLabel == "" {
+		groupLabel = DefaultGroupLabel
+	}
+
 	return Model{
-		inner:     inner,
-		delegate:  delegate,
-		theme:     c.Theme,
-		keyBinds:  c.CKeyBinds,
-		cmd:       c.Cmd,
-		Help:      statusbar.NewHelpModel(statusbar.NewHelpRenderer(c.Theme, kbr)),
-		statusBar: statusbar.NewStatusBarRenderer(c.Theme, 0),
+		inner:        inner,
+		filter:       filter,
+		queries:      c.Queries,
+		collapsed:    map[string]bool{},
+		selection:    selected,
+		secrets:      c.Secrets,
+		groupBy:      groupBy,
+		sortBy:       sortBy,
+		groupLabel:   groupLabel,
+		delegate:     delegate,
+		theme:        c.Theme,
+		keyBinds:     c.CKeyBinds,
+		listKeyBinds: c.KeyBinds,
+		cmd:          c.Cmd,
+		Help:         statusbar.NewHelpModel(statusbar.NewHelpRenderer(c.Theme, kbr)),
+		statusBar:    statusbar.NewStatusBarRenderer(c.Theme, 0),
 	}
 }
 
@@ -168,6 +259,74 @@ func (m *Model) Update(msg tea.Msg) tea.Cmd {
 			return nil
 		}
 
+		if !m.IsFiltering() && m.listKeyBinds.Changed.Match(msg.String()) {
+			return m.ToggleChangedOnly()
+		}
+
+		if !m.IsFiltering() && m.listKeyBinds.Group.Match(msg.String()) {
+			return m.CycleGroupBy()
+		}
+
+		if !m.IsFiltering() && m.listKeyBinds.Sort.Match(msg.String()) {
+			return m.CycleSortBy()
+		}
+
+		if !m.IsFiltering() && m.listKeyBinds.Query.Match(msg.String()) {
+			return m.StartQuery()
+		}
+
+		if !m.IsFiltering() && m.listKeyBinds.Matrix.Match(msg.String()) {
+			return m.RenderMatrix()
+		}
+
+		if !m.IsFiltering() && m.listKeyBinds.Compare.Match(msg.String()) {
+			return m.Compare()
+		}
+
+		if !m.IsFiltering() && m.listKeyBinds.Select.Match(msg.String()) {
+			return m.ToggleSelect()
+		}
+
+		if !m.IsFiltering() && m.listKeyBinds.SelectAll.Match(msg.String()) {
+			return m.SelectAll()
+		}
+
+		if !m.IsFiltering() && m.listKeyBinds.CopySelected.Match(msg.String()) {
+			return m.CopySelected()
+		}
+
+		if !m.IsFiltering() && m.listKeyBinds.Export.Match(msg.String()) {
+			return m.ExportSelected()
+		}
+
+		if !m.IsFiltering() && m.listKeyBinds.Warnings.Match(msg.String()) {
+			return m.ShowWarnings()
+		}
+
+		if !m.IsFiltering() && m.listKeyBinds.Find.Match(msg.String()) {
+			// Fall through to the inner list, which starts filtering.
+			m.setQueryMode(false)
+		}
+
+		if !m.IsFiltering() {
+			for _, q := range m.queries {
+				kb := q.KeyBind()
+				if kb.Match(msg.String()) {
+					return m.ApplyQuery(q.Query)
+				}
+			}
+		}
+
+		if !m.IsFiltering() && m.listKeyBinds.Open.Match(msg.String()) {
+			if h, ok := m.inner.SelectedItem().(*groupHeader); ok {
+				return m.toggleGroup(h.id)
+			}
+
+			if len(m.Selected()) > 0 {
+				return m.OpenSelected()
+			}
+		}
+
 		if m.IsFiltering() {
 			if msg.Code == tea.KeyEnter {
 				m.inner.SetFilterState(list.FilterApplied)
@@ -233,36 +392,198 @@ func (m Model) View() string {
 	return lipgloss.JoinVertical(lipgloss.Top, top, bottom)
 }
 
-// SetItems sets the documents displayed in the list.
+// SetItems sets the documents displayed in the list. The documents are
+// expected to be in render order. If the changed-only filter is enabled,
+// unchanged documents are hidden.
 func (m *Model) SetItems(docs []*yamls.Document) tea.Cmd {
-	// Sort items.
-	slices.SortStableFunc(docs, func(a, b *yamls.Document) int {
-		return strings.Compare(
-			strings.ToLower(a.Desc+a.Title),
-			strings.ToLower(b.Desc+b.Title),
-		)
-	})
-
 	// Build filter values.
-	items := make([]list.Item, 0, len(docs))
-
 	for _, doc := range docs {
 		doc.BuildFilterValue()
+	}
+
+	m.docs = docs
 
-		items = append(items, doc)
+	if docs == nil {
+		// Documents from a different configuration are not comparable.
+		m.compareDoc = nil
+		m.warnings = nil
+		m.ClearSelection()
 	}
 
 	// Update column widths for compact rendering.
 	m.delegate.UpdateColumnWidths(docs)
 
+	return m.applyItems()
+}
+
+// RenderMatrix returns a command that renders every variant of the current
+// profile, or a status message if the profile has no variants for the path.
+func (m *Model) RenderMatrix() tea.Cmd {
+	name, _ := m.cmd.GetCurrentProfile()
+
+	variants, err := m.cmd.FindVariants(m.cmd.GetPath(), name)
+	if err != nil || len(variants) == 0 {
+		return m.SetStatusMessage("no variants to compare", statusbar.StyleError)
+	}
+
+	return common.CmdHandler(RenderMatrixMsg{})
+}
+
+// Compare marks the selected document for comparison. If a document is
+// already marked, the marked and selected documents are compared
+// side-by-side. Selecting the marked document again removes the mark.
+func (m *Model) Compare() tea.Cmd {
+	doc, ok := m.inner.SelectedItem().(*yamls.Document)
+	if !ok {
+		return nil
+	}
+
+	switch m.compareDoc {
+	case nil:
+		m.compareDoc = doc
+
+		return m.SetStatusMessage("marked "+doc.Title+" for comparison", statusbar.StyleSuccess)
+
+	case doc:
+		m.compareDoc = nil
+
+		return m.SetStatusMessage("removed comparison mark", statusbar.StyleSuccess)
+	}
+
+	left := m.compareDoc
+	m.compareDoc = nil
+
+	return common.CmdHandler(CompareYAMLMsg{Left: left, Right: doc})
+}
+
+// ToggleChangedOnly toggles hiding documents that did not change since the
+// previous render.
+func (m *Model) ToggleChangedOnly() tea.Cmd {
+	m.changedOnly = !m.changedOnly
+
+	return m.applyItems()
+}
+
+// CycleGroupBy switches to the next grouping mode.
+func (m *Model) CycleGroupBy() tea.Cmd {
+	m.groupBy = next(groupByModes, m.groupBy)
+
+	return tea.Batch(
+		m.reapplyItems(),
+		m.SetStatusMessage("group by "+m.groupBy.String(), statusbar.StyleSuccess),
+	)
+}
+
+// CycleSortBy switches to the next sort mode.
+func (m *Model) CycleSortBy() tea.Cmd {
+	m.sortBy = next(sortByModes, m.sortBy)
+
+	return tea.Batch(
+		m.reapplyItems(),
+		m.SetStatusMessage("sort by "+m.sortBy.String(), statusbar.StyleSuccess),
+	)
+}
+
+// StartQuery opens the filter input in query mode, where the input is parsed
+// as a [query.Query] instead of fuzzy matching resource names.
+func (m *Model) StartQuery() tea.Cmd {
+	m.inner.ResetFilter()
+	m.setQueryMode(true)
+	m.inner.SetFilterState(list.Filtering)
+
+	return nil
+}
+
+// ApplyQuery filters the list to the resources that match the query.
+func (m *Model) ApplyQuery(q string) tea.Cmd {
+	m.setQueryMode(true)
+	m.inner.SetFilterText(q)
+
+	err := m.filter.queryError()
+	if err != nil {
+		return m.SetStatusMessage(err.Error(), statusbar.StyleError)
+	}
+
+	return nil
+}
+
+// setQueryMode sets whether the filter input is parsed as a query.
+func (m *Model) setQueryMode(v bool) {
+	m.filter.setQueryMode(v)
+
+	if v {
+		m.inner.FilterInput.Prompt = queryPrompt
+	} else {
+		m.inner.FilterInput.Prompt = findPrompt
+	}
+}
+
+// toggleGroup collapses or expands the group with the given id.
+func (m *Model) toggleGroup(id string) tea.Cmd {
+	m.collapsed[id] = !m.collapsed[id]
+
+	return m.reapplyItems()
+}
+
+// reapplyItems updates the inner list's items, keeping the selected item
+// selected if it is still visible.
+func (m *Model) reapplyItems() tea.Cmd {
+	selected := m.inner.SelectedItem()
+	cmd := m.applyItems()
+
+	for i, item := range m.inner.Items() {
+		if sameItem(item, selected) {
+			m.inner.Select(i)
+
+			break
+		}
+	}
+
+	return cmd
+}
+
+// applyItems updates the inner list's items from the stored documents.
+func (m *Model) applyItems() tea.Cmd {
+	docs := make([]*yamls.Document, 0, len(m.docs))
+
+	for _, doc := range m.docs {
+		if m.changedOnly && doc.Diff == kube.DiffUnchanged {
+			continue
+		}
+
+		docs = append(docs, doc)
+	}
+
+	levels := groupLevels(m.groupBy, m.groupLabel)
+	m.delegate.depth = len(levels)
+
+	items := groupItems(sortDocuments(docs, m.sortBy), levels, string(m.groupBy), 0, m.collapsed)
+	m.filter.setItems(items)
+
 	return m.inner.SetItems(items)
 }
 
+// sameItem reports whether a and b are the same document or group header.
+func sameItem(a, b list.Item) bool {
+	if ha, ok := a.(*groupHeader); ok {
+		hb, ok := b.(*groupHeader)
+
+		return ok && ha.id == hb.id
+	}
+
+	return a == b
+}
+
 // IsFiltering returns whether the user is actively typing a filter.
 func (m Model) IsFiltering() bool {
 	return m.inner.SettingFilter()
 }
 
+// IsFiltered returns whether a filter is being typed or applied.
+func (m Model) IsFiltered() bool {
+	return m.inner.FilterState() != list.Unfiltered
+}
+
 // ResetFiltering clears the active filter.
 func (m *Model) ResetFiltering() {
 	m.inner.ResetFilter()
@@ -346,7 +667,29 @@ func (m Model) headerView() string {
 }
 
 func (m Model) getHeaderSections() ([]string, lipgloss.Style) {
-	localCount := len(m.inner.Items())
+	var added, removed, modified, invalid, warned int
+
+	for _, doc := range m.docs {
+		switch doc.DiagnosticSeverity() {
+		case kube.SeverityError:
+			invalid++
+		case kube.SeverityWarning:
+			warned++
+		}
+
+		switch doc.Diff {
+		case kube.DiffAdded:
+			added++
+		case kube.DiffRemoved:
+			removed++
+		case kube.DiffModified:
+			modified++
+		case kube.DiffUnchanged:
+		}
+	}
+
+	// Removed documents are still listed, but are not part of the render.
+	localCount := len(m.docs) - removed
 
 	dividerDot := m.theme.Style(style.TextSubtleDim).SetString(" • ")
 	dividerBar := m.theme.Style(style.TextSubtleDim).SetString(" │ ")
@@ -364,14 +707,83 @@ func (m Model) getHeaderSections() ([]string, lipgloss.Style) {
 		m.theme.Style(style.Text).Render(fmt.Sprintf("%d resources", localCount)),
 	}
 
+	// Show a summary of changes since the previous render.
+	if added+removed+modified > 0 {
+		var changes []string
+
+		if added > 0 {
+			changes = append(changes, m.theme.Style(style.GenericInserted).Render(fmt.Sprintf("+%d", added)))
+		}
+
+		if modified > 0 {
+			changes = append(changes, m.theme.Style(style.TextAccent).Render(fmt.Sprintf("~%d", modified)))
+		}
+
+		if removed > 0 {
+			changes = append(changes, m.theme.Style(style.GenericDeleted).Render(fmt.Sprintf("-%d", removed)))
+		}
+
+		sections = append(sections, strings.Join(changes, " "))
+	}
+
+	// Show the number of resources with diagnostics.
+	if invalid+warned > 0 {
+		var problems []string
+
+		if invalid > 0 {
+			problems = append(problems, m.theme.Style(style.TextError).Render(fmt.Sprintf("%d invalid", invalid)))
+		}
+
+		if warned > 0 {
+			problems = append(problems, m.theme.Style(style.TextAccent).Render(fmt.Sprintf("%d with warnings", warned)))
+		}
+
+		sections = append(sections, strings.Join(problems, " "))
+	}
+
+	// Show the number of warnings printed by the renderer.
+	if len(m.warnings) > 0 {
+		sections = append(sections, m.theme.Style(style.TextAccent).Render(
+			fmt.Sprintf("%d renderer warnings", len(m.warnings)),
+		))
+	}
+
+	if m.changedOnly {
+		sections = append(sections, m.theme.Style(style.TextAccent).Render("changed only"))
+	}
+
+	selected := len(m.Selected())
+	if selected > 0 {
+		sections = append(sections, m.theme.Style(style.TextAccent).Render(fmt.Sprintf("%d selected", selected)))
+	}
+
+	if m.groupBy != GroupByNone {
+		groupBy := m.groupBy.String()
+		if m.groupBy == GroupByLabel {
+			groupBy = m.groupLabel
+		}
+
+		sections = append(sections, m.theme.Style(style.TextSubtle).Render("by "+groupBy))
+	}
+
+	if m.sortBy != SortByName {
+		sections = append(sections, m.theme.Style(style.TextSubtle).Render(m.sortBy.String()))
+	}
+
 	// Show filtered count when a filter is applied.
 	if m.inner.FilterState() == list.FilterApplied {
-		filterSection := fmt.Sprintf(
-			"%d %q",
-			len(m.inner.VisibleItems()),
-			m.inner.FilterValue(),
-		)
-		sections = append(sections, m.theme.Style(style.TextAccent).Render(filterSection))
+		if m.filter.queryError() != nil {
+			sections = append(sections, m.theme.Style(style.TextError).Render(
+				fmt.Sprintf("invalid query %q", m.inner.FilterValue()),
+			))
+		} else {
+			filterSection := fmt.Sprintf(
+				"%d %q",
+				len(m.inner.VisibleItems()),
+				m.inner.FilterValue(),
+			)
+			sections = append(sections, m.theme.Style(style.TextAccent).Render(filterSection))
+		}
 	}
 
 	return sections, dividerBar
@@ -0,0 +1,96 @@
+package resourcelist //nolint:testpackage // Tests internal list items.
+
+import (
+	"testing"
+
+	"charm.land/bubbles/v2/list"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/macropower/kat/pkg/kube"
+	"github.com/macropower/kat/pkg/ui/yamls"
+)
+
+func TestModel_ToggleChangedOnly(t *testing.T) {
+	t.Parallel()
+
+	newDoc := func(name string, diff kube.DiffType) *yamls.Document {
+		return &yamls.Document{
+			Object: &kube.Object{
+				"apiVersion": "v1",
+				"kind":       "ConfigMap",
+				"metadata":   map[string]any{"name": name},
+			},
+			Title: name,
+			Diff:  diff,
+		}
+	}
+
+	tcs := map[string]struct {
+		docs    []*yamls.Document
+		all     []string
+		changed []string
+	}{
+		"no documents": {},
+		"unchanged documents": {
+			docs: []*yamls.Document{
+				newDoc("a", kube.DiffUnchanged),
+				newDoc("b", kube.DiffUnchanged),
+			},
+			all: []string{"a", "b"},
+		},
+		"added, modified and removed documents": {
+			docs: []*yamls.Document{
+				newDoc("a", kube.DiffUnchanged),
+				newDoc("b", kube.DiffAdded),
+				newDoc("c", kube.DiffModified),
+				newDoc("d", kube.DiffUnchanged),
+				newDoc("e", kube.DiffRemoved),
+			},
+			all:     []string{"a", "b", "c", "d", "e"},
+			changed: []string{"b", "c", "e"},
+		},
+	}
+
+	titles := func(items []list.Item) []string {
+		var got []string
+
+		for _, item := range items {
+			doc, ok := item.(*yamls.Document)
+			require.True(t, ok)
+
+			got = append(got, doc.Title)
+		}
+
+		return got
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			delegate := &ItemDelegate{}
+			m := &Model{
+				inner:     list.New(nil, delegate, 0, 0),
+				delegate:  delegate,
+				filter:    &itemFilter{},
+				collapsed: map[string]bool{},
+				groupBy:   GroupByNone,
+				sortBy:    SortByRender,
+			}
+
+			m.SetItems(tc.docs)
+			assert.Equal(t, tc.all, titles(m.inner.Items()))
+
+			m.ToggleChangedOnly()
+			assert.Equal(t, tc.changed, titles(m.inner.Items()))
+
+			// Documents set while the filter is enabled are also filtered.
+			m.SetItems(tc.docs)
+			assert.Equal(t, tc.changed, titles(m.inner.Items()))
+
+			m.ToggleChangedOnly()
+			assert.Equal(t, tc.all, titles(m.inner.Items()))
+		})
+	}
+}
@@ -0,0 +1,139 @@
+package resourcelist
+
+import (
+	"fmt"
+	"sync"
+
+	"charm.land/bubbles/v2/list"
+
+	"github.com/macropower/kat/pkg/keys"
+	"github.com/macropower/kat/pkg/query"
+	"github.com/macropower/kat/pkg/ui/yamls"
+)
+
+const (
+	findPrompt  = "Find:"
+	queryPrompt = "Query:"
+)
+
+// SavedQuery is a query that is applied to the list with a key binding.
+type SavedQuery struct {
+	// Query is a field selector, label selector, or CEL expression.
+	Query string `json:"query" jsonschema:"title=Query"`
+	// Description describes the resources selected by the query.
+	Description string `json:"description,omitempty" jsonschema:"title=Description"`
+	// Keys defines the key bindings that apply the query.
+	Keys []keys.Key `json:"keys,omitempty" jsonschema:"title=Keys"`
+}
+
+// Validate checks that the query can be parsed.
+func (q *SavedQuery) Validate() error {
+	_, err := query.Parse(q.Query)
+	if err != nil {
+		return fmt.Errorf("query %q: %w", q.Query, err)
+	}
+
+	return nil
+}
+
+// KeyBind returns the key binding that applies the query.
+func (q *SavedQuery) KeyBind() keys.KeyBind {
+	desc := q.Description
+	if desc == "" {
+		desc = fmt.Sprintf("query %q", q.Query)
+	}
+
+	return keys.KeyBind{
+		Description: desc,
+		Keys:        q.Keys,
+	}
+}
+
+// itemFilter filters list items, either by fuzzy matching their filter
+// values, or by matching their objects against a [query.Query].
+//
+// The inner list only passes filter values to its filter function, so the
+// items are tracked here to look up the object of each filter value. It is
+// shared between copies of the [Model], and may be used concurrently by
+// filter commands.
+type itemFilter struct {
+	query *query.Query
+	err   error
+	raw   string
+	items []list.Item
+	mu    sync.Mutex
+	// queryMode is true if the filter input is a query.
+	queryMode bool
+}
+
+// setItems sets the items that are filtered, in the same order as the inner
+// list's items.
+func (f *itemFilter) setItems(items []list.Item) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.items = items
+}
+
+// setQueryMode sets whether the filter input is a query.
+func (f *itemFilter) setQueryMode(v bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.queryMode = v
+}
+
+// isQueryMode reports whether the filter input is a query.
+func (f *itemFilter) isQueryMode() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.queryMode
+}
+
+// queryError returns the error from parsing the current query, if any.
+func (f *itemFilter) queryError() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if !f.queryMode {
+		return nil
+	}
+
+	return f.err
+}
+
+// Filter implements [list.FilterFunc].
+func (f *itemFilter) Filter(term string, targets []string) []list.Rank {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if !f.queryMode {
+		return list.DefaultFilter(term, targets)
+	}
+
+	if term != f.raw || (f.query == nil && f.err == nil) {
+		f.raw = term
+		f.query, f.err = query.Parse(term)
+	}
+
+	// The items may have changed since the filter was started.
+	if f.err != nil || len(f.items) != len(targets) {
+		return nil
+	}
+
+	var ranks []list.Rank
+
+	for i, item := range f.items {
+		doc, ok := item.(*yamls.Document)
+		if !ok || doc.Object == nil {
+			continue
+		}
+
+		if f.query.Matches(doc.Object) {
+			ranks = append(ranks, list.Rank{Index: i})
+		}
+	}
+
+	return ranks
+}
@@ -0,0 +1,235 @@
+package resourcelist
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/atotto/clipboard"
+	"go.jacobcolvin.com/niceyaml"
+
+	tea "charm.land/bubbletea/v2"
+
+	"github.com/macropower/kat/pkg/kube"
+	"github.com/macropower/kat/pkg/ui/statusbar"
+	"github.com/macropower/kat/pkg/ui/yamls"
+)
+
+// exportFileFormat is the name of the file that selected resources are
+// exported to, formatted with the current time.
+const exportFileFormat = "kat-export-%s.yaml"
+
+// selection contains the metadata of the selected documents. Documents are
+// selected by their metadata, so that they remain selected when the list is
+// re-rendered.
+type selection map[kube.ResourceMetadata]struct{}
+
+// has reports whether the document is selected.
+func (s selection) has(doc *yamls.Document) bool {
+	if doc.Object == nil {
+		return false
+	}
+
+	_, ok := s[doc.Object.GetMetadata()]
+
+	return ok
+}
+
+// toggle selects the document, or removes it from the selection.
+func (s selection) toggle(doc *yamls.Document) {
+	if doc.Object == nil {
+		return
+	}
+
+	md := doc.Object.GetMetadata()
+
+	_, ok := s[md]
+	if ok {
+		delete(s, md)
+	} else {
+		s[md] = struct{}{}
+	}
+}
+
+// Highlighted returns the document under the cursor, or nil if the cursor is
+// on a group header or the list is empty.
+func (m Model) Highlighted() *yamls.Document {
+	doc, ok := m.inner.SelectedItem().(*yamls.Document)
+	if !ok {
+		return nil
+	}
+
+	return doc
+}
+
+// Selected returns the selected documents, in render order.
+func (m Model) Selected() []*yamls.Document {
+	var docs []*yamls.Document
+
+	for _, doc := range m.docs {
+		if m.selection.has(doc) {
+			docs = append(docs, doc)
+		}
+	}
+
+	return docs
+}
+
+// SelectedResources returns the resources of the selected documents, in
+// render order.
+func (m Model) SelectedResources() []*kube.Resource {
+	docs := m.Selected()
+
+	resources := make([]*kube.Resource, 0, len(docs))
+	for _, doc := range docs {
+		resources = append(resources, &kube.Resource{Object: doc.Object, Source: doc.Body})
+	}
+
+	return resources
+}
+
+// ToggleSelect selects the highlighted document, or removes it from the
+// selection, and moves the cursor to the next item.
+func (m *Model) ToggleSelect() tea.Cmd {
+	doc, ok := m.inner.SelectedItem().(*yamls.Document)
+	if !ok {
+		return nil
+	}
+
+	m.selection.toggle(doc)
+	m.inner.CursorDown()
+
+	return m.selectionStatus()
+}
+
+// SelectAll selects every visible document. If every visible document is
+// already selected, they are removed from the selection instead.
+func (m *Model) SelectAll() tea.Cmd {
+	var docs []*yamls.Document
+
+	for _, item := range m.inner.VisibleItems() {
+		doc, ok := item.(*yamls.Document)
+		if ok && doc.Object != nil {
+			docs = append(docs, doc)
+		}
+	}
+
+	all := true
+
+	for _, doc := range docs {
+		if !m.selection.has(doc) {
+			all = false
+
+			break
+		}
+	}
+
+	for _, doc := range docs {
+		if all {
+			delete(m.selection, doc.Object.GetMetadata())
+		} else {
+			m.selection[doc.Object.GetMetadata()] = struct{}{}
+		}
+	}
+
+	return m.selectionStatus()
+}
+
+// ClearSelection removes all documents from the selection. It reports
+// whether any documents were selected.
+func (m *Model) ClearSelection() bool {
+	if len(m.selection) == 0 {
+		return false
+	}
+
+	clear(m.selection)
+
+	return true
+}
+
+// CopySelected copies the selected documents to the clipboard, as a single
+// multi-document YAML stream. Secret values are copied masked.
+func (m *Model) CopySelected() tea.Cmd {
+	docs := m.Selected()
+	if len(docs) == 0 {
+		return m.SetStatusMessage("no resources selected", statusbar.StyleError)
+	}
+
+	content := m.joinSelected(docs, true)
+
+	return tea.Sequence(
+		tea.SetClipboard(content),
+		func() tea.Msg {
+			_ = clipboard.WriteAll(content) //nolint:errcheck // Can be ignored.
+			return nil
+		},
+		m.SetStatusMessage(fmt.Sprintf("copied %d resources", len(docs)), statusbar.StyleSuccess),
+	)
+}
+
+// ExportSelected writes the selected documents to a new file in the current
+// working directory, as a single multi-document YAML stream.
+func (m *Model) ExportSelected() tea.Cmd {
+	docs := m.Selected()
+	if len(docs) == 0 {
+		return m.SetStatusMessage("no resources selected", statusbar.StyleError)
+	}
+
+	path := fmt.Sprintf(exportFileFormat, time.Now().Format("20060102-150405"))
+
+	err := os.WriteFile(path, []byte(m.joinSelected(docs, false)), 0o600)
+	if err != nil {
+		return m.SetStatusMessage(fmt.Sprintf("export: %v", err), statusbar.StyleError)
+	}
+
+	return m.SetStatusMessage(fmt.Sprintf("exported %d resources to %s", len(docs), path), statusbar.StyleSuccess)
+}
+
+// OpenSelected returns a command that opens the selected documents,
+// concatenated into a single document. Secret values are masked.
+func (m *Model) OpenSelected() tea.Cmd {
+	docs := m.Selected()
+	if len(docs) == 0 {
+		return nil
+	}
+
+	return LoadYAML(&yamls.Document{
+		Body:  niceyaml.NewSourceFromString(m.joinSelected(docs, true)),
+		Title: fmt.Sprintf("%d resources", len(docs)),
+		Desc:  "selection",
+	})
+}
+
+// joinSelected joins the documents into a multi-document YAML stream. If
+// mask is set, secret values are masked.
+func (m *Model) joinSelected(docs []*yamls.Document, mask bool) string {
+	resources := make([]*kube.Resource, 0, len(docs))
+
+	for _, doc := range docs {
+		r := &kube.Resource{Object: doc.Object, Source: doc.Body}
+
+		if mask && m.secrets != nil && m.secrets.IsSecret(doc.Object) {
+			content, err := m.secrets.Mask(doc.Object, doc.Body.Content())
+			if err != nil {
+				content = fmt.Sprintf("# Secret values are hidden: %v\n", err)
+			}
+
+			r.Source = niceyaml.NewSourceFromString(content)
+		}
+
+		resources = append(resources, r)
+	}
+
+	return kube.JoinResources(resources)
+}
+
+// selectionStatus returns a status message with the number of selected
+// documents.
+func (m *Model) selectionStatus() tea.Cmd {
+	n := len(m.Selected())
+	if n == 0 {
+		return m.SetStatusMessage("selection cleared", statusbar.StyleSuccess)
+	}
+
+	return m.SetStatusMessage(fmt.Sprintf("%d selected", n), statusbar.StyleSuccess)
+}
@@ -0,0 +1,43 @@
+package resourcelist
+
+import (
+	"fmt"
+
+	"github.com/goccy/go-yaml"
+	"go.jacobcolvin.com/niceyaml"
+
+	tea "charm.land/bubbletea/v2"
+
+	"github.com/macropower/kat/pkg/command"
+	"github.com/macropower/kat/pkg/ui/statusbar"
+	"github.com/macropower/kat/pkg/ui/yamls"
+)
+
+// Warnings returns the warnings printed by the last render.
+func (m Model) Warnings() []command.Warning {
+	return m.warnings
+}
+
+// SetWarnings sets the warnings printed by the last render.
+func (m *Model) SetWarnings(warnings []command.Warning) {
+	m.warnings = warnings
+}
+
+// ShowWarnings returns a command that opens the warnings printed by the last
+// render as a YAML document.
+func (m *Model) ShowWarnings() tea.Cmd {
+	if len(m.warnings) == 0 {
+		return m.SetStatusMessage("no warnings", statusbar.StyleSuccess)
+	}
+
+	b, err := yaml.Marshal(m.warnings)
+	if err != nil {
+		return m.SetStatusMessage(fmt.Sprintf("warnings: %v", err), statusbar.StyleError)
+	}
+
+	return LoadYAML(&yamls.Document{
+		Body:  niceyaml.NewSourceFromString(string(b)),
+		Title: fmt.Sprintf("%d warnings", len(m.warnings)),
+		Desc:  "warnings",
+	})
+}
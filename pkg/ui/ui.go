@@ -21,6 +21,7 @@ import (
 	"github.com/macropower/kat/pkg/keys"
 	"github.com/macropower/kat/pkg/kube"
 	"github.com/macropower/kat/pkg/log"
+	"github.com/macropower/kat/pkg/profile"
 	"github.com/macropower/kat/pkg/ui/common"
 	"github.com/macropower/kat/pkg/ui/menu"
 	"github.com/macropower/kat/pkg/ui/pager"
@@ -50,6 +51,13 @@ type GotResultMsg command.Output
 
 type ShowResultMsg struct{}
 
+// GotMatrixMsg contains the result of rendering every variant of the current
+// profile.
+type GotMatrixMsg struct {
+	Err    error
+	Output command.MatrixOutput
+}
+
 // State is the top-level application State.
 type State int
 
@@ -76,6 +84,7 @@ type model struct {
 	resultDocument yamls.Document
 	lastMouseEvent time.Time
 	result         string
+	resources      []*kube.Resource
 	list           resourcelist.Model
 	menu           menu.Model
 	spinner        spinner.Model
@@ -149,22 +158,22 @@ func (m *model) unloadDocument() tea.Cmd {
 
 func newModel(cfg *Config, cmd common.Commander) *model {
 	uiTheme := cfg.UI.Theme
-	_, profile := cmd.GetCurrentProfile()
-	if profile != nil && profile.UI != nil {
-		if profile.UI.Theme != "" {
-			uiTheme = profile.UI.Theme
+	_, prof := cmd.GetCurrentProfile()
+	if prof != nil && prof.UI != nil {
+		if prof.UI.Theme != "" {
+			uiTheme = prof.UI.Theme
 		}
 
-		if profile.UI.Compact != nil {
-			cfg.UI.Compact = profile.UI.Compact
+		if prof.UI.Compact != nil {
+			cfg.UI.Compact = prof.UI.Compact
 		}
 
-		if profile.UI.WordWrap != nil {
-			cfg.UI.WordWrap = profile.UI.WordWrap
+		if prof.UI.WordWrap != nil {
+			cfg.UI.WordWrap = prof.UI.WordWrap
 		}
 
-		if profile.UI.LineNumbers != nil {
-			cfg.UI.LineNumbers = profile.UI.LineNumbers
+		if prof.UI.LineNumbers != nil {
+			cfg.UI.LineNumbers = prof.UI.LineNumbers
 		}
 	}
 
@@ -188,12 +197,22 @@ func newModel(cfg *Config, cmd common.Commander) *model {
 
 	ckb := cfg.KeyBinds.Common
 
+	var secrets *kube.SecretMasker
+	if *cfg.Secrets.Mask {
+		secrets = kube.NewSecretMasker(cfg.Secrets.Kinds...)
+	}
+
 	listModel := resourcelist.NewModel(resourcelist.Config{
-		Theme:     t,
-		KeyBinds:  cfg.KeyBinds.List,
-		CKeyBinds: ckb,
-		Cmd:       cmd,
-		Compact:   *cfg.UI.Compact,
+		Theme:      t,
+		KeyBinds:   cfg.KeyBinds.List,
+		CKeyBinds:  ckb,
+		Cmd:        cmd,
+		GroupBy:    resourcelist.GroupBy(cfg.UI.GroupBy),
+		SortBy:     resourcelist.SortBy(cfg.UI.SortBy),
+		GroupLabel: cfg.UI.GroupLabel,
+		Queries:    cfg.Queries,
+		Secrets:    secrets,
+		Compact:    *cfg.UI.Compact,
 	})
 
 	pagerModel := pager.NewModel(pager.Config{
@@ -202,6 +221,7 @@ func newModel(cfg *Config, cmd common.Commander) *model {
 		CKeyBinds: ckb,
 		Cmd:       cmd,
 		Printer:   printer,
+		Secrets:   secrets,
 	})
 
 	menuModel, err := menu.NewModel(menu.Config{
@@ -292,6 +312,38 @@ func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case ShowResultMsg:
 		cmds = append(cmds, m.showResultInPager())
 
+	case resourcelist.CompareYAMLMsg:
+		cmds = append(cmds, m.setState(stateShowDocument), common.CmdHandler(pager.LoadSplitMsg{
+			Left:  *msg.Left,
+			Right: *msg.Right,
+		}))
+
+	case resourcelist.RenderMatrixMsg:
+		m.loaded = false
+		m.overlayState = overlayStateLoading
+		cmds = append(cmds, m.spinner.Tick, m.runMatrix(context.Background()))
+
+	case GotMatrixMsg:
+		m.loaded = true
+		m.overlayState = overlayStateNone
+
+		if msg.Err != nil {
+			m.err = msg.Err
+			m.overlayState = overlayStateError
+
+			break
+		}
+
+		doc, err := matrixToYAML(msg.Output)
+		if err != nil {
+			m.err = err
+			m.overlayState = overlayStateError
+
+			break
+		}
+
+		cmds = append(cmds, m.setState(stateShowDocument), common.CmdHandler(pager.LoadDocumentMsg{Document: *doc}))
+
 	case command.EventEnd:
 		cmds = append(cmds, m.handleResourceUpdate(msg)...)
 
@@ -314,6 +366,10 @@ func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case command.EventListResources:
 		cmds = append(cmds, m.unloadDocument())
 
+	case pager.OpenResourceMsg:
+		doc := kubeResourceToYAML(msg.Resource)
+		cmds = append(cmds, m.setState(stateShowDocument), common.CmdHandler(pager.LoadDocumentMsg{Document: *doc}))
+
 	case command.EventOpenResource:
 		cmds = append(cmds, m.setState(stateShowDocument))
 
@@ -322,12 +378,15 @@ func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		cmds = append(cmds, common.CmdHandler(pager.LoadDocumentMsg{Document: *yamlDoc}))
 
 	case menu.ChangeConfigMsg:
+		// Renders for a different configuration are not comparable.
+		m.resources = nil
 		m.list.SetItems(nil)
 
 		cmds = append(cmds, m.unloadDocument())
 
 		err := m.cmd.ConfigureContext(msg.Context,
 			command.WithProfile(msg.To.Profile),
+			command.WithVariant(msg.To.Variant),
 			command.WithPath(msg.To.File),
 			command.WithExtraArgs(msg.To.ExtraArgs...),
 		)
@@ -521,9 +580,9 @@ func (m *model) handleKeyPress(msg tea.KeyPressMsg) (tea.Model, tea.Cmd) {
 	}
 
 	// Handle plugin keybinds.
-	_, profile := m.cmd.GetCurrentProfile()
-	if profile != nil && !m.isTextInputFocused() {
-		if pluginName := profile.GetPluginNameByKey(msg.String()); pluginName != "" {
+	_, prof := m.cmd.GetCurrentProfile()
+	if prof != nil && !m.isTextInputFocused() {
+		if pluginName := prof.GetPluginNameByKey(msg.String(), m.pluginScope()); pluginName != "" {
 			cmd := m.runPlugin(context.Background(), pluginName)
 
 			return m, cmd
@@ -545,7 +604,8 @@ func (m *model) handleGlobalKeys(msg tea.KeyPressMsg) (tea.Model, tea.Cmd, bool)
 		return m, tea.Quit, true
 
 	case m.matchAction(m.kb.Common.Escape, msg):
-		isShowingDocument := m.state == stateShowDocument && !m.pager.IsSearching()
+		isShowingDocument := m.state == stateShowDocument && !m.pager.IsSearching() &&
+			!m.pager.IsShowingRelated() && !m.pager.IsShowingSplit()
 		isShowingMenu := m.state == stateShowMenu
 		isShowingList := m.state == stateShowList
 
@@ -555,12 +615,24 @@ func (m *model) handleGlobalKeys(msg tea.KeyPressMsg) (tea.Model, tea.Cmd, bool)
 			cmds = append(cmds, m.unloadDocument())
 		}
 
+		// Clear the selection once the filter is cleared.
+		if isShowingList && !m.list.IsFiltered() {
+			m.list.ClearSelection()
+		}
+
 		if isShowingList {
 			m.list.ResetFiltering()
 		}
 
 		if m.state == stateShowDocument {
 			m.pager.ExitSearch()
+
+			// Close the related resources before the split view.
+			if m.pager.IsShowingRelated() {
+				m.pager.CloseRelated()
+			} else {
+				m.pager.CloseSplit()
+			}
 		}
 
 		return m, tea.Batch(cmds...), true
@@ -624,11 +696,16 @@ func (m *model) handleResourceUpdate(msg command.EventEnd) []tea.Cmd {
 
 	cmds = append(cmds, m.routeCommandResult(msg.Output)...)
 
+	if msg.Output.Type == command.TypeRun && msg.Output.Error == nil {
+		m.list.SetWarnings(msg.Output.Warnings)
+	}
+
 	if len(msg.Output.Resources) == 0 {
 		return cmds
 	}
 
-	docs := resourcesToDocuments(msg.Output.Resources)
+	docs := m.diffResources(msg.Output.Resources)
+	m.pager.SetGraph(kube.NewGraph(msg.Output.Resources))
 
 	cmds = append(cmds, m.list.SetItems(docs))
 	cmds = append(cmds, m.notifyPagerRevisions(docs)...)
@@ -636,6 +713,40 @@ func (m *model) handleResourceUpdate(msg command.EventEnd) []tea.Cmd {
 	return cmds
 }
 
+// diffResources converts kubernetes resources to YAML documents, marking each
+// one with how it changed since the previous render. Resources that were
+// removed are included so that they remain visible in the list.
+func (m *model) diffResources(resources []*kube.Resource) []*yamls.Document {
+	if m.resources == nil {
+		// Nothing to compare against on the first render.
+		m.resources = resources
+
+		return resourcesToDocuments(resources)
+	}
+
+	diffs := kube.DiffResources(m.resources, resources)
+	m.resources = resources
+
+	docs := make([]*yamls.Document, 0, len(diffs))
+	for _, d := range diffs {
+		res := d.New
+		if d.Type == kube.DiffRemoved {
+			res = d.Old
+		}
+
+		doc := kubeResourceToYAML(res)
+		doc.Diff = d.Type
+
+		if d.Type == kube.DiffRemoved {
+			// Diagnostics only apply to the current render.
+			doc.Diagnostics = nil
+		}
+		docs = append(docs, doc)
+	}
+
+	return docs
+}
+
 // routeCommandResult sends error or plugin output to the result overlay.
 func (m *model) routeCommandResult(output command.Output) []tea.Cmd {
 	if output.Error != nil || output.Type == command.TypePlugin {
@@ -665,6 +776,10 @@ func (m *model) notifyPagerRevisions(docs []*yamls.Document) []tea.Cmd {
 	var cmds []tea.Cmd
 
 	for _, doc := range docs {
+		if doc.Diff == kube.DiffRemoved {
+			continue
+		}
+
 		if kube.ObjectEqual(doc.Object, m.pager.CurrentDocumentObject()) {
 			cmds = append(cmds, common.CmdHandler(pager.RevisionMsg{Document: *doc}))
 		}
@@ -728,13 +843,60 @@ func (m *model) runCommand(ctx context.Context) tea.Cmd {
 	}
 }
 
+func (m *model) runMatrix(ctx context.Context) tea.Cmd {
+	return func() tea.Msg {
+		out, err := m.cmd.RunMatrixContext(ctx)
+
+		return GotMatrixMsg{Output: out, Err: err}
+	}
+}
+
+// pluginScope returns the scope of the plugins that can be run in the
+// current view.
+func (m *model) pluginScope() profile.PluginScope {
+	if m.state == stateShowDocument {
+		return profile.PluginScopePager
+	}
+
+	return profile.PluginScopeList
+}
+
+// runPlugin runs the named plugin. The plugin is passed the resource that is
+// currently shown, or highlighted in the list, and the resources that are
+// selected in the list.
 func (m *model) runPlugin(ctx context.Context, name string) tea.Cmd {
+	var (
+		opts []command.PluginRunOpt
+		doc  *yamls.Document
+	)
+
+	switch m.state {
+	case stateShowDocument:
+		doc = &m.pager.CurrentDocument
+
+	case stateShowList:
+		doc = m.list.Highlighted()
+
+		resources := m.list.SelectedResources()
+		if len(resources) > 0 {
+			opts = append(opts, command.WithPluginResources(resources...))
+		}
+
+	case stateShowMenu:
+	}
+
+	// Documents without an object, e.g. errors or concatenated resources,
+	// do not describe a single resource.
+	if doc != nil && doc.Object != nil {
+		opts = append(opts, command.WithPluginResource(&kube.Resource{Object: doc.Object, Source: doc.Body}))
+	}
+
 	return func() tea.Msg {
 		log.WithContext(ctx).DebugContext(ctx, "running plugin",
 			slog.String("name", name),
 		)
 
-		go m.cmd.RunPluginContext(ctx, name)
+		go m.cmd.RunPluginContext(ctx, name, opts...)
 
 		return nil
 	}
@@ -743,9 +905,39 @@ func (m *model) runPlugin(ctx context.Context, name string) tea.Cmd {
 // Convert a [kube.Resource] to an internal representation of a YAML document.
 func kubeResourceToYAML(res *kube.Resource) *yamls.Document {
 	return &yamls.Document{
-		Object: res.Object,
-		Body:   res.Source,
-		Title:  res.Object.GetNamespacedName(),
-		Desc:   res.Object.GetGroupKind(),
+		Object:      res.Object,
+		Body:        res.Source,
+		Title:       res.Object.GetNamespacedName(),
+		Desc:        res.Object.GetGroupKind(),
+		Diagnostics: res.Diagnostics,
 	}
 }
+
+// matrixToYAML converts the result of a matrix render to a YAML document.
+// Variants that failed to render are listed as leading comments.
+func matrixToYAML(out command.MatrixOutput) (*yamls.Document, error) {
+	body, err := out.Matrix.YAML()
+	if err != nil {
+		return nil, err //nolint:wrapcheck // Already wrapped.
+	}
+
+	var b strings.Builder
+
+	for i, o := range out.Outputs {
+		if o.Error == nil {
+			continue
+		}
+
+		for line := range strings.SplitSeq(strings.TrimSpace(o.Error.Error()), "\n") {
+			fmt.Fprintf(&b, "# error: %s: %s\n", out.Matrix.Names[i], line)
+		}
+	}
+
+	b.Write(body)
+
+	return &yamls.Document{
+		Body:  niceyaml.NewSourceFromString(b.String()),
+		Title: "matrix",
+		Desc:  strings.Join(out.Matrix.Names, ", "),
+	}, nil
+}
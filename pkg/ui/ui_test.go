@@ -0,0 +1,133 @@
+package ui //nolint:testpackage // Tests internal diffing of renders.
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/macropower/kat/pkg/kube"
+)
+
+func newConfigMap(name, value string, diagnostics ...kube.Diagnostic) *kube.Resource {
+	return &kube.Resource{
+		Object: &kube.Object{
+			"apiVersion": "v1",
+			"kind":       "ConfigMap",
+			"metadata":   map[string]any{"name": name, "namespace": "default"},
+			"data":       map[string]any{"key": value},
+		},
+		Diagnostics: diagnostics,
+	}
+}
+
+func TestModel_DiffResources(t *testing.T) {
+	t.Parallel()
+
+	type result struct {
+		title       string
+		diff        kube.DiffType
+		diagnostics int
+	}
+
+	warning := kube.Diagnostic{Severity: kube.SeverityWarning, Source: "test"}
+
+	tcs := map[string]struct {
+		renders [][]*kube.Resource
+		want    []result
+	}{
+		"first render": {
+			renders: [][]*kube.Resource{
+				{newConfigMap("a", "v"), newConfigMap("b", "v")},
+			},
+			want: []result{
+				{title: "default/a", diff: kube.DiffUnchanged},
+				{title: "default/b", diff: kube.DiffUnchanged},
+			},
+		},
+		"unchanged documents": {
+			renders: [][]*kube.Resource{
+				{newConfigMap("a", "v")},
+				{newConfigMap("a", "v")},
+			},
+			want: []result{
+				{title: "default/a", diff: kube.DiffUnchanged},
+			},
+		},
+		"added document": {
+			renders: [][]*kube.Resource{
+				{newConfigMap("a", "v")},
+				{newConfigMap("a", "v"), newConfigMap("b", "v")},
+			},
+			want: []result{
+				{title: "default/a", diff: kube.DiffUnchanged},
+				{title: "default/b", diff: kube.DiffAdded},
+			},
+		},
+		"changed document": {
+			renders: [][]*kube.Resource{
+				{newConfigMap("a", "v1")},
+				{newConfigMap("a", "v2", warning)},
+			},
+			want: []result{
+				{title: "default/a", diff: kube.DiffModified, diagnostics: 1},
+			},
+		},
+		"removed document is retained without diagnostics": {
+			renders: [][]*kube.Resource{
+				{newConfigMap("a", "v"), newConfigMap("b", "v", warning)},
+				{newConfigMap("a", "v")},
+			},
+			want: []result{
+				{title: "default/a", diff: kube.DiffUnchanged},
+				{title: "default/b", diff: kube.DiffRemoved},
+			},
+		},
+		"removed document is dropped after the next render": {
+			renders: [][]*kube.Resource{
+				{newConfigMap("a", "v"), newConfigMap("b", "v")},
+				{newConfigMap("a", "v")},
+				{newConfigMap("a", "v")},
+			},
+			want: []result{
+				{title: "default/a", diff: kube.DiffUnchanged},
+			},
+		},
+		"added, changed and removed documents": {
+			renders: [][]*kube.Resource{
+				{newConfigMap("a", "v1"), newConfigMap("b", "v")},
+				{newConfigMap("c", "v"), newConfigMap("a", "v2")},
+			},
+			want: []result{
+				{title: "default/c", diff: kube.DiffAdded},
+				{title: "default/a", diff: kube.DiffModified},
+				{title: "default/b", diff: kube.DiffRemoved},
+			},
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			m := &model{}
+
+			var got []result
+
+			for _, resources := range tc.renders {
+				got = nil
+
+				for _, doc := range m.diffResources(resources) {
+					got = append(got, result{
+						title:       doc.Title,
+						diff:        doc.Diff,
+						diagnostics: len(doc.Diagnostics),
+					})
+				}
+			}
+
+			require.Len(t, got, len(tc.want))
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
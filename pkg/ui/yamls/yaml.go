@@ -21,6 +21,30 @@ type Document struct {
 	Body  *niceyaml.Source
 	Title string
 	Desc  string
+
+	// Diff describes how the document changed compared with the previous
+	// render. It is [kube.DiffUnchanged] if there is no previous render.
+	Diff kube.DiffType
+
+	// Diagnostics contains problems found in the document's resource, e.g.
+	// by schema validation.
+	Diagnostics []kube.Diagnostic
+}
+
+// DiagnosticSeverity returns the most severe diagnostic severity, or an empty
+// string if the document has no diagnostics.
+func (m *Document) DiagnosticSeverity() kube.Severity {
+	var severity kube.Severity
+
+	for _, d := range m.Diagnostics {
+		if d.Severity == kube.SeverityError {
+			return kube.SeverityError
+		}
+
+		severity = d.Severity
+	}
+
+	return severity
 }
 
 // FilterValue returns the value to filter against.
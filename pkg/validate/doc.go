@@ -0,0 +1,11 @@
+// Package validate checks rendered Kubernetes resources against JSON schemas.
+//
+// Every resource is checked against an embedded schema for the fields that are
+// common to all Kubernetes objects. Schemas for each kind are looked up by
+// group, version and kind in the configured local directories, which can use
+// the layout of the kubeconform schema repositories
+// (`{kind}-{group}-{version}.json`), or of the CRDs-catalog
+// (`{group}/{kind}_{version}.json`).
+//
+// Problems are reported as [kube.Diagnostic] values on each resource.
+package validate
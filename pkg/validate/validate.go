@@ -0,0 +1,382 @@
+package validate
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+
+	"github.com/macropower/kat/pkg/kube"
+)
+
+// DiagnosticSource is the [kube.Diagnostic] source used for schema validation
+// problems.
+const DiagnosticSource = "schema"
+
+const (
+	// bundleScheme is the URL scheme used for embedded schemas.
+	bundleScheme = "bundle"
+	// envelopeURL is the embedded schema for the fields that are common to all
+	// resources, which all resources are validated against.
+	envelopeURL = bundleScheme + ":///envelope.json"
+)
+
+var (
+	//go:embed schemas/*.json
+	bundleFS embed.FS
+
+	// ErrInvalidSchema is returned when a schema cannot be loaded or compiled.
+	ErrInvalidSchema = errors.New("invalid schema")
+	// ErrNoSchemaDirs is returned when validation is enabled without any
+	// schema directories.
+	ErrNoSchemaDirs = errors.New("validation requires at least one schema directory")
+
+	printer = message.NewPrinter(language.English)
+
+	simpleKeyRe = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_-]*$`)
+)
+
+// Config configures schema validation of rendered resources.
+type Config struct {
+	// Enabled controls whether rendered resources are validated.
+	// Validation is disabled by default.
+	Enabled *bool `json:"enabled,omitempty" jsonschema:"title=Enabled"`
+	// SchemaDirs contains directories to search for JSON schemas, in order.
+	// At least one directory is required when validation is enabled.
+	SchemaDirs []string `json:"schemaDirs,omitempty" jsonschema:"title=Schema Directories"`
+}
+
+// IsEnabled reports whether validation is enabled.
+func (c *Config) IsEnabled() bool {
+	return c != nil && c.Enabled != nil && *c.Enabled
+}
+
+// Validate returns [ErrNoSchemaDirs] if validation is enabled without any
+// schema directories.
+func (c *Config) Validate() error {
+	if c.IsEnabled() && len(c.SchemaDirs) == 0 {
+		return ErrNoSchemaDirs
+	}
+
+	return nil
+}
+
+// Validator validates [kube.Resource] objects against JSON schemas.
+// It is safe for concurrent use.
+type Validator struct {
+	compiler *jsonschema.Compiler
+	envelope *jsonschema.Schema
+	schemas  map[string]schemaResult // Keyed by apiVersion and kind.
+	dirs     []string
+	mu       sync.Mutex
+}
+
+type schemaResult struct {
+	schema *jsonschema.Schema
+	err    error
+}
+
+// ValidatorOpt is a functional option for configuring a [Validator].
+type ValidatorOpt func(*Validator)
+
+// WithSchemaDirs sets the local directories that are searched for schemas.
+// Without any directories, resources are only checked for common fields.
+func WithSchemaDirs(dirs ...string) ValidatorOpt {
+	return func(v *Validator) {
+		v.dirs = dirs
+	}
+}
+
+// New creates a new [Validator].
+func New(opts ...ValidatorOpt) (*Validator, error) {
+	c := jsonschema.NewCompiler()
+	c.UseLoader(jsonschema.SchemeURLLoader{
+		"file":       jsonschema.FileLoader{},
+		bundleScheme: bundleLoader{},
+	})
+
+	v := &Validator{
+		compiler: c,
+		schemas:  make(map[string]schemaResult),
+	}
+	for _, opt := range opts {
+		opt(v)
+	}
+
+	envelope, err := c.Compile(envelopeURL)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidSchema, err)
+	}
+
+	v.envelope = envelope
+
+	return v, nil
+}
+
+// NewFromConfig creates a new [Validator] from a [Config].
+func NewFromConfig(c *Config) (*Validator, error) {
+	err := c.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	return New(WithSchemaDirs(c.SchemaDirs...))
+}
+
+// Validate validates each resource, and appends any problems to the
+// resource's diagnostics.
+func (v *Validator) Validate(resources []*kube.Resource) {
+	for _, r := range resources {
+		r.Diagnostics = append(r.Diagnostics, v.ValidateObject(r.Object)...)
+	}
+}
+
+// ValidateObject validates a single object and returns any problems.
+// Objects are validated against the common Kubernetes object schema, and
+// then against the schema for their kind, if one can be found.
+func (v *Validator) ValidateObject(obj *kube.Object) []kube.Diagnostic {
+	inst, err := toInstance(obj)
+	if err != nil {
+		return []kube.Diagnostic{newDiagnostic(kube.SeverityError, "", err.Error())}
+	}
+
+	diags := validateInstance(v.envelope, inst)
+	if len(diags) > 0 {
+		// Without a valid apiVersion and kind, we cannot find a kind schema.
+		return diags
+	}
+
+	sch, err := v.schemaFor(obj.GetAPIVersion(), obj.GetKind())
+	if err != nil {
+		return []kube.Diagnostic{newDiagnostic(kube.SeverityWarning, "", err.Error())}
+	}
+
+	if sch == nil {
+		return nil
+	}
+
+	return validateInstance(sch, inst)
+}
+
+// schemaFor returns the compiled schema for the given apiVersion and kind.
+// It returns nil if no schema could be found.
+func (v *Validator) schemaFor(apiVersion, kind string) (*jsonschema.Schema, error) {
+	key := apiVersion + "/" + kind
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if res, ok := v.schemas[key]; ok {
+		return res.schema, res.err
+	}
+
+	var res schemaResult
+
+	loc := v.findSchema(apiVersion, kind)
+	if loc != "" {
+		res.schema, res.err = v.compiler.Compile(loc)
+		if res.err != nil {
+			res.err = fmt.Errorf("%w for %s: %w", ErrInvalidSchema, key, res.err)
+		}
+	}
+
+	v.schemas[key] = res
+
+	return res.schema, res.err
+}
+
+// findSchema returns the URL of the schema for the given apiVersion and
+// kind, or an empty string if no schema exists.
+func (v *Validator) findSchema(apiVersion, kind string) string {
+	names := schemaFileNames(apiVersion, kind)
+
+	for _, dir := range v.dirs {
+		for _, name := range names {
+			path, err := filepath.Abs(filepath.Join(dir, filepath.FromSlash(name)))
+			if err != nil {
+				continue
+			}
+
+			if _, err := os.Stat(path); err == nil {
+				return (&url.URL{Scheme: "file", Path: filepath.ToSlash(path)}).String()
+			}
+		}
+	}
+
+	return ""
+}
+
+// schemaFileNames returns the candidate schema file names for the given
+// apiVersion and kind, using the kubeconform and CRDs-catalog layouts.
+func schemaFileNames(apiVersion, kind string) []string {
+	group, version, ok := strings.Cut(apiVersion, "/")
+	if !ok {
+		group, version = "", apiVersion
+	}
+
+	kind = strings.ToLower(kind)
+
+	if group == "" {
+		return []string{fmt.Sprintf("%s-%s.json", kind, version)}
+	}
+
+	shortGroup, _, _ := strings.Cut(group, ".")
+
+	return []string{
+		fmt.Sprintf("%s-%s-%s.json", kind, shortGroup, version),
+		fmt.Sprintf("%s/%s_%s.json", group, kind, version),
+	}
+}
+
+// toInstance converts obj into the plain JSON types expected by the schema
+// validator.
+func toInstance(obj *kube.Object) (any, error) {
+	b, err := json.Marshal(obj)
+	if err != nil {
+		return nil, fmt.Errorf("encode object: %w", err)
+	}
+
+	inst, err := jsonschema.UnmarshalJSON(bytes.NewReader(b))
+	if err != nil {
+		return nil, fmt.Errorf("decode object: %w", err)
+	}
+
+	return inst, nil
+}
+
+// validateInstance validates inst against sch, and converts each failed
+// keyword into a [kube.Diagnostic].
+func validateInstance(sch *jsonschema.Schema, inst any) []kube.Diagnostic {
+	err := sch.Validate(inst)
+	if err == nil {
+		return nil
+	}
+
+	var verr *jsonschema.ValidationError
+	if !errors.As(err, &verr) {
+		return []kube.Diagnostic{newDiagnostic(kube.SeverityError, "", err.Error())}
+	}
+
+	var (
+		diags []kube.Diagnostic
+		seen  = make(map[kube.Diagnostic]struct{})
+	)
+
+	for _, leaf := range leafErrors(verr) {
+		d := newDiagnostic(kube.SeverityError,
+			yamlPath(inst, leaf.InstanceLocation),
+			leaf.ErrorKind.LocalizedString(printer))
+
+		// Branches of anyOf/oneOf can report the same problem more than once.
+		if _, ok := seen[d]; ok {
+			continue
+		}
+
+		seen[d] = struct{}{}
+		diags = append(diags, d)
+	}
+
+	return diags
+}
+
+// leafErrors returns the most specific errors in the tree.
+func leafErrors(err *jsonschema.ValidationError) []*jsonschema.ValidationError {
+	if len(err.Causes) == 0 {
+		return []*jsonschema.ValidationError{err}
+	}
+
+	var leaves []*jsonschema.ValidationError
+	for _, cause := range err.Causes {
+		leaves = append(leaves, leafErrors(cause)...)
+	}
+
+	return leaves
+}
+
+// yamlPath converts a JSON instance location into a YAML path, e.g.
+// `$.spec.containers[0].image`. The instance is used to tell list indices
+// apart from map keys.
+func yamlPath(inst any, loc []string) string {
+	var b strings.Builder
+
+	b.WriteString("$")
+
+	cur := inst
+	for _, tok := range loc {
+		switch v := cur.(type) {
+		case []any:
+			b.WriteString("[" + tok + "]")
+
+			i, err := strconv.Atoi(tok)
+			if err != nil || i < 0 || i >= len(v) {
+				cur = nil
+			} else {
+				cur = v[i]
+			}
+
+		case map[string]any:
+			writeKey(&b, tok)
+
+			cur = v[tok]
+
+		default:
+			writeKey(&b, tok)
+
+			cur = nil
+		}
+	}
+
+	return b.String()
+}
+
+func writeKey(b *strings.Builder, key string) {
+	if simpleKeyRe.MatchString(key) {
+		b.WriteString("." + key)
+	} else {
+		b.WriteString(".'" + strings.ReplaceAll(key, "'", "\\'") + "'")
+	}
+}
+
+func newDiagnostic(severity kube.Severity, path, msg string) kube.Diagnostic {
+	return kube.Diagnostic{
+		Severity: severity,
+		Source:   DiagnosticSource,
+		Path:     path,
+		Message:  msg,
+	}
+}
+
+// bundleLoader loads embedded schemas.
+type bundleLoader struct{}
+
+func (bundleLoader) Load(rawURL string) (any, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("parse url: %w", err)
+	}
+
+	f, err := bundleFS.Open("schemas/" + strings.TrimPrefix(u.Path, "/"))
+	if err != nil {
+		return nil, fmt.Errorf("open embedded schema: %w", err)
+	}
+	defer f.Close() //nolint:errcheck // Best-effort close.
+
+	doc, err := jsonschema.UnmarshalJSON(f)
+	if err != nil {
+		return nil, fmt.Errorf("decode embedded schema: %w", err)
+	}
+
+	return doc, nil
+}
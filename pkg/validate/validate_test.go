@@ -0,0 +1,255 @@
+package validate_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/macropower/kat/pkg/kube"
+	"github.com/macropower/kat/pkg/validate"
+)
+
+func TestValidator_ValidateObject(t *testing.T) {
+	t.Parallel()
+
+	tcs := map[string]struct {
+		obj  kube.Object
+		want []kube.Diagnostic
+	}{
+		"valid configmap": {
+			obj: kube.Object{
+				"apiVersion": "v1",
+				"kind":       "ConfigMap",
+				"metadata":   map[string]any{"name": "test", "namespace": "default"},
+				"data":       map[string]any{"key": "value"},
+			},
+		},
+		"non-string configmap data": {
+			obj: kube.Object{
+				"apiVersion": "v1",
+				"kind":       "ConfigMap",
+				"metadata":   map[string]any{"name": "test"},
+				"data":       map[string]any{"port": 8080},
+			},
+			want: []kube.Diagnostic{{
+				Severity: kube.SeverityError,
+				Source:   validate.DiagnosticSource,
+				Path:     "$.data.port",
+				Message:  "got number, want string",
+			}},
+		},
+		"unknown field": {
+			obj: kube.Object{
+				"apiVersion": "v1",
+				"kind":       "Secret",
+				"metadata":   map[string]any{"name": "test"},
+				"strigData":  map[string]any{"key": "value"},
+			},
+			want: []kube.Diagnostic{{
+				Severity: kube.SeverityError,
+				Source:   validate.DiagnosticSource,
+				Path:     "$",
+				Message:  "additional properties 'strigData' not allowed",
+			}},
+		},
+		"missing name": {
+			obj: kube.Object{
+				"apiVersion": "apps/v1",
+				"kind":       "Deployment",
+				"metadata":   map[string]any{"labels": map[string]any{"app.kubernetes.io/name": true}},
+			},
+			want: []kube.Diagnostic{
+				{
+					Severity: kube.SeverityError,
+					Source:   validate.DiagnosticSource,
+					Path:     "$.metadata",
+					Message:  "missing property 'name'",
+				},
+				{
+					Severity: kube.SeverityError,
+					Source:   validate.DiagnosticSource,
+					Path:     "$.metadata",
+					Message:  "missing property 'generateName'",
+				},
+				{
+					Severity: kube.SeverityError,
+					Source:   validate.DiagnosticSource,
+					Path:     "$.metadata.labels.'app.kubernetes.io/name'",
+					Message:  "got boolean, want string",
+				},
+			},
+		},
+		"kind without a schema": {
+			obj: kube.Object{
+				"apiVersion": "example.com/v1",
+				"kind":       "Widget",
+				"metadata":   map[string]any{"name": "test"},
+				"spec":       map[string]any{"size": 1},
+			},
+		},
+	}
+
+	v, err := validate.New(validate.WithSchemaDirs("testdata/schemas"))
+	require.NoError(t, err)
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			got := v.ValidateObject(&tc.obj)
+			assert.ElementsMatch(t, tc.want, got)
+		})
+	}
+}
+
+func TestValidator_SchemaDirs(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+
+	widgetSchema := `{
+  "type": "object",
+  "properties": {
+    "spec": {
+      "type": "object",
+      "properties": {
+        "size": {"type": "integer", "minimum": 1}
+      }
+    }
+  }
+}`
+
+	// kubeconform layout.
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "widget-example-v1.json"), []byte(widgetSchema), 0o600))
+
+	// CRDs-catalog layout.
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "example.com"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "example.com", "gadget_v1.json"), []byte(widgetSchema), 0o600))
+
+	// Earlier directories take precedence.
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "configmap-v1.json"), []byte(`{"type": "object"}`), 0o600))
+
+	v, err := validate.New(validate.WithSchemaDirs(dir, "testdata/schemas"))
+	require.NoError(t, err)
+
+	resources, err := kube.SplitYAML([]byte(`apiVersion: example.com/v1
+kind: Widget
+metadata:
+  name: a
+spec:
+  size: 0
+---
+apiVersion: example.com/v1
+kind: Gadget
+metadata:
+  name: b
+spec:
+  size: 0
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: c
+data:
+  port: 8080
+`))
+	require.NoError(t, err)
+
+	v.Validate(resources)
+
+	require.Len(t, resources, 3)
+	require.Len(t, resources[0].Diagnostics, 1)
+	assert.Equal(t, "$.spec.size", resources[0].Diagnostics[0].Path)
+	require.Len(t, resources[1].Diagnostics, 1)
+	assert.Equal(t, "$.spec.size", resources[1].Diagnostics[0].Path)
+	assert.Empty(t, resources[2].Diagnostics)
+
+	assert.Equal(t, 2, kube.CountDiagnostics(resources, kube.SeverityError))
+}
+
+func TestValidator_InvalidSchema(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "widget-example-v1.json"), []byte(`{"type": 1}`), 0o600))
+
+	v, err := validate.New(validate.WithSchemaDirs(dir))
+	require.NoError(t, err)
+
+	got := v.ValidateObject(&kube.Object{
+		"apiVersion": "example.com/v1",
+		"kind":       "Widget",
+		"metadata":   map[string]any{"name": "a"},
+	})
+
+	require.Len(t, got, 1)
+	assert.Equal(t, kube.SeverityWarning, got[0].Severity)
+	assert.Contains(t, got[0].Message, validate.ErrInvalidSchema.Error())
+}
+
+func TestValidator_WithoutSchemaDirs(t *testing.T) {
+	t.Parallel()
+
+	v, err := validate.New()
+	require.NoError(t, err)
+
+	// Only the common fields are checked.
+	got := v.ValidateObject(&kube.Object{
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+		"metadata":   map[string]any{"name": "test"},
+		"data":       map[string]any{"port": 8080},
+	})
+	assert.Empty(t, got)
+
+	got = v.ValidateObject(&kube.Object{
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+		"metadata":   map[string]any{},
+	})
+	assert.NotEmpty(t, got)
+}
+
+func TestConfig_Validate(t *testing.T) {
+	t.Parallel()
+
+	enabled := true
+
+	tcs := map[string]struct {
+		cfg     *validate.Config
+		wantErr error
+	}{
+		"nil": {},
+		"disabled": {
+			cfg: &validate.Config{},
+		},
+		"enabled with schema dirs": {
+			cfg: &validate.Config{Enabled: &enabled, SchemaDirs: []string{"schemas"}},
+		},
+		"enabled without schema dirs": {
+			cfg:     &validate.Config{Enabled: &enabled},
+			wantErr: validate.ErrNoSchemaDirs,
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			err := tc.cfg.Validate()
+			if tc.wantErr != nil {
+				require.ErrorIs(t, err, tc.wantErr)
+
+				_, err = validate.NewFromConfig(tc.cfg)
+				require.ErrorIs(t, err, tc.wantErr)
+
+				return
+			}
+
+			require.NoError(t, err)
+		})
+	}
+}